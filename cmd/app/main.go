@@ -1,12 +1,22 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	_ "itam_auth/docs"
 	"itam_auth/internal/config"
 	"itam_auth/internal/database"
+	"itam_auth/internal/grpcserver"
 	"itam_auth/internal/routes"
+	"itam_auth/internal/services/auth"
+	"itam_auth/internal/services/jwt"
+	"itam_auth/internal/services/mailer"
+	"itam_auth/internal/services/passkey"
+	"itam_auth/internal/services/ratelimit"
+	"itam_auth/internal/services/revocation"
 	"log"
+	"net"
+	"time"
 )
 
 // @title ITaM Auth API
@@ -25,7 +35,8 @@ import (
 // @license.name MIT
 // @license.url https://opensource.org/licenses/MIT
 const (
-	serverPort = ":8080"
+	serverPort        = ":8080"
+	keyReloadInterval = time.Minute // Как часто перечитывать ключи подписи из базы
 )
 
 func main() {
@@ -51,7 +62,62 @@ func main() {
 	defer storage.Close()
 	log.Println("Database successfully connected.")
 
-	router := routes.SetupRoutes(storage, appConfig.JwtSecretKey, appConfig)
+	keys, err := jwt.LoadKeyRing(appConfig)
+	if err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+	if err := keys.Reload(context.Background(), storage.GetSigningKeys); err != nil {
+		log.Fatalf("Failed to load JWT signing keys from database: %v", err)
+	}
+	go keys.Watch(context.Background(), storage.GetSigningKeys, keyReloadInterval)
+	log.Printf("JWT signing keys loaded (kid=%s).", keys.SigningKeyID())
+	if keys.SigningAlg() == jwt.AlgHS256 {
+		log.Println("Warning: tokens are signed with HS256, OpenID Connect sign-in is disabled until an RS256 or EdDSA key is active.")
+	}
+
+	if err := auth.ConfigureTokenClaims(appConfig.JwtClaims); err != nil {
+		log.Fatalf("Failed to configure access token claims: %v", err)
+	}
+
+	passkeys, err := passkey.NewService(storage, keys, appConfig)
+	if err != nil {
+		log.Fatalf("Failed to configure passkeys: %v", err)
+	}
+
+	mail, err := mailer.New(appConfig)
+	if err != nil {
+		log.Fatalf("Failed to configure mailer: %v", err)
+	}
+
+	limiter, err := ratelimit.New(storage, appConfig)
+	if err != nil {
+		log.Fatalf("Failed to configure rate limiting: %v", err)
+	}
+
+	// Хранилище отозванных токенов, общее для REST и gRPC API
+	revocations := revocation.NewStore(storage)
+
+	if appConfig.GRPCEnabled {
+		listener, err := net.Listen("tcp", appConfig.GRPCAddr)
+		if err != nil {
+			log.Fatalf("Failed to listen for gRPC on %s: %v", appConfig.GRPCAddr, err)
+		}
+		grpcServer, err := grpcserver.New(storage, keys, revocations, appConfig)
+		if err != nil {
+			log.Fatalf("Failed to configure gRPC server: %v", err)
+		}
+		if appConfig.GRPCTLSCertPath == "" {
+			log.Printf("Warning: gRPC server on %s accepts plaintext connections, do not expose it outside the internal network", appConfig.GRPCAddr)
+		}
+		go func() {
+			log.Printf("Starting gRPC server on %s", appConfig.GRPCAddr)
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Printf("gRPC server stopped: %v", err)
+			}
+		}()
+	}
+
+	router := routes.SetupRoutes(storage, keys, revocations, passkeys, mail, limiter, appConfig)
 	log.Printf("Starting server on port %s", serverPort)
 	if err := router.Run(serverPort); err != nil {
 		fmt.Printf("Error starting server: %v", err)
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"itam_auth/internal/config"
+	"itam_auth/internal/database"
+	"itam_auth/internal/models"
+	"itam_auth/internal/services/oidc"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+func main() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to parse config: %v", err)
+	}
+
+	flag.StringVar(&cfg.DBUser, "db-user", cfg.DBUser, "database user")
+	flag.StringVar(&cfg.DBPass, "db-pass", cfg.DBPass, "database password")
+	flag.StringVar(&cfg.DBHost, "db-host", cfg.DBHost, "database host")
+	flag.StringVar(&cfg.DBPort, "db-port", cfg.DBPort, "database port")
+	flag.StringVar(&cfg.DBName, "db-name", cfg.DBName, "database name")
+	action := flag.String("action", "list", "action: create or list")
+	id := flag.String("id", "", "client_id of the created client")
+	name := flag.String("name", "", "human-readable name of the created client")
+	redirectURIs := flag.String("redirect-uris", "", "comma-separated list of allowed redirect URIs")
+	scopes := flag.String("scopes", "", "comma-separated list of scopes the client may get as a service account: "+strings.Join(models.ServiceScopes, ", "))
+	public := flag.Bool("public", false, "create a public client (SPA, mobile app) without a secret")
+	flag.Parse()
+
+	dsn := fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		cfg.DBHost,
+		cfg.DBPort,
+		cfg.DBUser,
+		cfg.DBPass,
+		cfg.DBName,
+	)
+
+	storage, err := database.Initialize(dsn)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer storage.Close()
+
+	if err := applyAction(context.Background(), storage, *action, *id, *name, *redirectURIs, *scopes, *public); err != nil {
+		log.Fatalf("Client management failed: %v", err)
+	}
+}
+
+func applyAction(ctx context.Context, storage *database.Storage, action, id, name, redirectURIs, scopes string, public bool) error {
+	switch action {
+	case "create":
+		if id == "" || name == "" {
+			return fmt.Errorf("-id and -name are required for create")
+		}
+		if redirectURIs == "" && scopes == "" {
+			return fmt.Errorf("-redirect-uris or -scopes is required for create")
+		}
+		if public && scopes != "" {
+			return fmt.Errorf("public clients cannot be service accounts")
+		}
+		client := models.OAuthClient{
+			ID:           id,
+			Name:         name,
+			RedirectURIs: splitList(redirectURIs),
+			Scopes:       splitList(scopes),
+			CreatedAt:    time.Now(),
+		}
+		for _, scope := range client.Scopes {
+			if !slices.Contains(models.ServiceScopes, scope) {
+				return fmt.Errorf("unknown scope: %s", scope)
+			}
+		}
+		var secret string
+		if !public {
+			var hash string
+			var err error
+			secret, hash, err = oidc.GenerateClientSecret()
+			if err != nil {
+				return err
+			}
+			client.SecretHash = &hash
+		}
+		if _, err := storage.SaveOAuthClient(ctx, client); err != nil {
+			return err
+		}
+		fmt.Printf("Created client %s\n", client.ID)
+		if secret != "" {
+			fmt.Printf("client_secret: %s\nStore it now, it cannot be shown again\n", secret)
+		}
+	case "list":
+		clients, err := storage.ListOAuthClients(ctx)
+		if err != nil {
+			return err
+		}
+		for _, client := range clients {
+			kind := "confidential"
+			if client.IsPublic() {
+				kind = "public"
+			}
+			fmt.Printf("%-30s %-30s %-12s %-40s %s\n", client.ID, client.Name, kind,
+				strings.Join(client.Scopes, ","), strings.Join(client.RedirectURIs, ","))
+		}
+	default:
+		return fmt.Errorf("invalid action: %s. (Use 'create' or 'list')", action)
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
+
+// go run cmd/clients/main.go --action=create --id=points --name="ITaM Points" --redirect-uris=https://points.itam.com/callback
+// go run cmd/clients/main.go --action=create --id=itam-spa --name="ITaM SPA" --redirect-uris=http://localhost:5173/callback --public
+// go run cmd/clients/main.go --action=create --id=points-importer --name="Points importer" --scopes=achievements
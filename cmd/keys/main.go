@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"itam_auth/internal/config"
+	"itam_auth/internal/database"
+	"itam_auth/internal/models"
+	"itam_auth/internal/services/jwt"
+	"log"
+	"time"
+)
+
+// publishDelay - сколько ожидающий ключ должен провисеть в JWKS, прежде чем его можно сделать активным:
+// экземпляры сервиса перечитывают ключи раз в минуту, а проверяющие сервисы кэшируют JWKS до 5 минут
+const publishDelay = 10 * time.Minute
+
+func main() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to parse config: %v", err)
+	}
+
+	flag.StringVar(&cfg.DBUser, "db-user", cfg.DBUser, "database user")
+	flag.StringVar(&cfg.DBPass, "db-pass", cfg.DBPass, "database password")
+	flag.StringVar(&cfg.DBHost, "db-host", cfg.DBHost, "database host")
+	flag.StringVar(&cfg.DBPort, "db-port", cfg.DBPort, "database port")
+	flag.StringVar(&cfg.DBName, "db-name", cfg.DBName, "database name")
+	action := flag.String("action", "list", "action: generate, promote, retire, encrypt or list")
+	alg := flag.String("alg", cfg.JwtSigningAlg, "algorithm of the generated key: HS256, RS256 or EdDSA")
+	kid := flag.String("kid", "", "ID of the key to promote or retire")
+	force := flag.Bool("force", false, "promote the key without waiting until it is published")
+	flag.Parse()
+
+	kek, err := jwt.ParseKeyEncryptionKey(cfg.JwtKeyEncryptionKey)
+	if err != nil {
+		log.Fatalf("Invalid key encryption key: %v", err)
+	}
+
+	dsn := fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		cfg.DBHost,
+		cfg.DBPort,
+		cfg.DBUser,
+		cfg.DBPass,
+		cfg.DBName,
+	)
+
+	storage, err := database.Initialize(dsn)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer storage.Close()
+
+	if err := applyAction(context.Background(), storage, kek, *action, *alg, *kid, *force); err != nil {
+		log.Fatalf("Key management failed: %v", err)
+	}
+}
+
+func applyAction(ctx context.Context, storage *database.Storage, kek []byte, action, alg, kid string, force bool) error {
+	switch action {
+	case "generate":
+		key, err := jwt.GenerateSigningKey(alg)
+		if err != nil {
+			return err
+		}
+		if kek != nil {
+			if key, err = jwt.EncryptSigningKey(key, kek); err != nil {
+				return err
+			}
+		} else {
+			fmt.Println("Warning: JWT_KEY_ENCRYPTION_KEY is not set, the private key is stored unencrypted")
+		}
+		if err := storage.SavePendingSigningKey(ctx, key); err != nil {
+			return err
+		}
+		fmt.Printf("Generated %s key %s, it is published in JWKS but does not sign tokens yet.\n", key.Algorithm, key.ID)
+		fmt.Printf("Promote it in %s or later: --action=promote --kid=%s\n", publishDelay, key.ID)
+	case "promote":
+		if kid == "" {
+			return fmt.Errorf("-kid is required for promote")
+		}
+		key, err := findKey(ctx, storage, kid)
+		if err != nil {
+			return err
+		}
+		if key.State != models.KeyStatePending {
+			return fmt.Errorf("key %s is %s, only pending keys can be promoted", kid, key.State)
+		}
+		if wait := time.Until(key.CreatedAt.Add(publishDelay)); wait > 0 && !force {
+			return fmt.Errorf("key %s was published less than %s ago, verifiers may not have it yet; retry in %s or use -force",
+				kid, publishDelay, wait.Round(time.Second))
+		}
+		if err := storage.PromoteSigningKey(ctx, kid); err != nil {
+			return err
+		}
+		fmt.Printf("Key %s is now active, the previous active key is now retiring\n", kid)
+	case "retire":
+		if kid == "" {
+			return fmt.Errorf("-kid is required for retire")
+		}
+		if err := storage.RetireSigningKey(ctx, kid); err != nil {
+			return err
+		}
+		fmt.Printf("Key %s retired, tokens signed with it are no longer accepted\n", kid)
+	case "encrypt":
+		if kek == nil {
+			return fmt.Errorf("JWT_KEY_ENCRYPTION_KEY is required for encrypt")
+		}
+		keys, err := storage.ListSigningKeys(ctx)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if jwt.IsEncryptedSigningKey(key) || key.State == models.KeyStateRetired {
+				continue
+			}
+			encrypted, err := jwt.EncryptSigningKey(key, kek)
+			if err != nil {
+				return err
+			}
+			if err := storage.UpdateSigningKeyPrivateKey(ctx, key.ID, encrypted.PrivateKey); err != nil {
+				return err
+			}
+			fmt.Printf("Key %s encrypted\n", key.ID)
+		}
+	case "list":
+		keys, err := storage.ListSigningKeys(ctx)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			encrypted := "plain"
+			if jwt.IsEncryptedSigningKey(key) {
+				encrypted = "encrypted"
+			}
+			fmt.Printf("%-45s %-6s %-9s %-9s created %s\n", key.ID, key.Algorithm, key.State, encrypted, key.CreatedAt.Format("2006-01-02 15:04:05"))
+		}
+	default:
+		return fmt.Errorf("invalid action: %s. (Use 'generate', 'promote', 'retire', 'encrypt' or 'list')", action)
+	}
+	return nil
+}
+
+func findKey(ctx context.Context, storage *database.Storage, kid string) (models.SigningKey, error) {
+	keys, err := storage.ListSigningKeys(ctx)
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+	for _, key := range keys {
+		if key.ID == kid {
+			return key, nil
+		}
+	}
+	return models.SigningKey{}, fmt.Errorf("no signing key found with ID: %s", kid)
+}
+
+// go run cmd/keys/main.go --action=generate --alg=EdDSA
+// go run cmd/keys/main.go --action=promote --kid="<kid of the pending key>"
+// go run cmd/keys/main.go --action=retire --kid="<kid of the retiring key>"
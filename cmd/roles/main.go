@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"itam_auth/internal/config"
+	"itam_auth/internal/database"
+	"itam_auth/internal/models"
+	"log"
+
+	"github.com/google/uuid"
+)
+
+func main() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to parse config: %v", err)
+	}
+
+	flag.StringVar(&cfg.DBUser, "db-user", cfg.DBUser, "database user")
+	flag.StringVar(&cfg.DBPass, "db-pass", cfg.DBPass, "database password")
+	flag.StringVar(&cfg.DBHost, "db-host", cfg.DBHost, "database host")
+	flag.StringVar(&cfg.DBPort, "db-port", cfg.DBPort, "database port")
+	flag.StringVar(&cfg.DBName, "db-name", cfg.DBName, "database name")
+	action := flag.String("action", "list", "action: list, set-parent, attach-permission or grant-role")
+	role := flag.String("role", "", "name of the role to change or grant")
+	parent := flag.String("parent", "", "name of the parent role; empty removes the parent")
+	permission := flag.String("permission", "", "name of the permission to attach to the role")
+	user := flag.String("user", "", "email or ID of the user to grant the role to")
+	flag.Parse()
+
+	dsn := fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		cfg.DBHost,
+		cfg.DBPort,
+		cfg.DBUser,
+		cfg.DBPass,
+		cfg.DBName,
+	)
+
+	storage, err := database.Initialize(dsn)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer storage.Close()
+
+	if err := applyAction(context.Background(), storage, *action, *role, *parent, *permission, *user); err != nil {
+		log.Fatalf("Role management failed: %v", err)
+	}
+}
+
+func applyAction(ctx context.Context, storage *database.Storage, action, roleName, parentName, permissionName, userRef string) error {
+	switch action {
+	case "set-parent":
+		if roleName == "" {
+			return fmt.Errorf("-role is required for set-parent")
+		}
+		role, err := storage.GetRoleByName(ctx, roleName)
+		if err != nil {
+			return fmt.Errorf("role %s: %w", roleName, err)
+		}
+		var parentID *uuid.UUID
+		if parentName != "" {
+			parent, err := storage.GetRoleByName(ctx, parentName)
+			if err != nil {
+				return fmt.Errorf("role %s: %w", parentName, err)
+			}
+			parentID = &parent.ID
+		}
+		if err := storage.SetRoleParent(ctx, role.ID, parentID, models.AuditEvent{Details: map[string]string{"source": "cli"}}); err != nil {
+			return err
+		}
+		if parentName == "" {
+			fmt.Printf("Role %s no longer inherits from another role\n", roleName)
+		} else {
+			fmt.Printf("Role %s now inherits from %s\n", roleName, parentName)
+		}
+	case "attach-permission":
+		if roleName == "" || permissionName == "" {
+			return fmt.Errorf("-role and -permission are required for attach-permission")
+		}
+		role, err := storage.GetRoleByName(ctx, roleName)
+		if err != nil {
+			return fmt.Errorf("role %s: %w", roleName, err)
+		}
+		permission, err := storage.GetPermissionByName(ctx, permissionName)
+		if err != nil {
+			return fmt.Errorf("permission %s: %w", permissionName, err)
+		}
+		rolePermission := models.RolePermission{ID: uuid.New(), RoleID: role.ID, PermissionID: permission.ID}
+		if err := storage.AttachPermission(ctx, rolePermission, models.AuditEvent{Details: map[string]string{"source": "cli"}}); err != nil {
+			return err
+		}
+		fmt.Printf("Permission %s attached to role %s\n", permissionName, roleName)
+	case "grant-role":
+		if roleName == "" || userRef == "" {
+			return fmt.Errorf("-role and -user are required for grant-role")
+		}
+		role, err := storage.GetRoleByName(ctx, roleName)
+		if err != nil {
+			return fmt.Errorf("role %s: %w", roleName, err)
+		}
+		user, err := findUser(ctx, storage, userRef)
+		if err != nil {
+			return fmt.Errorf("user %s: %w", userRef, err)
+		}
+		userRole := models.UserRole{ID: uuid.New(), UserID: user.ID, RoleID: role.ID}
+		if err := storage.GrantRole(ctx, userRole, models.AuditEvent{Details: map[string]string{"source": "cli"}}); err != nil {
+			return err
+		}
+		fmt.Printf("Role %s granted to %s (%s)\n", roleName, user.Email, user.ID)
+	case "list":
+		roles, err := storage.GetAllRoles(ctx)
+		if err != nil {
+			return err
+		}
+		names := make(map[uuid.UUID]string, len(roles))
+		for _, role := range roles {
+			names[role.ID] = role.Name
+		}
+		for _, role := range roles {
+			fmt.Printf("%-30s %-36s %s\n", role.Name, role.ID, inheritsFrom(role, names))
+		}
+	default:
+		return fmt.Errorf("invalid action: %s. (Use 'list', 'set-parent', 'attach-permission' or 'grant-role')", action)
+	}
+	return nil
+}
+
+func inheritsFrom(role models.Role, names map[uuid.UUID]string) string {
+	if role.ParentID == nil {
+		return "-"
+	}
+	return names[*role.ParentID]
+}
+
+// findUser ищет пользователя по ID, а если ref не является UUID - по email
+func findUser(ctx context.Context, storage *database.Storage, ref string) (models.User, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return storage.GetUserByID(ctx, id)
+	}
+	return storage.GetUserByEmail(ctx, ref)
+}
@@ -1,6 +1,8 @@
 import { createRouter, createWebHistory } from 'vue-router'
 import AuthView from '../views/AuthView.vue'
 import RegisterView from '../views/RegisterView.vue'
+import VerifyEmailView from '../views/VerifyEmailView.vue'
+import ResetPasswordView from '../views/ResetPasswordView.vue'
 import ProfileView from '../views/ProfileView.vue'
 import AchievementsView from '../views/AchievementsView.vue'
 import RequestsView from '../views/RequestsView.vue'
@@ -10,6 +12,8 @@ const routes = [
   { path: '/', redirect: '/profile' },
   { path: '/auth', component: AuthView },
   { path: '/register', component: RegisterView },
+  { path: '/verify_email', component: VerifyEmailView },
+  { path: '/reset_password', component: ResetPasswordView },
   { path: '/profile', component: ProfileView },
   { path: '/achievements', component: AchievementsView },
   { path: '/requests', component: RequestsView },
@@ -21,15 +25,16 @@ const router = createRouter({
   routes
 })
 
-// Глобальный guard: если нет токена, редирект на /auth (кроме /auth и /register)
+// Глобальный guard: если нет токена, редирект на /auth (кроме страниц входа, регистрации, подтверждения email и сброса пароля)
 router.beforeEach((to, from, next) => {
-  const publicPages = ['/auth', '/register']
+  const publicPages = ['/auth', '/register', '/verify_email', '/reset_password']
   const authRequired = !publicPages.includes(to.path)
   const token = localStorage.getItem('token')
   if (authRequired && !token) {
     return next('/auth')
   }
-  if ((to.path === '/auth' || to.path === '/register') && token) {
+  // На /auth с параметрами OpenID Connect пускаем и вошедшего пользователя, чтобы выдать код приложению
+  if ((to.path === '/auth' || to.path === '/register') && token && !to.query.client_id) {
     return next('/profile')
   }
   next()
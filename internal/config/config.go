@@ -10,16 +10,51 @@ import (
 )
 
 type AppConfig struct {
-	DBUser         string
-	DBPass         string
-	DBHost         string
-	DBPort         string
-	DBName         string
-	MigrationsPath string
-	JwtSecretKey   string
-	UploadPath     string
-	MaxFileSize    int64
-	AllowedTypes   []string
+	DBUser                  string
+	DBPass                  string
+	DBHost                  string
+	DBPort                  string
+	DBName                  string
+	MigrationsPath          string
+	JwtSecretKey            string
+	JwtSigningAlg           string
+	JwtPrivateKeyPath       string
+	JwtKeyID                string
+	JwtConfigKeyState       string   // Состояние ключей из конфигурации: active, retiring или retired
+	JwtLegacyTokensUntil    string   // До какого момента (RFC 3339) принимаются токены без kid; пусто - без срока
+	JwtKeyEncryptionKey     string   // Ключ шифрования закрытых ключей в signing_keys, 32 байта в base64
+	JwtClaims               []string // Необязательные claims access-токена: roles, permissions, name, specification или none
+	UploadPath              string
+	MaxFileSize             int64
+	AllowedTypes            []string
+	OIDCIssuer              string
+	OIDCLoginURL            string
+	TelegramBotToken        string
+	TelegramAuthTTL         int64 // Сколько секунд после auth_date принимаются данные виджета Telegram
+	MFARequireAdmins        bool  // Требовать второй фактор у пользователей с admin_* правами
+	WebAuthnRPID            string
+	WebAuthnRPOrigins       []string // Адреса фронтенда, с которых разрешены ключи доступа
+	MailDriver              string   // smtp или log
+	MailFrom                string
+	MailOutputDir           string // Куда драйвер log сохраняет письма; пусто - только в лог
+	SMTPHost                string
+	SMTPPort                string
+	SMTPUsername            string
+	SMTPPassword            string
+	EmailVerifyURL          string // Страница фронтенда, на которую ведет ссылка из письма; токен добавляется в ?token=
+	RequireVerifiedEmail    bool   // Не пускать по паролю и ключу доступа, пока email не подтвержден
+	PasswordResetURL        string // Страница фронтенда для ссылки сброса пароля; токен добавляется в ?token=
+	RateLimitEnabled        bool
+	RateLimitStore          string   // memory или postgres
+	RateLimitPolicies       []string // Политики вида name=limit/period[:burst]
+	ForwardAuthCookie       string   // Cookie с access-токеном, которую принимает /auth/forward_auth
+	ForwardAuthCookieDomain string   // Домен cookie, общий для приложений за прокси; пусто - только текущий хост
+	ForwardAuthCookieSecure bool
+	GRPCEnabled             bool
+	GRPCAddr                string   // Адрес gRPC API для внутренних сервисов
+	GRPCTLSCertPath         string   // Сертификат и закрытый ключ TLS для gRPC API; пусто - открытый текст
+	GRPCTLSKeyPath          string
+	TrustedProxies          []string // Адреса и подсети прокси, которым доверяется X-Forwarded-For; пусто - никому
 }
 
 func LoadConfig() (*AppConfig, error) {
@@ -35,16 +70,53 @@ func LoadConfig() (*AppConfig, error) {
 	}
 
 	config := &AppConfig{
-		DBUser:         getEnv("DB_USER", "itam_user"),
-		DBPass:         getEnv("DB_PASSWORD", "itam_db"),
-		DBHost:         getEnv("DB_HOST", "localhost"),
-		DBPort:         getEnv("DB_PORT", "5432"),
-		DBName:         getEnv("DB_NAME", "itam_auth"),
-		MigrationsPath: getEnv("MIGRATIONS_PATH", ""),
-		JwtSecretKey:   getEnv("JWT_SECRET_KEY", ""),
-		UploadPath:     getEnv("UPLOAD_PATH", "./uploads"),
-		MaxFileSize:    getEnvInt64("MAX_FILE_SIZE", 10485760), // 10MB по умолчанию
-		AllowedTypes:   getEnvSlice("ALLOWED_TYPES", []string{".jpg", ".jpeg", ".png", ".gif", ".webp", ".pdf", ".doc", ".docx"}),
+		DBUser:               getEnv("DB_USER", "itam_user"),
+		DBPass:               getEnv("DB_PASSWORD", "itam_db"),
+		DBHost:               getEnv("DB_HOST", "localhost"),
+		DBPort:               getEnv("DB_PORT", "5432"),
+		DBName:               getEnv("DB_NAME", "itam_auth"),
+		MigrationsPath:       getEnv("MIGRATIONS_PATH", ""),
+		JwtSecretKey:         getEnv("JWT_SECRET_KEY", ""),
+		JwtSigningAlg:        getEnv("JWT_SIGNING_ALG", "HS256"),
+		JwtPrivateKeyPath:    getEnv("JWT_PRIVATE_KEY_PATH", ""),
+		JwtKeyID:             getEnv("JWT_KEY_ID", ""),
+		JwtConfigKeyState:    getEnv("JWT_CONFIG_KEY_STATE", "active"),
+		JwtLegacyTokensUntil: getEnv("JWT_LEGACY_TOKENS_UNTIL", ""),
+		JwtKeyEncryptionKey:  getEnv("JWT_KEY_ENCRYPTION_KEY", ""),
+		JwtClaims:            getEnvSlice("JWT_CLAIMS", []string{"roles", "permissions", "name", "specification"}),
+		UploadPath:           getEnv("UPLOAD_PATH", "./uploads"),
+		MaxFileSize:          getEnvInt64("MAX_FILE_SIZE", 10485760), // 10MB по умолчанию
+		AllowedTypes:         getEnvSlice("ALLOWED_TYPES", []string{".jpg", ".jpeg", ".png", ".gif", ".webp", ".pdf", ".doc", ".docx"}),
+		OIDCIssuer:           getEnv("OIDC_ISSUER", "http://localhost:8080"),
+		OIDCLoginURL:         getEnv("OIDC_LOGIN_URL", "http://localhost:5173/auth"),
+		TelegramBotToken:     getEnv("TELEGRAM_BOT_TOKEN", ""),
+		TelegramAuthTTL:      getEnvInt64("TELEGRAM_AUTH_TTL", 86400), // сутки по умолчанию
+		MFARequireAdmins:     getEnvBool("MFA_REQUIRE_ADMINS", false),
+		WebAuthnRPID:         getEnv("WEBAUTHN_RP_ID", "localhost"),
+		WebAuthnRPOrigins:    getEnvSlice("WEBAUTHN_RP_ORIGINS", []string{"http://localhost:5173"}),
+		MailDriver:           getEnv("MAIL_DRIVER", "log"),
+		MailFrom:             getEnv("MAIL_FROM", "ITaM <no-reply@itam.local>"),
+		MailOutputDir:        getEnv("MAIL_OUTPUT_DIR", ""),
+		SMTPHost:             getEnv("SMTP_HOST", ""),
+		SMTPPort:             getEnv("SMTP_PORT", "587"),
+		SMTPUsername:         getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:         getEnv("SMTP_PASSWORD", ""),
+		EmailVerifyURL:       getEnv("EMAIL_VERIFY_URL", "http://localhost:5173/verify_email"),
+		RequireVerifiedEmail: getEnvBool("REQUIRE_EMAIL_VERIFICATION", false),
+		PasswordResetURL:     getEnv("PASSWORD_RESET_URL", "http://localhost:5173/reset_password"),
+		RateLimitEnabled:     getEnvBool("RATE_LIMIT_ENABLED", true),
+		RateLimitStore:       getEnv("RATE_LIMIT_STORE", "memory"),
+		RateLimitPolicies: getEnvSlice("RATE_LIMIT_POLICIES", []string{
+			"default=300/m:100", "login=10/m", "register=5/h", "email=5/h", "upload=30/h:10", "user=600/m:200",
+		}),
+		ForwardAuthCookie:       getEnv("FORWARD_AUTH_COOKIE", "itam_session"),
+		ForwardAuthCookieDomain: getEnv("FORWARD_AUTH_COOKIE_DOMAIN", ""),
+		ForwardAuthCookieSecure: getEnvBool("FORWARD_AUTH_COOKIE_SECURE", true),
+		GRPCEnabled:             getEnvBool("GRPC_ENABLED", false),
+		GRPCAddr:                getEnv("GRPC_ADDR", "127.0.0.1:9090"),
+		GRPCTLSCertPath:         getEnv("GRPC_TLS_CERT_PATH", ""),
+		GRPCTLSKeyPath:          getEnv("GRPC_TLS_KEY_PATH", ""),
+		TrustedProxies:          getEnvSlice("TRUSTED_PROXIES", nil),
 	}
 
 	if err := validateConfig(config); err != nil {
@@ -68,8 +140,8 @@ func validateConfig(cfg *AppConfig) error {
 	if cfg.MigrationsPath == "" {
 		missingVars = append(missingVars, "MIGRATIONS_PATH")
 	}
-	if cfg.JwtSecretKey == "" {
-		missingVars = append(missingVars, "JWT_SECRET_KEY")
+	if cfg.MailDriver == "smtp" && cfg.SMTPHost == "" {
+		missingVars = append(missingVars, "SMTP_HOST")
 	}
 	if cfg.UploadPath == "" {
 		missingVars = append(missingVars, "UPLOAD_PATH")
@@ -104,6 +176,16 @@ func getEnvInt64(key string, defaultValue int64) int64 {
 	return defaultValue
 }
 
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists && value != "" {
+		boolValue, err := strconv.ParseBool(value)
+		if err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvSlice(key string, defaultValue []string) []string {
 	if value, exists := os.LookupEnv(key); exists && value != "" {
 		return strings.Split(value, ",")
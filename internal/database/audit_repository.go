@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"itam_auth/internal/models"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	saveAuditEventQuery = `INSERT INTO admin_audit_log (id, actor_id, action, target_type, target_id, details, ip, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)`
+	getAuditEventsQuery = `SELECT id, actor_id, action, target_type, target_id, details, COALESCE(ip, ''), created_at
+	FROM admin_audit_log
+	WHERE ($1::uuid IS NULL OR target_id = $1)
+	ORDER BY created_at DESC, id
+	LIMIT $2 OFFSET $3`
+)
+
+// withAudit выполняет изменение и записывает его в журнал аудита в одной транзакции: изменение без
+// записи в журнале не сохраняется. change может дополнить event, например прежним названием роли
+func (s *Storage) withAudit(ctx context.Context, event models.AuditEvent, change func(tx *sql.Tx, event *models.AuditEvent) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("Failed to rollback transaction for %s (target=%s): %v", event.Action, event.TargetID, err)
+		}
+	}()
+
+	if event.Details == nil {
+		event.Details = map[string]string{}
+	}
+	if err := change(tx, &event); err != nil {
+		return err
+	}
+	if err := saveAuditEvent(ctx, tx, event); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func saveAuditEvent(ctx context.Context, tx *sql.Tx, event models.AuditEvent) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	details, err := json.Marshal(event.Details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, saveAuditEventQuery,
+		event.ID,
+		event.ActorID,
+		event.Action,
+		event.TargetType,
+		event.TargetID,
+		details,
+		event.IP,
+		event.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save audit event: %w", err)
+	}
+	return nil
+}
+
+// GetAuditEvents возвращает записи журнала аудита, новые первыми. targetID ограничивает выборку
+// изменениями одной роли, права или пользователя
+func (s *Storage) GetAuditEvents(ctx context.Context, targetID *uuid.UUID, limit, offset int) ([]models.AuditEvent, error) {
+	rows, err := s.db.QueryContext(ctx, getAuditEventsQuery, targetID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.AuditEvent{}
+	for rows.Next() {
+		var event models.AuditEvent
+		var actorID uuid.NullUUID
+		var details []byte
+		err := rows.Scan(&event.ID, &actorID, &event.Action, &event.TargetType, &event.TargetID, &details, &event.IP, &event.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		if actorID.Valid {
+			event.ActorID = &actorID.UUID
+		}
+		if err := json.Unmarshal(details, &event.Details); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal audit details: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"itam_auth/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	saveEmailVerificationTokenQuery = `INSERT INTO email_verification_tokens (id, user_id, email, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)`
+	useEmailVerificationTokenQuery = `UPDATE email_verification_tokens SET used_at = $1
+		WHERE id = $2 AND user_id = $3 AND used_at IS NULL AND expires_at > $1`
+	deleteExpiredEmailVerificationTokensQuery = `DELETE FROM email_verification_tokens WHERE expires_at < $1`
+)
+
+// ErrEmailVerificationTokenInvalid - токен не выдавался, уже использован или истек
+var ErrEmailVerificationTokenInvalid = errors.New("email verification token is invalid or has already been used")
+
+func (s *Storage) SaveEmailVerificationToken(ctx context.Context, token models.EmailVerificationToken) error {
+	_, err := s.db.ExecContext(ctx, saveEmailVerificationTokenQuery,
+		token.ID,
+		token.UserID,
+		token.Email,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save email verification token: %w", err)
+	}
+	return nil
+}
+
+// UseEmailVerificationToken погашает токен подтверждения. Каждый токен принимается один раз
+func (s *Storage) UseEmailVerificationToken(ctx context.Context, id, userID uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx, useEmailVerificationTokenQuery, time.Now(), id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to use email verification token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrEmailVerificationTokenInvalid
+	}
+	return nil
+}
+
+func (s *Storage) DeleteExpiredEmailVerificationTokens(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, deleteExpiredEmailVerificationTokensQuery, time.Now()); err != nil {
+		return fmt.Errorf("failed to delete expired email verification tokens: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"itam_auth/internal/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	getLoginThrottlesQuery = `SELECT key, kind, failed_attempts, last_failed_at, locked_until
+		FROM login_throttles WHERE key = ANY($1)`
+	recordLoginFailureQuery = `INSERT INTO login_throttles (key, kind, failed_attempts, last_failed_at) VALUES ($1, $2, 1, $3)
+		ON CONFLICT (key) DO UPDATE SET
+			failed_attempts = CASE WHEN login_throttles.last_failed_at < $4 THEN 1 ELSE login_throttles.failed_attempts + 1 END,
+			last_failed_at = EXCLUDED.last_failed_at
+		RETURNING failed_attempts`
+	setLoginLockQuery             = `UPDATE login_throttles SET locked_until = $1 WHERE key = $2`
+	deleteLoginThrottleQuery      = `DELETE FROM login_throttles WHERE key = $1`
+	listActiveLoginThrottlesQuery = `SELECT key, kind, failed_attempts, last_failed_at, locked_until
+		FROM login_throttles WHERE last_failed_at >= $1 OR locked_until > $2 ORDER BY last_failed_at DESC`
+	deleteStaleLoginThrottlesQuery = `DELETE FROM login_throttles WHERE last_failed_at < $1 AND (locked_until IS NULL OR locked_until < $2)`
+)
+
+// ErrLoginThrottleNotFound - по этому ключу неудачных попыток нет
+var ErrLoginThrottleNotFound = errors.New("login throttle not found")
+
+func scanLoginThrottle(row interface{ Scan(...any) error }) (models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	var lockedUntil sql.NullTime
+	if err := row.Scan(&throttle.Key, &throttle.Kind, &throttle.FailedAttempts, &throttle.LastFailedAt, &lockedUntil); err != nil {
+		return models.LoginThrottle{}, err
+	}
+	if lockedUntil.Valid {
+		throttle.LockedUntil = &lockedUntil.Time
+	}
+	return throttle, nil
+}
+
+// GetLoginThrottles возвращает счетчики по ключам. Ключей без неудачных попыток в ответе нет
+func (s *Storage) GetLoginThrottles(ctx context.Context, keys []string) ([]models.LoginThrottle, error) {
+	rows, err := s.db.QueryContext(ctx, getLoginThrottlesQuery, pq.Array(keys))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login throttles: %w", err)
+	}
+	defer rows.Close()
+
+	var throttles []models.LoginThrottle
+	for rows.Next() {
+		throttle, err := scanLoginThrottle(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan login throttle: %w", err)
+		}
+		throttles = append(throttles, throttle)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate login throttles: %w", err)
+	}
+	return throttles, nil
+}
+
+// RecordLoginFailure учитывает неудачную попытку и возвращает число неудач подряд. Если с прошлой
+// неудачи прошло больше window, счет начинается заново
+func (s *Storage) RecordLoginFailure(ctx context.Context, key, kind string, window time.Duration) (int, error) {
+	now := time.Now()
+	var failures int
+	if err := s.db.QueryRowContext(ctx, recordLoginFailureQuery, key, kind, now, now.Add(-window)).Scan(&failures); err != nil {
+		return 0, fmt.Errorf("failed to record login failure: %w", err)
+	}
+	return failures, nil
+}
+
+func (s *Storage) SetLoginLock(ctx context.Context, key string, lockedUntil time.Time) error {
+	if _, err := s.db.ExecContext(ctx, setLoginLockQuery, lockedUntil, key); err != nil {
+		return fmt.Errorf("failed to set login lock: %w", err)
+	}
+	return nil
+}
+
+// DeleteLoginThrottle сбрасывает счетчик и снимает блокировку
+func (s *Storage) DeleteLoginThrottle(ctx context.Context, key string) error {
+	result, err := s.db.ExecContext(ctx, deleteLoginThrottleQuery, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete login throttle: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrLoginThrottleNotFound
+	}
+	return nil
+}
+
+// ListActiveLoginThrottles возвращает действующие блокировки и счетчики с неудачами за последнее window
+func (s *Storage) ListActiveLoginThrottles(ctx context.Context, window time.Duration) ([]models.LoginThrottle, error) {
+	now := time.Now()
+	rows, err := s.db.QueryContext(ctx, listActiveLoginThrottlesQuery, now.Add(-window), now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list login throttles: %w", err)
+	}
+	defer rows.Close()
+
+	throttles := []models.LoginThrottle{}
+	for rows.Next() {
+		throttle, err := scanLoginThrottle(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan login throttle: %w", err)
+		}
+		throttles = append(throttles, throttle)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate login throttles: %w", err)
+	}
+	return throttles, nil
+}
+
+// DeleteStaleLoginThrottles удаляет счетчики без неудач за последнее window и без действующей блокировки
+func (s *Storage) DeleteStaleLoginThrottles(ctx context.Context, window time.Duration) error {
+	now := time.Now()
+	if _, err := s.db.ExecContext(ctx, deleteStaleLoginThrottlesQuery, now.Add(-window), now); err != nil {
+		return fmt.Errorf("failed to delete stale login throttles: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"itam_auth/internal/models"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	startMFAEnrollmentQuery = `INSERT INTO user_mfa (user_id, totp_secret, created_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET totp_secret = EXCLUDED.totp_secret, last_used_step = 0,
+			failed_attempts = 0, locked_until = NULL, created_at = EXCLUDED.created_at
+		WHERE user_mfa.confirmed_at IS NULL`
+	getUserMFAQuery = `SELECT user_id, totp_secret, last_used_step, failed_attempts, locked_until, confirmed_at, created_at
+		FROM user_mfa WHERE user_id = $1`
+	confirmMFAQuery = `UPDATE user_mfa SET confirmed_at = $1, last_used_step = $2, failed_attempts = 0, locked_until = NULL
+		WHERE user_id = $3 AND confirmed_at IS NULL AND last_used_step < $2`
+	acceptTOTPStepQuery = `UPDATE user_mfa SET last_used_step = $1, failed_attempts = 0, locked_until = NULL
+		WHERE user_id = $2 AND last_used_step < $1`
+	recordMFAFailureQuery = `UPDATE user_mfa SET failed_attempts = failed_attempts + 1,
+		locked_until = CASE WHEN failed_attempts + 1 >= $1 THEN $2 ELSE locked_until END
+		WHERE user_id = $3`
+	resetMFAFailuresQuery    = `UPDATE user_mfa SET failed_attempts = 0, locked_until = NULL WHERE user_id = $1`
+	deleteUserMFAQuery       = `DELETE FROM user_mfa WHERE user_id = $1`
+	saveRecoveryCodeQuery    = `INSERT INTO mfa_recovery_codes (id, user_id, code_hash, created_at) VALUES ($1, $2, $3, $4)`
+	deleteRecoveryCodesQuery = `DELETE FROM mfa_recovery_codes WHERE user_id = $1`
+	useRecoveryCodeQuery     = `UPDATE mfa_recovery_codes SET used_at = $1 WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL`
+	countRecoveryCodesQuery  = `SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+)
+
+var (
+	// ErrMFANotFound - пользователь не подключал второй фактор
+	ErrMFANotFound = errors.New("mfa is not configured")
+	// ErrMFAAlreadyEnabled - второй фактор уже подключен, начать подключение заново нельзя
+	ErrMFAAlreadyEnabled = errors.New("mfa is already enabled")
+	// ErrTOTPCodeReused - код этого интервала уже был принят
+	ErrTOTPCodeReused = errors.New("totp code has already been used")
+	// ErrRecoveryCodeInvalid - код восстановления не найден или уже использован
+	ErrRecoveryCodeInvalid = errors.New("invalid recovery code")
+)
+
+// StartMFAEnrollment сохраняет новый секрет неподтвержденного подключения
+func (s *Storage) StartMFAEnrollment(ctx context.Context, userID uuid.UUID, secret string) error {
+	result, err := s.db.ExecContext(ctx, startMFAEnrollmentQuery, userID, secret, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to start mfa enrollment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrMFAAlreadyEnabled
+	}
+	return nil
+}
+
+func (s *Storage) GetUserMFA(ctx context.Context, userID uuid.UUID) (models.UserMFA, error) {
+	var mfa models.UserMFA
+	var lockedUntil, confirmedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, getUserMFAQuery, userID).Scan(
+		&mfa.UserID,
+		&mfa.TOTPSecret,
+		&mfa.LastUsedStep,
+		&mfa.FailedAttempts,
+		&lockedUntil,
+		&confirmedAt,
+		&mfa.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return mfa, ErrMFANotFound
+		}
+		return mfa, fmt.Errorf("failed to get user mfa: %w", err)
+	}
+	if lockedUntil.Valid {
+		mfa.LockedUntil = &lockedUntil.Time
+	}
+	if confirmedAt.Valid {
+		mfa.ConfirmedAt = &confirmedAt.Time
+	}
+	return mfa, nil
+}
+
+// ConfirmMFA завершает подключение первым принятым кодом и заменяет коды восстановления
+func (s *Storage) ConfirmMFA(ctx context.Context, userID uuid.UUID, step int64, codes []models.RecoveryCode) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("Failed to rollback transaction for mfa confirmation (user_id=%s): %v", userID, err)
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx, confirmMFAQuery, time.Now(), step, userID)
+	if err != nil {
+		return fmt.Errorf("failed to confirm mfa: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrTOTPCodeReused
+	}
+
+	if err := saveRecoveryCodes(ctx, tx, userID, codes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// AcceptTOTPStep запоминает интервал принятого кода. Код того же или более раннего интервала
+// повторно не принимается
+func (s *Storage) AcceptTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	result, err := s.db.ExecContext(ctx, acceptTOTPStepQuery, step, userID)
+	if err != nil {
+		return fmt.Errorf("failed to accept totp code: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrTOTPCodeReused
+	}
+	return nil
+}
+
+// RecordMFAFailure учитывает неверный код. После maxAttempts неудач подряд ввод блокируется до lockedUntil
+func (s *Storage) RecordMFAFailure(ctx context.Context, userID uuid.UUID, maxAttempts int, lockedUntil time.Time) error {
+	if _, err := s.db.ExecContext(ctx, recordMFAFailureQuery, maxAttempts, lockedUntil, userID); err != nil {
+		return fmt.Errorf("failed to record mfa failure: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) ResetMFAFailures(ctx context.Context, userID uuid.UUID) error {
+	if _, err := s.db.ExecContext(ctx, resetMFAFailuresQuery, userID); err != nil {
+		return fmt.Errorf("failed to reset mfa failures: %w", err)
+	}
+	return nil
+}
+
+// DeleteUserMFA отключает второй фактор вместе с кодами восстановления
+func (s *Storage) DeleteUserMFA(ctx context.Context, userID uuid.UUID) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("Failed to rollback transaction for mfa removal (user_id=%s): %v", userID, err)
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, deleteRecoveryCodesQuery, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, deleteUserMFAQuery, userID); err != nil {
+		return fmt.Errorf("failed to delete user mfa: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// UseRecoveryCode погашает код восстановления. Каждый код принимается один раз
+func (s *Storage) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error {
+	result, err := s.db.ExecContext(ctx, useRecoveryCodeQuery, time.Now(), userID, codeHash)
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrRecoveryCodeInvalid
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes выдает новый набор кодов восстановления взамен прежних
+func (s *Storage) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codes []models.RecoveryCode) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("Failed to rollback transaction for recovery codes (user_id=%s): %v", userID, err)
+		}
+	}()
+
+	if err := saveRecoveryCodes(ctx, tx, userID, codes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	if err := s.db.QueryRowContext(ctx, countRecoveryCodesQuery, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+func saveRecoveryCodes(ctx context.Context, tx *sql.Tx, userID uuid.UUID, codes []models.RecoveryCode) error {
+	if _, err := tx.ExecContext(ctx, deleteRecoveryCodesQuery, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	for _, code := range codes {
+		if _, err := tx.ExecContext(ctx, saveRecoveryCodeQuery, code.ID, userID, code.CodeHash, code.CreatedAt); err != nil {
+			return fmt.Errorf("failed to save recovery code: %w", err)
+		}
+	}
+	return nil
+}
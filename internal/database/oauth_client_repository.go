@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"itam_auth/internal/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	saveOAuthClientQuery = `INSERT INTO oauth_clients (id, name, secret_hash, redirect_uris, scopes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+	getOAuthClientQuery        = `SELECT id, name, secret_hash, redirect_uris, scopes, created_at FROM oauth_clients WHERE id = $1`
+	listOAuthClientsQuery      = `SELECT id, name, secret_hash, redirect_uris, scopes, created_at FROM oauth_clients ORDER BY created_at`
+	saveAuthorizationCodeQuery = `INSERT INTO oauth_authorization_codes
+		(code_hash, client_id, user_id, redirect_uri, scope, nonce, code_challenge, auth_time, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	consumeAuthorizationCodeQuery = `DELETE FROM oauth_authorization_codes WHERE code_hash = $1
+		RETURNING code_hash, client_id, user_id, redirect_uri, scope, nonce, code_challenge, auth_time, expires_at, created_at`
+	deleteExpiredAuthorizationCodesQuery = `DELETE FROM oauth_authorization_codes WHERE expires_at < $1`
+)
+
+func scanOAuthClient(row interface{ Scan(...any) error }) (models.OAuthClient, error) {
+	var client models.OAuthClient
+	var secretHash sql.NullString
+	err := row.Scan(
+		&client.ID,
+		&client.Name,
+		&secretHash,
+		pq.Array(&client.RedirectURIs),
+		pq.Array(&client.Scopes),
+		&client.CreatedAt,
+	)
+	if err != nil {
+		return models.OAuthClient{}, err
+	}
+	if secretHash.Valid {
+		client.SecretHash = &secretHash.String
+	}
+	return client, nil
+}
+
+func (s *Storage) SaveOAuthClient(ctx context.Context, client models.OAuthClient) (string, error) {
+	_, err := s.db.ExecContext(ctx, saveOAuthClientQuery,
+		client.ID,
+		client.Name,
+		client.SecretHash,
+		pq.Array(client.RedirectURIs),
+		pq.Array(client.Scopes),
+		client.CreatedAt,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to save OAuth client: %w", err)
+	}
+	return client.ID, nil
+}
+
+func (s *Storage) GetOAuthClient(ctx context.Context, id string) (models.OAuthClient, error) {
+	client, err := scanOAuthClient(s.db.QueryRowContext(ctx, getOAuthClientQuery, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return client, fmt.Errorf("client not found")
+		}
+		return client, fmt.Errorf("failed to get OAuth client: %w", err)
+	}
+	return client, nil
+}
+
+func (s *Storage) ListOAuthClients(ctx context.Context) ([]models.OAuthClient, error) {
+	rows, err := s.db.QueryContext(ctx, listOAuthClientsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list OAuth clients: %w", err)
+	}
+	defer rows.Close()
+
+	var clients []models.OAuthClient
+	for rows.Next() {
+		client, err := scanOAuthClient(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan OAuth client: %w", err)
+		}
+		clients = append(clients, client)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return clients, nil
+}
+
+func (s *Storage) SaveAuthorizationCode(ctx context.Context, code models.AuthorizationCode) error {
+	_, err := s.db.ExecContext(ctx, saveAuthorizationCodeQuery,
+		code.CodeHash,
+		code.ClientID,
+		code.UserID,
+		code.RedirectURI,
+		code.Scope,
+		code.Nonce,
+		code.CodeChallenge,
+		code.AuthTime,
+		code.ExpiresAt,
+		code.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save authorization code: %w", err)
+	}
+	return nil
+}
+
+// ConsumeAuthorizationCode удаляет код и возвращает его данные. Повторный вызов для того же кода
+// вернет ошибку, поэтому код можно обменять на токены только один раз
+func (s *Storage) ConsumeAuthorizationCode(ctx context.Context, codeHash string) (models.AuthorizationCode, error) {
+	var code models.AuthorizationCode
+	var nonce sql.NullString
+	err := s.db.QueryRowContext(ctx, consumeAuthorizationCodeQuery, codeHash).Scan(
+		&code.CodeHash,
+		&code.ClientID,
+		&code.UserID,
+		&code.RedirectURI,
+		&code.Scope,
+		&nonce,
+		&code.CodeChallenge,
+		&code.AuthTime,
+		&code.ExpiresAt,
+		&code.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return code, fmt.Errorf("authorization code not found")
+		}
+		return code, fmt.Errorf("failed to consume authorization code: %w", err)
+	}
+	code.Nonce = nonce.String
+	return code, nil
+}
+
+func (s *Storage) DeleteExpiredAuthorizationCodes(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, deleteExpiredAuthorizationCodesQuery, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to delete expired authorization codes: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"itam_auth/internal/models"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	savePasswordResetTokenQuery = `INSERT INTO password_reset_tokens (token_hash, user_id, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)`
+	usePasswordResetTokenQuery = `UPDATE password_reset_tokens SET used_at = $1
+		WHERE token_hash = $2 AND used_at IS NULL AND expires_at > $1 RETURNING user_id`
+	deleteUserPasswordResetTokensQuery    = `DELETE FROM password_reset_tokens WHERE user_id = $1 AND used_at IS NULL`
+	deleteExpiredPasswordResetTokensQuery = `DELETE FROM password_reset_tokens WHERE expires_at < $1`
+)
+
+// ErrPasswordResetTokenInvalid - токен сброса не выдавался, уже использован или истек
+var ErrPasswordResetTokenInvalid = errors.New("password reset token is invalid or has already been used")
+
+func (s *Storage) SavePasswordResetToken(ctx context.Context, token models.PasswordResetToken) error {
+	_, err := s.db.ExecContext(ctx, savePasswordResetTokenQuery,
+		token.TokenHash,
+		token.UserID,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save password reset token: %w", err)
+	}
+	return nil
+}
+
+// ResetPassword погашает токен сброса и устанавливает новый пароль. Остальные неиспользованные
+// токены пользователя удаляются. Возвращает ID пользователя, которому принадлежал токен
+func (s *Storage) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (uuid.UUID, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("Failed to rollback transaction for password reset: %v", err)
+		}
+	}()
+
+	now := time.Now()
+	var userID uuid.UUID
+	if err := tx.QueryRowContext(ctx, usePasswordResetTokenQuery, now, tokenHash).Scan(&userID); err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, ErrPasswordResetTokenInvalid
+		}
+		return uuid.Nil, fmt.Errorf("failed to use password reset token: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, updatePasswordQuery, passwordHash, now, userID); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to update password: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, deleteUserPasswordResetTokensQuery, userID); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to delete password reset tokens: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return userID, nil
+}
+
+func (s *Storage) DeleteExpiredPasswordResetTokens(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, deleteExpiredPasswordResetTokensQuery, time.Now()); err != nil {
+		return fmt.Errorf("failed to delete expired password reset tokens: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"itam_auth/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	savePersonalTokenQuery = `INSERT INTO personal_access_tokens (id, user_id, name, token_hash, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	getPersonalTokenByHashQuery = `SELECT id, user_id, name, token_hash, scopes, expires_at, last_used_at, last_used_ip, revoked_at, created_at
+		FROM personal_access_tokens WHERE token_hash = $1`
+	getPersonalTokensByUserQuery = `SELECT id, user_id, name, token_hash, scopes, expires_at, last_used_at, last_used_ip, revoked_at, created_at
+		FROM personal_access_tokens WHERE user_id = $1 AND revoked_at IS NULL ORDER BY created_at`
+	// Время использования пишется не чаще раза в минуту, чтобы скрипт с частыми запросами не нагружал базу
+	touchPersonalTokenQuery = `UPDATE personal_access_tokens SET last_used_at = $1, last_used_ip = COALESCE(NULLIF($2, ''), last_used_ip)
+		WHERE id = $3 AND (last_used_at IS NULL OR last_used_at < $1 - INTERVAL '1 minute')`
+	revokePersonalTokenQuery = `UPDATE personal_access_tokens SET revoked_at = $1
+		WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL`
+	revokeUserPersonalTokensQuery = `UPDATE personal_access_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL`
+	countPersonalTokensQuery      = `SELECT COUNT(*) FROM personal_access_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2`
+)
+
+// ErrPersonalTokenNotFound - токена нет, он принадлежит другому пользователю или уже отозван
+var ErrPersonalTokenNotFound = errors.New("personal access token not found")
+
+func scanPersonalToken(row interface{ Scan(...any) error }) (models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	var lastUsedAt, revokedAt sql.NullTime
+	var lastUsedIP sql.NullString
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.TokenHash,
+		pq.Array(&token.Scopes),
+		&token.ExpiresAt,
+		&lastUsedAt,
+		&lastUsedIP,
+		&revokedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return models.PersonalAccessToken{}, err
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	if lastUsedIP.Valid {
+		token.LastUsedIP = &lastUsedIP.String
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return token, nil
+}
+
+func (s *Storage) SavePersonalToken(ctx context.Context, token models.PersonalAccessToken) error {
+	_, err := s.db.ExecContext(ctx, savePersonalTokenQuery,
+		token.ID,
+		token.UserID,
+		token.Name,
+		token.TokenHash,
+		pq.Array(token.Scopes),
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save personal access token: %w", err)
+	}
+	return nil
+}
+
+// GetPersonalTokenByHash возвращает токен по SHA-256, в том числе отозванный или просроченный
+func (s *Storage) GetPersonalTokenByHash(ctx context.Context, tokenHash string) (models.PersonalAccessToken, error) {
+	token, err := scanPersonalToken(s.db.QueryRowContext(ctx, getPersonalTokenByHashQuery, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return token, ErrPersonalTokenNotFound
+		}
+		return token, fmt.Errorf("failed to get personal access token: %w", err)
+	}
+	return token, nil
+}
+
+// GetPersonalTokensByUser возвращает неотозванные токены пользователя, включая просроченные
+func (s *Storage) GetPersonalTokensByUser(ctx context.Context, userID uuid.UUID) ([]models.PersonalAccessToken, error) {
+	rows, err := s.db.QueryContext(ctx, getPersonalTokensByUserQuery, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get personal access tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []models.PersonalAccessToken
+	for rows.Next() {
+		token, err := scanPersonalToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan personal access token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate personal access tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// CountActivePersonalTokens считает неотозванные и непросроченные токены пользователя
+func (s *Storage) CountActivePersonalTokens(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	if err := s.db.QueryRowContext(ctx, countPersonalTokensQuery, userID, time.Now()).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count personal access tokens: %w", err)
+	}
+	return count, nil
+}
+
+// TouchPersonalToken запоминает время и адрес последнего использования токена. Пустой ip (токен проверяет
+// другой сервис через /introspect) оставляет прежний адрес
+func (s *Storage) TouchPersonalToken(ctx context.Context, id uuid.UUID, usedAt time.Time, ip string) error {
+	if _, err := s.db.ExecContext(ctx, touchPersonalTokenQuery, usedAt, ip, id); err != nil {
+		return fmt.Errorf("failed to update personal access token usage: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) RevokePersonalToken(ctx context.Context, userID, id uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx, revokePersonalTokenQuery, time.Now(), id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke personal access token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrPersonalTokenNotFound
+	}
+	return nil
+}
+
+// RevokeUserPersonalTokens отзывает все персональные токены пользователя
+func (s *Storage) RevokeUserPersonalTokens(ctx context.Context, userID uuid.UUID) error {
+	if _, err := s.db.ExecContext(ctx, revokeUserPersonalTokensQuery, time.Now(), userID); err != nil {
+		return fmt.Errorf("failed to revoke user personal access tokens: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"itam_auth/internal/models"
+	"log"
+	"time"
+)
+
+const (
+	createRateLimitBucketQuery = `INSERT INTO rate_limit_buckets (key, tokens, updated_at, full_at) VALUES ($1, $2, $3, $3)
+		ON CONFLICT (key) DO NOTHING`
+	lockRateLimitBucketQuery        = `SELECT tokens, updated_at FROM rate_limit_buckets WHERE key = $1 FOR UPDATE`
+	updateRateLimitBucketQuery      = `UPDATE rate_limit_buckets SET tokens = $1, updated_at = $2, full_at = $3 WHERE key = $4`
+	deleteFullRateLimitBucketsQuery = `DELETE FROM rate_limit_buckets WHERE full_at < $1`
+)
+
+// TakeRateLimitToken забирает жетон из bucket по ключу под блокировкой строки, чтобы экземпляры сервиса
+// не расходовали один жетон дважды. Новый bucket создается полным. Возвращает состояние после
+// попытки и то, был ли жетон
+func (s *Storage) TakeRateLimitToken(ctx context.Context, key string, rate float64, burst int) (models.TokenBucket, bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.TokenBucket{}, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("Failed to rollback transaction for rate limit bucket (key=%s): %v", key, err)
+		}
+	}()
+
+	now := time.Now()
+	bucket := models.NewTokenBucket(burst, now)
+	if _, err := tx.ExecContext(ctx, createRateLimitBucketQuery, key, bucket.Tokens, now); err != nil {
+		return models.TokenBucket{}, false, fmt.Errorf("failed to create rate limit bucket: %w", err)
+	}
+	if err := tx.QueryRowContext(ctx, lockRateLimitBucketQuery, key).Scan(&bucket.Tokens, &bucket.UpdatedAt); err != nil {
+		return models.TokenBucket{}, false, fmt.Errorf("failed to get rate limit bucket: %w", err)
+	}
+
+	allowed := bucket.Take(now, rate, burst)
+	fullAt := now.Add(bucket.Until(float64(burst), rate))
+	if _, err := tx.ExecContext(ctx, updateRateLimitBucketQuery, bucket.Tokens, bucket.UpdatedAt, fullAt, key); err != nil {
+		return models.TokenBucket{}, false, fmt.Errorf("failed to save rate limit bucket: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.TokenBucket{}, false, fmt.Errorf("failed to commit rate limit bucket: %w", err)
+	}
+	return bucket, allowed, nil
+}
+
+// DeleteFullRateLimitBuckets удаляет наполнившиеся bucket: они ничем не отличаются от отсутствующих
+func (s *Storage) DeleteFullRateLimitBuckets(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, deleteFullRateLimitBucketsQuery, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete full rate limit buckets: %w", err)
+	}
+	return result.RowsAffected()
+}
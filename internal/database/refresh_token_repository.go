@@ -0,0 +1,141 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"itam_auth/internal/models"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	saveRefreshTokenQuery = `INSERT INTO refresh_tokens (id, family_id, user_id, client_id, scope, auth_time, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	getRefreshTokenByIDQuery = `SELECT id, family_id, user_id, client_id, scope, auth_time, expires_at, rotated_at, revoked_at, created_at
+		FROM refresh_tokens WHERE id = $1`
+	rotateRefreshTokenQuery = `UPDATE refresh_tokens SET rotated_at = $1
+		WHERE id = $2 AND rotated_at IS NULL AND revoked_at IS NULL`
+	revokeRefreshTokenFamilyQuery = `UPDATE refresh_tokens SET revoked_at = $1
+		WHERE family_id = $2 AND revoked_at IS NULL`
+)
+
+// ErrRefreshTokenReused возвращается при попытке повторно обменять refresh-токен
+var ErrRefreshTokenReused = errors.New("refresh token has already been used")
+
+func (s *Storage) SaveRefreshToken(ctx context.Context, token models.RefreshToken) (uuid.UUID, error) {
+	_, err := s.db.ExecContext(ctx, saveRefreshTokenQuery,
+		token.ID,
+		token.FamilyID,
+		token.UserID,
+		token.ClientID,
+		token.Scope,
+		token.AuthTime,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to save refresh token: %w", err)
+	}
+	return token.ID, nil
+}
+
+func (s *Storage) GetRefreshToken(ctx context.Context, id uuid.UUID) (models.RefreshToken, error) {
+	row := s.db.QueryRowContext(ctx, getRefreshTokenByIDQuery, id)
+
+	var token models.RefreshToken
+	var clientID, scope sql.NullString
+	var rotatedAt, revokedAt sql.NullTime
+	err := row.Scan(
+		&token.ID,
+		&token.FamilyID,
+		&token.UserID,
+		&clientID,
+		&scope,
+		&token.AuthTime,
+		&token.ExpiresAt,
+		&rotatedAt,
+		&revokedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return token, fmt.Errorf("refresh token not found")
+		}
+		return token, err
+	}
+	if clientID.Valid {
+		token.ClientID = &clientID.String
+	}
+	token.Scope = scope.String
+	if rotatedAt.Valid {
+		token.RotatedAt = &rotatedAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return token, nil
+}
+
+// RotateRefreshToken помечает старый токен как использованный и сохраняет новый
+// токен того же семейства. Если старый токен уже был обменян или отозван,
+// возвращает ErrRefreshTokenReused.
+func (s *Storage) RotateRefreshToken(ctx context.Context, oldID uuid.UUID, newToken models.RefreshToken) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Failed to begin transaction for rotating refresh token %s: %v", oldID, err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("Failed to rollback transaction for refresh token %s: %v", oldID, err)
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx, rotateRefreshTokenQuery, time.Now(), oldID)
+	if err != nil {
+		log.Printf("Failed to rotate refresh token %s: %v", oldID, err)
+		return fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrRefreshTokenReused
+	}
+
+	_, err = tx.ExecContext(ctx, saveRefreshTokenQuery,
+		newToken.ID,
+		newToken.FamilyID,
+		newToken.UserID,
+		newToken.ClientID,
+		newToken.Scope,
+		newToken.AuthTime,
+		newToken.ExpiresAt,
+		newToken.CreatedAt,
+	)
+	if err != nil {
+		log.Printf("Failed to save rotated refresh token %s: %v", newToken.ID, err)
+		return fmt.Errorf("failed to save refresh token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Failed to commit transaction for refresh token %s: %v", oldID, err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Storage) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx, revokeRefreshTokenFamilyQuery, time.Now(), familyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"itam_auth/internal/models"
 	"log"
@@ -16,10 +17,14 @@ var (
 	(id, user_id, description, certificate, status, type, created_at, updated_at) 
 	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
 	getRequestsByUserID = `SELECT * FROM requests WHERE user_id = $1 LIMIT $2 OFFSET $3`
+	getRequestByID      = `SELECT id, user_id, description, certificate, status, type, created_at FROM requests WHERE id = $1`
 	updateRequest       = `UPDATE requests SET status = $1, updated_at = $2 WHERE id = $3`
 	deleteRequest       = `DELETE FROM requests WHERE id = $1`
 )
 
+// ErrRequestNotFound - запроса с таким ID нет
+var ErrRequestNotFound = errors.New("request not found")
+
 var ValidRequestStatuses = map[string]bool{
 	"pending":  true,
 	"approved": true,
@@ -145,6 +150,18 @@ func (s *Storage) GetRequests(ctx context.Context, userID uuid.UUID, limit, offs
 	return requests, nil
 }
 
+func (s *Storage) GetRequestByID(ctx context.Context, requestID uuid.UUID) (models.Request, error) {
+	request, err := scanRequest(s.db.QueryRowContext(ctx, getRequestByID, requestID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Request{}, ErrRequestNotFound
+		}
+		log.Printf("Failed to get request with ID %s: %v", requestID, err)
+		return models.Request{}, err
+	}
+	return request, nil
+}
+
 func (s *Storage) UpdateRequestStatus(ctx context.Context, requestID uuid.UUID, status string) error {
 	if requestID == uuid.Nil {
 		return fmt.Errorf("request ID cannot be empty")
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	saveRevokedTokenQuery = `INSERT INTO revoked_tokens (jti, user_id, expires_at, revoked_at)
+		VALUES ($1, $2, $3, $4) ON CONFLICT (jti) DO NOTHING`
+	isTokenRevokedQuery          = `SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1)`
+	deleteExpiredRevokedQuery    = `DELETE FROM revoked_tokens WHERE expires_at < $1`
+	getTokensValidAfterQuery     = `SELECT tokens_valid_after FROM users WHERE id = $1`
+	setTokensValidAfterQuery     = `UPDATE users SET tokens_valid_after = $1 WHERE id = $2`
+	revokeUserRefreshTokensQuery = `UPDATE refresh_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL`
+)
+
+func (s *Storage) SaveRevokedToken(ctx context.Context, tokenID, userID uuid.UUID, expiresAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, saveRevokedTokenQuery, tokenID, userID, expiresAt, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save revoked token: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) IsTokenRevoked(ctx context.Context, tokenID uuid.UUID) (bool, error) {
+	var revoked bool
+	if err := s.db.QueryRowContext(ctx, isTokenRevokedQuery, tokenID).Scan(&revoked); err != nil {
+		return false, fmt.Errorf("failed to check revoked token: %w", err)
+	}
+	return revoked, nil
+}
+
+// DeleteExpiredRevokedTokens удаляет записи об отозванных токенах, срок действия которых уже истек
+func (s *Storage) DeleteExpiredRevokedTokens(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, deleteExpiredRevokedQuery, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired revoked tokens: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetTokensValidAfter возвращает момент, раньше которого токены пользователя недействительны.
+// Нулевое время означает, что такого ограничения нет.
+func (s *Storage) GetTokensValidAfter(ctx context.Context, userID uuid.UUID) (time.Time, error) {
+	var validAfter sql.NullTime
+	err := s.db.QueryRowContext(ctx, getTokensValidAfterQuery, userID).Scan(&validAfter)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return time.Time{}, fmt.Errorf("user not found")
+		}
+		return time.Time{}, fmt.Errorf("failed to get tokens_valid_after: %w", err)
+	}
+	if !validAfter.Valid {
+		return time.Time{}, nil
+	}
+	return validAfter.Time, nil
+}
+
+func (s *Storage) SetTokensValidAfter(ctx context.Context, userID uuid.UUID, validAfter time.Time) error {
+	result, err := s.db.ExecContext(ctx, setTokensValidAfterQuery, validAfter, userID)
+	if err != nil {
+		return fmt.Errorf("failed to set tokens_valid_after: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no user found with ID: %s", userID)
+	}
+	return nil
+}
+
+// RevokeUserRefreshTokens отзывает все refresh-токены пользователя
+func (s *Storage) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx, revokeUserRefreshTokensQuery, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke user refresh tokens: %w", err)
+	}
+	return nil
+}
@@ -3,35 +3,137 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"itam_auth/internal/models"
+	"slices"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
 
 var (
-	saveNewRole            = `INSERT INTO roles (id, name) VALUES ($1, $2)`
-	getRoleByID            = `SELECT id, name FROM roles WHERE id = $1`
-	getRoleByName          = `SELECT id, name FROM roles WHERE name = $1`
+	saveNewRole            = `INSERT INTO roles (id, name, parent_id) VALUES ($1, $2, $3)`
+	getRoleByID            = `SELECT id, name, parent_id FROM roles WHERE id = $1`
+	getRoleByName          = `SELECT id, name, parent_id FROM roles WHERE name = $1`
+	getAllRoles            = `SELECT id, name, parent_id FROM roles ORDER BY name`
 	saveNewPermission      = `INSERT INTO permissions (id, name) VALUES ($1, $2)`
 	getPermissionByID      = `SELECT id, name FROM permissions WHERE id = $1`
+	getPermissionByName    = `SELECT id, name FROM permissions WHERE name = $1`
 	getPermissionsByRoleID = `SELECT p.id, p.name FROM permissions p INNER JOIN role_permissions rp ON p.id = rp.permission_id WHERE rp.role_id = $1`
-	getRolesByUserID       = `SELECT r.id, r.name FROM roles r INNER JOIN user_roles ur ON r.id = ur.role_id WHERE ur.user_id = $1`
-	getUserPermissions     = `
-		SELECT p.id, p.name
+	getRolesByUserID       = `SELECT r.id, r.name, r.parent_id FROM roles r INNER JOIN user_roles ur ON r.id = ur.role_id WHERE ur.user_id = $1`
+	// Права всех ролей пользователя и их предков. Глубина ограничена на случай цикла, созданного в обход приложения
+	getUserPermissions = `
+		WITH RECURSIVE role_tree AS (
+			SELECT ur.role_id, 1 AS depth
+			FROM user_roles ur
+			WHERE ur.user_id = $1
+			UNION
+			SELECT r.parent_id, rt.depth + 1
+			FROM role_tree rt
+			INNER JOIN roles r ON r.id = rt.role_id
+			WHERE r.parent_id IS NOT NULL AND rt.depth < $2
+		)
+		SELECT DISTINCT p.id, p.name
 		FROM permissions p
 		INNER JOIN role_permissions rp ON p.id = rp.permission_id
-		INNER JOIN user_roles ur ON rp.role_id = ur.role_id
-		WHERE ur.user_id = $1`
-	saveUserRole        = `INSERT INTO user_roles (id, user_id, role_id) VALUES ($1, $2, $3)`
-	getRolesByIDs       = `SELECT id, name FROM roles WHERE id = ANY($1)`
-	getPermissionsByIDs = `SELECT id, name FROM permissions WHERE id = ANY($1)`
-	getRolePermissions  = `SELECT id, role_id, permission_id FROM role_permissions WHERE role_id = $1`
+		WHERE rp.role_id IN (SELECT role_id FROM role_tree)`
+	// То же, что getUserPermissions, но с путем от назначенной роли до роли, которой выдано право
+	getEffectivePermissions = `
+		WITH RECURSIVE role_tree AS (
+			SELECT r.id AS role_id, r.parent_id, ARRAY[r.name]::VARCHAR[] AS path
+			FROM roles r
+			INNER JOIN user_roles ur ON ur.role_id = r.id
+			WHERE ur.user_id = $1
+			UNION ALL
+			SELECT parent.id, parent.parent_id, rt.path || parent.name
+			FROM role_tree rt
+			INNER JOIN roles parent ON parent.id = rt.parent_id
+			WHERE cardinality(rt.path) < $2
+		)
+		SELECT p.id, p.name, rt.role_id, rt.path
+		FROM role_tree rt
+		INNER JOIN role_permissions rp ON rp.role_id = rt.role_id
+		INNER JOIN permissions p ON p.id = rp.permission_id
+		ORDER BY p.name, cardinality(rt.path), rt.path`
+	lockRolesForHierarchy = `LOCK TABLE roles IN SHARE ROW EXCLUSIVE MODE`
+	getRoleAncestorIDs    = `
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, 1 AS depth FROM roles WHERE id = $1
+			UNION ALL
+			SELECT r.id, r.parent_id, a.depth + 1
+			FROM ancestors a
+			INNER JOIN roles r ON r.id = a.parent_id
+			WHERE a.depth < $2
+		)
+		SELECT id FROM ancestors`
+	setRoleParent              = `UPDATE roles SET parent_id = $1 WHERE id = $2`
+	saveUserRole               = `INSERT INTO user_roles (id, user_id, role_id) VALUES ($1, $2, $3)`
+	getRolesByIDs              = `SELECT id, name, parent_id FROM roles WHERE id = ANY($1)`
+	getPermissionsByIDs        = `SELECT id, name FROM permissions WHERE id = ANY($1)`
+	getRolePermissions         = `SELECT id, role_id, permission_id FROM role_permissions WHERE role_id = $1`
+	getAllPermissions          = `SELECT id, name FROM permissions ORDER BY name`
+	getRoleNameForUpdate       = `SELECT name, parent_id FROM roles WHERE id = $1 FOR UPDATE`
+	renameRole                 = `UPDATE roles SET name = $1 WHERE id = $2`
+	deleteRole                 = `DELETE FROM roles WHERE id = $1`
+	getPermissionNameForUpdate = `SELECT name FROM permissions WHERE id = $1 FOR UPDATE`
+	renamePermission           = `UPDATE permissions SET name = $1 WHERE id = $2`
+	deletePermission           = `DELETE FROM permissions WHERE id = $1`
+	saveRolePermission         = `INSERT INTO role_permissions (id, role_id, permission_id) VALUES ($1, $2, $3)`
+	deleteRolePermission       = `DELETE FROM role_permissions WHERE role_id = $1 AND permission_id = $2`
+	deleteUserRole             = `DELETE FROM user_roles WHERE user_id = $1 AND role_id = $2`
+	// Только пользователи, которым роль назначена напрямую, без наследников роли
+	getUsersByRoleID = `SELECT u.id, u.name, COALESCE(u.email, ''), u.telegram, u.telegram_id, COALESCE(u.password_hash, ''), u.email_verified_at IS NOT NULL, u.photo_url, u.about, u.resume_url, u.specification, u.created_at, u.updated_at
+	FROM users u
+	INNER JOIN user_roles ur ON ur.user_id = u.id
+	WHERE ur.role_id = $1
+	ORDER BY u.created_at, u.id
+	LIMIT $2 OFFSET $3`
 )
 
+const foreignKeyViolationCode = "23503" // Код ошибки Postgres при ссылке на несуществующую запись
+
+// maxRoleDepth - наибольшая длина цепочки наследования ролей
+const maxRoleDepth = 32
+
+var (
+	// ErrRoleNotFound - роли с таким ID или названием нет
+	ErrRoleNotFound = errors.New("role not found")
+	// ErrRoleCycle - новая родительская роль сама наследует от этой роли
+	ErrRoleCycle = errors.New("role hierarchy cycle")
+	// ErrRoleHierarchyTooDeep - у новой родительской роли уже maxRoleDepth предков
+	ErrRoleHierarchyTooDeep = errors.New("role hierarchy is too deep")
+	// ErrRoleExists - роль с таким названием уже есть
+	ErrRoleExists = errors.New("role already exists")
+	// ErrPermissionNotFound - права с таким ID нет
+	ErrPermissionNotFound = errors.New("permission not found")
+	// ErrPermissionExists - право с таким названием уже есть
+	ErrPermissionExists = errors.New("permission already exists")
+	// ErrRolePermissionExists - право уже привязано к роли
+	ErrRolePermissionExists = errors.New("permission is already attached to the role")
+	// ErrRolePermissionNotFound - право не привязано к роли
+	ErrRolePermissionNotFound = errors.New("permission is not attached to the role")
+	// ErrUserRoleExists - роль уже назначена пользователю
+	ErrUserRoleExists = errors.New("role is already granted to the user")
+	// ErrUserRoleNotFound - роль не назначена пользователю
+	ErrUserRoleNotFound = errors.New("role is not granted to the user")
+)
+
+func scanRole(row interface{ Scan(...any) error }) (models.Role, error) {
+	var role models.Role
+	var parentID uuid.NullUUID
+	if err := row.Scan(&role.ID, &role.Name, &parentID); err != nil {
+		return models.Role{}, err
+	}
+	if parentID.Valid {
+		role.ParentID = &parentID.UUID
+	}
+	return role, nil
+}
+
 func (s *Storage) SaveRole(ctx context.Context, role models.Role) (uuid.UUID, error) {
-	_, err := s.db.ExecContext(ctx, saveNewRole, role.ID, role.Name)
+	_, err := s.db.ExecContext(ctx, saveNewRole, role.ID, role.Name, role.ParentID)
 	if err != nil {
 		return uuid.Nil, err
 	}
@@ -39,13 +141,10 @@ func (s *Storage) SaveRole(ctx context.Context, role models.Role) (uuid.UUID, er
 }
 
 func (s *Storage) GetRole(ctx context.Context, id uuid.UUID) (models.Role, error) {
-	row := s.db.QueryRowContext(ctx, getRoleByID, id)
-
-	var role models.Role
-	err := row.Scan(&role.ID, &role.Name)
+	role, err := scanRole(s.db.QueryRowContext(ctx, getRoleByID, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return role, fmt.Errorf("role not found")
+			return role, ErrRoleNotFound
 		}
 		return role, err
 	}
@@ -53,13 +152,10 @@ func (s *Storage) GetRole(ctx context.Context, id uuid.UUID) (models.Role, error
 }
 
 func (s *Storage) GetRoleByName(ctx context.Context, name string) (models.Role, error) {
-	row := s.db.QueryRowContext(ctx, getRoleByName, name)
-
-	var role models.Role
-	err := row.Scan(&role.ID, &role.Name)
+	role, err := scanRole(s.db.QueryRowContext(ctx, getRoleByName, name))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return role, fmt.Errorf("role not found")
+			return role, ErrRoleNotFound
 		}
 		return role, err
 	}
@@ -81,7 +177,21 @@ func (s *Storage) GetPermission(ctx context.Context, id uuid.UUID) (models.Permi
 	err := row.Scan(&permission.ID, &permission.Name)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return permission, fmt.Errorf("permission not found")
+			return permission, ErrPermissionNotFound
+		}
+		return permission, err
+	}
+	return permission, nil
+}
+
+func (s *Storage) GetPermissionByName(ctx context.Context, name string) (models.Permission, error) {
+	row := s.db.QueryRowContext(ctx, getPermissionByName, name)
+
+	var permission models.Permission
+	err := row.Scan(&permission.ID, &permission.Name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return permission, ErrPermissionNotFound
 		}
 		return permission, err
 	}
@@ -121,8 +231,7 @@ func (s *Storage) GetUserRoles(ctx context.Context, userID uuid.UUID) ([]models.
 
 	var userRoles []models.UserRole
 	for rows.Next() {
-		var role models.Role
-		err := rows.Scan(&role.ID, &role.Name)
+		role, err := scanRole(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan user role: %w", err)
 		}
@@ -140,8 +249,9 @@ func (s *Storage) GetUserRoles(ctx context.Context, userID uuid.UUID) ([]models.
 	return userRoles, nil
 }
 
+// GetUserPermissions возвращает права всех ролей пользователя с учетом наследования, каждое право один раз
 func (s *Storage) GetUserPermissions(ctx context.Context, userID uuid.UUID) ([]models.Permission, error) {
-	rows, err := s.db.QueryContext(ctx, getUserPermissions, userID)
+	rows, err := s.db.QueryContext(ctx, getUserPermissions, userID, maxRoleDepth)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user permissions: %w", err)
 	}
@@ -185,8 +295,7 @@ func (s *Storage) GetRolesByIDs(ctx context.Context, roleIDs []uuid.UUID) ([]mod
 
 	var roles []models.Role
 	for rows.Next() {
-		var role models.Role
-		err := rows.Scan(&role.ID, &role.Name)
+		role, err := scanRole(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan role: %w", err)
 		}
@@ -227,3 +336,463 @@ func (s *Storage) GetPermissionsByIDs(ctx context.Context, permissionIDs []uuid.
 
 	return permissions, nil
 }
+
+func (s *Storage) GetAllRoles(ctx context.Context) ([]models.Role, error) {
+	rows, err := s.db.QueryContext(ctx, getAllRoles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []models.Role{}
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan role: %w", err)
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return roles, nil
+}
+
+// GetEffectivePermissions возвращает права пользователя с учетом наследования ролей. Право, полученное
+// несколькими путями, встречается в ответе для каждого пути; первым идет самый короткий
+func (s *Storage) GetEffectivePermissions(ctx context.Context, userID uuid.UUID) ([]models.EffectivePermission, error) {
+	rows, err := s.db.QueryContext(ctx, getEffectivePermissions, userID, maxRoleDepth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get effective permissions: %w", err)
+	}
+	defer rows.Close()
+
+	permissions := []models.EffectivePermission{}
+	for rows.Next() {
+		var permission models.EffectivePermission
+		if err := rows.Scan(&permission.PermissionID, &permission.Permission, &permission.RoleID, pq.Array(&permission.Path)); err != nil {
+			return nil, fmt.Errorf("failed to scan effective permission: %w", err)
+		}
+		permission.AssignedRole = permission.Path[0]
+		permission.Role = permission.Path[len(permission.Path)-1]
+		permission.Inherited = len(permission.Path) > 1
+		permissions = append(permissions, permission)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// SetRoleParent делает parentID родителем роли; nil убирает родителя. Роль не может наследовать
+// от самой себя или от своих потомков (ErrRoleCycle). Изменение записывается в журнал аудита
+func (s *Storage) SetRoleParent(ctx context.Context, roleID uuid.UUID, parentID *uuid.UUID, event models.AuditEvent) error {
+	event.Action, event.TargetType, event.TargetID = models.AuditRoleSetParent, models.AuditTargetRole, roleID
+	return s.withAudit(ctx, event, func(tx *sql.Tx, event *models.AuditEvent) error {
+		if err := checkRoleParent(ctx, tx, roleID, parentID); err != nil {
+			return err
+		}
+
+		var name string
+		var previous uuid.NullUUID
+		if err := tx.QueryRowContext(ctx, getRoleNameForUpdate, roleID).Scan(&name, &previous); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrRoleNotFound
+			}
+			return fmt.Errorf("failed to get role: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, setRoleParent, parentID, roleID); err != nil {
+			return fmt.Errorf("failed to set role parent: %w", err)
+		}
+
+		event.Details["name"] = name
+		if previous.Valid {
+			event.Details["previous_parent_id"] = previous.UUID.String()
+		}
+		if parentID != nil {
+			event.Details["parent_id"] = parentID.String()
+		}
+		return nil
+	})
+}
+
+// checkRoleParent блокирует иерархию ролей до конца транзакции и проверяет, что parentID существует
+// и может стать родителем роли. nil (без родителя) допустим всегда
+func checkRoleParent(ctx context.Context, tx *sql.Tx, roleID uuid.UUID, parentID *uuid.UUID) error {
+	// Два одновременных изменения иерархии могли бы вместе замкнуть цикл, поэтому выполняются по очереди
+	if _, err := tx.ExecContext(ctx, lockRolesForHierarchy); err != nil {
+		return fmt.Errorf("failed to lock roles: %w", err)
+	}
+	if parentID == nil {
+		return nil
+	}
+
+	ancestors, err := roleAncestorIDs(ctx, tx, *parentID)
+	if err != nil {
+		return err
+	}
+	if len(ancestors) == 0 {
+		return ErrRoleNotFound
+	}
+	if slices.Contains(ancestors, roleID) {
+		return ErrRoleCycle
+	}
+	if len(ancestors) >= maxRoleDepth {
+		return ErrRoleHierarchyTooDeep
+	}
+	return nil
+}
+
+// roleAncestorIDs возвращает роль и всех ее предков, начиная с нее самой
+func roleAncestorIDs(ctx context.Context, tx *sql.Tx, roleID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := tx.QueryContext(ctx, getRoleAncestorIDs, roleID, maxRoleDepth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role ancestors: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan role ancestor: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (s *Storage) GetAllPermissions(ctx context.Context) ([]models.Permission, error) {
+	rows, err := s.db.QueryContext(ctx, getAllPermissions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get permissions: %w", err)
+	}
+	defer rows.Close()
+
+	permissions := []models.Permission{}
+	for rows.Next() {
+		var permission models.Permission
+		if err := rows.Scan(&permission.ID, &permission.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan permission: %w", err)
+		}
+		permissions = append(permissions, permission)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// GetPermissionsByRoleID возвращает права, привязанные к роли напрямую, без прав ее предков
+func (s *Storage) GetPermissionsByRoleID(ctx context.Context, roleID uuid.UUID) ([]models.Permission, error) {
+	rows, err := s.db.QueryContext(ctx, getPermissionsByRoleID, roleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role permissions: %w", err)
+	}
+	defer rows.Close()
+
+	permissions := []models.Permission{}
+	for rows.Next() {
+		var permission models.Permission
+		if err := rows.Scan(&permission.ID, &permission.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan permission: %w", err)
+		}
+		permissions = append(permissions, permission)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// GetRolesByUserID возвращает роли, назначенные пользователю напрямую
+func (s *Storage) GetRolesByUserID(ctx context.Context, userID uuid.UUID) ([]models.Role, error) {
+	rows, err := s.db.QueryContext(ctx, getRolesByUserID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user roles: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []models.Role{}
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan role: %w", err)
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return roles, nil
+}
+
+// GetUsersByRoleID возвращает пользователей, которым роль назначена напрямую
+func (s *Storage) GetUsersByRoleID(ctx context.Context, roleID uuid.UUID, limit, offset int) ([]models.User, error) {
+	rows, err := s.db.QueryContext(ctx, getUsersByRoleID, roleID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var user models.User
+		err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.Telegram, &user.TelegramID, &user.PasswordHash, &user.EmailVerified, &user.PhotoURL, &user.About, &user.ResumeURL, &user.Specification, &user.CreatedAt, &user.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return users, nil
+}
+
+// CreateRole создает роль, при необходимости сразу с родительской, и записывает это в журнал аудита
+func (s *Storage) CreateRole(ctx context.Context, role models.Role, event models.AuditEvent) error {
+	event.Action, event.TargetType, event.TargetID = models.AuditRoleCreate, models.AuditTargetRole, role.ID
+	return s.withAudit(ctx, event, func(tx *sql.Tx, event *models.AuditEvent) error {
+		if err := checkRoleParent(ctx, tx, role.ID, role.ParentID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, saveNewRole, role.ID, role.Name, role.ParentID); err != nil {
+			if isPQError(err, uniqueViolationCode) {
+				return ErrRoleExists
+			}
+			return fmt.Errorf("failed to save role: %w", err)
+		}
+
+		event.Details["name"] = role.Name
+		if role.ParentID != nil {
+			event.Details["parent_id"] = role.ParentID.String()
+		}
+		return nil
+	})
+}
+
+// RenameRole меняет название роли и записывает прежнее название в журнал аудита
+func (s *Storage) RenameRole(ctx context.Context, roleID uuid.UUID, name string, event models.AuditEvent) error {
+	event.Action, event.TargetType, event.TargetID = models.AuditRoleRename, models.AuditTargetRole, roleID
+	return s.withAudit(ctx, event, func(tx *sql.Tx, event *models.AuditEvent) error {
+		var previous string
+		var parentID uuid.NullUUID
+		if err := tx.QueryRowContext(ctx, getRoleNameForUpdate, roleID).Scan(&previous, &parentID); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrRoleNotFound
+			}
+			return fmt.Errorf("failed to get role: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, renameRole, name, roleID); err != nil {
+			if isPQError(err, uniqueViolationCode) {
+				return ErrRoleExists
+			}
+			return fmt.Errorf("failed to rename role: %w", err)
+		}
+
+		event.Details["previous_name"] = previous
+		event.Details["name"] = name
+		return nil
+	})
+}
+
+// DeleteRole удаляет роль. Пользователи теряют ее, а дочерние роли остаются без родителя
+func (s *Storage) DeleteRole(ctx context.Context, roleID uuid.UUID, event models.AuditEvent) error {
+	event.Action, event.TargetType, event.TargetID = models.AuditRoleDelete, models.AuditTargetRole, roleID
+	return s.withAudit(ctx, event, func(tx *sql.Tx, event *models.AuditEvent) error {
+		var name string
+		var parentID uuid.NullUUID
+		if err := tx.QueryRowContext(ctx, getRoleNameForUpdate, roleID).Scan(&name, &parentID); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrRoleNotFound
+			}
+			return fmt.Errorf("failed to get role: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, deleteRole, roleID); err != nil {
+			return fmt.Errorf("failed to delete role: %w", err)
+		}
+
+		event.Details["name"] = name
+		if parentID.Valid {
+			event.Details["parent_id"] = parentID.UUID.String()
+		}
+		return nil
+	})
+}
+
+// CreatePermission создает право и записывает это в журнал аудита
+func (s *Storage) CreatePermission(ctx context.Context, permission models.Permission, event models.AuditEvent) error {
+	event.Action, event.TargetType, event.TargetID = models.AuditPermissionCreate, models.AuditTargetPermission, permission.ID
+	return s.withAudit(ctx, event, func(tx *sql.Tx, event *models.AuditEvent) error {
+		if _, err := tx.ExecContext(ctx, saveNewPermission, permission.ID, permission.Name); err != nil {
+			if isPQError(err, uniqueViolationCode) {
+				return ErrPermissionExists
+			}
+			return fmt.Errorf("failed to save permission: %w", err)
+		}
+
+		event.Details["name"] = permission.Name
+		return nil
+	})
+}
+
+// RenamePermission меняет название права и записывает прежнее название в журнал аудита
+func (s *Storage) RenamePermission(ctx context.Context, permissionID uuid.UUID, name string, event models.AuditEvent) error {
+	event.Action, event.TargetType, event.TargetID = models.AuditPermissionRename, models.AuditTargetPermission, permissionID
+	return s.withAudit(ctx, event, func(tx *sql.Tx, event *models.AuditEvent) error {
+		var previous string
+		if err := tx.QueryRowContext(ctx, getPermissionNameForUpdate, permissionID).Scan(&previous); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrPermissionNotFound
+			}
+			return fmt.Errorf("failed to get permission: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, renamePermission, name, permissionID); err != nil {
+			if isPQError(err, uniqueViolationCode) {
+				return ErrPermissionExists
+			}
+			return fmt.Errorf("failed to rename permission: %w", err)
+		}
+
+		event.Details["previous_name"] = previous
+		event.Details["name"] = name
+		return nil
+	})
+}
+
+// DeletePermission удаляет право вместе с его привязками к ролям
+func (s *Storage) DeletePermission(ctx context.Context, permissionID uuid.UUID, event models.AuditEvent) error {
+	event.Action, event.TargetType, event.TargetID = models.AuditPermissionDelete, models.AuditTargetPermission, permissionID
+	return s.withAudit(ctx, event, func(tx *sql.Tx, event *models.AuditEvent) error {
+		var name string
+		if err := tx.QueryRowContext(ctx, getPermissionNameForUpdate, permissionID).Scan(&name); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrPermissionNotFound
+			}
+			return fmt.Errorf("failed to get permission: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, deletePermission, permissionID); err != nil {
+			return fmt.Errorf("failed to delete permission: %w", err)
+		}
+
+		event.Details["name"] = name
+		return nil
+	})
+}
+
+// AttachPermission привязывает право к роли и записывает это в журнал аудита
+func (s *Storage) AttachPermission(ctx context.Context, rolePermission models.RolePermission, event models.AuditEvent) error {
+	event.Action, event.TargetType, event.TargetID = models.AuditPermissionAttach, models.AuditTargetRole, rolePermission.RoleID
+	return s.withAudit(ctx, event, func(tx *sql.Tx, event *models.AuditEvent) error {
+		_, err := tx.ExecContext(ctx, saveRolePermission, rolePermission.ID, rolePermission.RoleID, rolePermission.PermissionID)
+		if err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) {
+				switch {
+				case pqErr.Code == uniqueViolationCode:
+					return ErrRolePermissionExists
+				case pqErr.Code == foreignKeyViolationCode && strings.Contains(pqErr.Constraint, "permission_id"):
+					return ErrPermissionNotFound
+				case pqErr.Code == foreignKeyViolationCode:
+					return ErrRoleNotFound
+				}
+			}
+			return fmt.Errorf("failed to attach permission: %w", err)
+		}
+
+		event.Details["permission_id"] = rolePermission.PermissionID.String()
+		return nil
+	})
+}
+
+// DetachPermission отвязывает право от роли и записывает это в журнал аудита
+func (s *Storage) DetachPermission(ctx context.Context, roleID, permissionID uuid.UUID, event models.AuditEvent) error {
+	event.Action, event.TargetType, event.TargetID = models.AuditPermissionDetach, models.AuditTargetRole, roleID
+	return s.withAudit(ctx, event, func(tx *sql.Tx, event *models.AuditEvent) error {
+		result, err := tx.ExecContext(ctx, deleteRolePermission, roleID, permissionID)
+		if err != nil {
+			return fmt.Errorf("failed to detach permission: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return ErrRolePermissionNotFound
+		}
+
+		event.Details["permission_id"] = permissionID.String()
+		return nil
+	})
+}
+
+// GrantRole назначает роль пользователю и записывает это в журнал аудита
+func (s *Storage) GrantRole(ctx context.Context, userRole models.UserRole, event models.AuditEvent) error {
+	event.Action, event.TargetType, event.TargetID = models.AuditUserRoleGrant, models.AuditTargetUser, userRole.UserID
+	return s.withAudit(ctx, event, func(tx *sql.Tx, event *models.AuditEvent) error {
+		_, err := tx.ExecContext(ctx, saveUserRole, userRole.ID, userRole.UserID, userRole.RoleID)
+		if err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) {
+				switch {
+				case pqErr.Code == uniqueViolationCode:
+					return ErrUserRoleExists
+				case pqErr.Code == foreignKeyViolationCode && strings.Contains(pqErr.Constraint, "user_id"):
+					return ErrUserNotFound
+				case pqErr.Code == foreignKeyViolationCode:
+					return ErrRoleNotFound
+				}
+			}
+			return fmt.Errorf("failed to grant role: %w", err)
+		}
+
+		event.Details["role_id"] = userRole.RoleID.String()
+		return nil
+	})
+}
+
+// RevokeRole снимает с пользователя роль и записывает это в журнал аудита
+func (s *Storage) RevokeRole(ctx context.Context, userID, roleID uuid.UUID, event models.AuditEvent) error {
+	event.Action, event.TargetType, event.TargetID = models.AuditUserRoleRevoke, models.AuditTargetUser, userID
+	return s.withAudit(ctx, event, func(tx *sql.Tx, event *models.AuditEvent) error {
+		result, err := tx.ExecContext(ctx, deleteUserRole, userID, roleID)
+		if err != nil {
+			return fmt.Errorf("failed to revoke role: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return ErrUserRoleNotFound
+		}
+
+		event.Details["role_id"] = roleID.String()
+		return nil
+	})
+}
+
+// isPQError сообщает, что err - ошибка Postgres с кодом code
+func isPQError(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
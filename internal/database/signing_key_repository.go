@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"itam_auth/internal/models"
+	"log"
+	"time"
+)
+
+const (
+	signingKeyColumns    = `id, algorithm, private_key, state, created_at, activated_at, retired_at`
+	getSigningKeysQuery  = `SELECT ` + signingKeyColumns + ` FROM signing_keys WHERE state <> 'retired' ORDER BY created_at`
+	listSigningKeysQuery = `SELECT ` + signingKeyColumns + ` FROM signing_keys ORDER BY created_at`
+	demoteActiveKeyQuery = `UPDATE signing_keys SET state = 'retiring' WHERE state = 'active'`
+	savePendingKeyQuery  = `INSERT INTO signing_keys (id, algorithm, private_key, state, created_at)
+		VALUES ($1, $2, $3, 'pending', $4)`
+	activatePendingKeyQuery = `UPDATE signing_keys SET state = 'active', activated_at = $1 WHERE id = $2 AND state = 'pending'`
+	retireSigningKeyQuery   = `UPDATE signing_keys SET state = 'retired', retired_at = $1
+		WHERE id = $2 AND state IN ('pending', 'retiring')`
+	updateSigningKeyPrivateKeyQuery = `UPDATE signing_keys SET private_key = $1 WHERE id = $2`
+)
+
+func scanSigningKey(row interface{ Scan(...any) error }) (models.SigningKey, error) {
+	var key models.SigningKey
+	var activatedAt, retiredAt sql.NullTime
+	err := row.Scan(
+		&key.ID,
+		&key.Algorithm,
+		&key.PrivateKey,
+		&key.State,
+		&key.CreatedAt,
+		&activatedAt,
+		&retiredAt,
+	)
+	if err != nil {
+		return models.SigningKey{}, fmt.Errorf("failed to scan signing key: %w", err)
+	}
+	if activatedAt.Valid {
+		key.ActivatedAt = &activatedAt.Time
+	}
+	if retiredAt.Valid {
+		key.RetiredAt = &retiredAt.Time
+	}
+	return key, nil
+}
+
+func (s *Storage) querySigningKeys(ctx context.Context, query string) ([]models.SigningKey, error) {
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signing keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []models.SigningKey
+	for rows.Next() {
+		key, err := scanSigningKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return keys, nil
+}
+
+// GetSigningKeys возвращает ожидающие, активный и уходящие ключи подписи
+func (s *Storage) GetSigningKeys(ctx context.Context) ([]models.SigningKey, error) {
+	return s.querySigningKeys(ctx, getSigningKeysQuery)
+}
+
+// ListSigningKeys возвращает все ключи подписи, включая выведенные из обращения
+func (s *Storage) ListSigningKeys(ctx context.Context) ([]models.SigningKey, error) {
+	return s.querySigningKeys(ctx, listSigningKeysQuery)
+}
+
+// SavePendingSigningKey сохраняет новый ключ в состоянии pending: он публикуется в JWKS, но не подписывает токены
+func (s *Storage) SavePendingSigningKey(ctx context.Context, key models.SigningKey) error {
+	_, err := s.db.ExecContext(ctx, savePendingKeyQuery, key.ID, key.Algorithm, key.PrivateKey, key.CreatedAt)
+	if err != nil {
+		log.Printf("Failed to save signing key %s: %v", key.ID, err)
+		return fmt.Errorf("failed to save signing key: %w", err)
+	}
+	return nil
+}
+
+// PromoteSigningKey делает ожидающий ключ активным, а прежний активный ключ переводит в состояние retiring
+func (s *Storage) PromoteSigningKey(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Failed to begin transaction for promoting signing key %s: %v", id, err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("Failed to rollback transaction for signing key %s: %v", id, err)
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, demoteActiveKeyQuery); err != nil {
+		log.Printf("Failed to demote active signing key: %v", err)
+		return fmt.Errorf("failed to demote active signing key: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, activatePendingKeyQuery, time.Now(), id)
+	if err != nil {
+		log.Printf("Failed to activate signing key %s: %v", id, err)
+		return fmt.Errorf("failed to activate signing key: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no pending signing key found with ID: %s", id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Failed to commit transaction for signing key %s: %v", id, err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateSigningKeyPrivateKey заменяет сохраненный закрытый ключ, например зашифрованным
+func (s *Storage) UpdateSigningKeyPrivateKey(ctx context.Context, id, privateKey string) error {
+	if _, err := s.db.ExecContext(ctx, updateSigningKeyPrivateKeyQuery, privateKey, id); err != nil {
+		return fmt.Errorf("failed to update signing key: %w", err)
+	}
+	return nil
+}
+
+// RetireSigningKey выводит уходящий или ожидающий ключ из обращения. Активный ключ вывести нельзя
+func (s *Storage) RetireSigningKey(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, retireSigningKeyQuery, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to retire signing key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no pending or retiring signing key found with ID: %s", id)
+	}
+	return nil
+}
@@ -3,19 +3,37 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"itam_auth/internal/models"
 	"log"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// ErrUserNotFound - пользователь не найден
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrTelegramAlreadyLinked - аккаунт Telegram уже привязан к другому пользователю
+var ErrTelegramAlreadyLinked = errors.New("telegram account is already linked to another user")
+
 const (
-	saveNewUserQuery = `INSERT INTO users (id, name, email, password_hash, specification, created_at, updated_at) 
-	VALUES ($1, $2, $3, $4, $5, $6, $7)`
-	getUserByIDQuery    = `SELECT id, name, email, telegram, password_hash, photo_url, about, resume_url, specification, created_at, updated_at FROM users WHERE id = $1`
-	getUserByEmailQuery = `SELECT id, name, email, password_hash FROM users WHERE email = $1`
-	updateUserQuery     = `UPDATE users SET name = $1, specification = $2, about = $3, photo_url = $4, resume_url = $5, telegram = $6, updated_at = $7 WHERE id = $8`
+	saveNewUserQuery = `INSERT INTO users (id, name, email, password_hash, telegram, telegram_id, photo_url, specification, created_at, updated_at) 
+	VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6, $7, $8, $9, $10)`
+	getUserByIDQuery = `SELECT id, name, COALESCE(email, ''), telegram, telegram_id, COALESCE(password_hash, ''), email_verified_at IS NOT NULL, photo_url, about, resume_url, specification, created_at, updated_at
+	FROM users WHERE id = $1`
+	getUserByTelegramIDQuery = `SELECT id, name, COALESCE(email, ''), telegram, telegram_id, COALESCE(password_hash, ''), email_verified_at IS NOT NULL, photo_url, about, resume_url, specification, created_at, updated_at
+	FROM users WHERE telegram_id = $1`
+	getUserByEmailQuery    = `SELECT id, name, email, COALESCE(password_hash, ''), email_verified_at IS NOT NULL, specification FROM users WHERE email = $1`
+	linkTelegramQuery      = `UPDATE users SET telegram_id = $1, telegram = COALESCE($2, telegram), updated_at = $3 WHERE id = $4`
+	unlinkTelegramQuery    = `UPDATE users SET telegram_id = NULL, updated_at = $1 WHERE id = $2`
+	updateUserQuery        = `UPDATE users SET name = $1, specification = $2, about = $3, photo_url = $4, resume_url = $5, telegram = $6, updated_at = $7 WHERE id = $8`
+	markEmailVerifiedQuery = `UPDATE users SET email_verified_at = $1, updated_at = $1 WHERE id = $2 AND email = $3`
+	updatePasswordQuery    = `UPDATE users SET password_hash = $1, updated_at = $2 WHERE id = $3`
+
+	uniqueViolationCode = "23505" // Код ошибки Postgres при нарушении уникальности
 )
 
 func (s *Storage) SaveUser(ctx context.Context, user models.User) (uuid.UUID, error) {
@@ -24,6 +42,9 @@ func (s *Storage) SaveUser(ctx context.Context, user models.User) (uuid.UUID, er
 		user.Name,
 		user.Email,
 		user.PasswordHash,
+		user.Telegram,
+		user.TelegramID,
+		user.PhotoURL,
 		user.Specification,
 		user.CreatedAt,
 		user.UpdatedAt,
@@ -34,20 +55,64 @@ func (s *Storage) SaveUser(ctx context.Context, user models.User) (uuid.UUID, er
 	return user.ID, nil
 }
 
-func (s *Storage) GetUserByID(ctx context.Context, id uuid.UUID) (models.User, error) {
-	row := s.db.QueryRowContext(ctx, getUserByIDQuery, id)
-
+func scanUser(row *sql.Row) (models.User, error) {
 	var user models.User
-	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.Telegram, &user.PasswordHash, &user.PhotoURL, &user.About, &user.ResumeURL, &user.Specification, &user.CreatedAt, &user.UpdatedAt)
+	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.Telegram, &user.TelegramID, &user.PasswordHash, &user.EmailVerified, &user.PhotoURL, &user.About, &user.ResumeURL, &user.Specification, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return user, fmt.Errorf("user not found")
+			return user, ErrUserNotFound
 		}
 		return user, err
 	}
 	return user, nil
 }
 
+func (s *Storage) GetUserByID(ctx context.Context, id uuid.UUID) (models.User, error) {
+	return scanUser(s.db.QueryRowContext(ctx, getUserByIDQuery, id))
+}
+
+func (s *Storage) GetUserByTelegramID(ctx context.Context, telegramID int64) (models.User, error) {
+	return scanUser(s.db.QueryRowContext(ctx, getUserByTelegramIDQuery, telegramID))
+}
+
+// LinkTelegram привязывает аккаунт Telegram к пользователю. Если передан username, он заменяет
+// контакт Telegram в профиле. Аккаунт, уже привязанный к другому пользователю, привязать нельзя
+func (s *Storage) LinkTelegram(ctx context.Context, userID uuid.UUID, telegramID int64, username *string) error {
+	result, err := s.db.ExecContext(ctx, linkTelegramQuery, telegramID, username, time.Now(), userID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolationCode {
+			return ErrTelegramAlreadyLinked
+		}
+		return fmt.Errorf("failed to link telegram: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no user found with ID: %s", userID)
+	}
+	return nil
+}
+
+func (s *Storage) UnlinkTelegram(ctx context.Context, userID uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx, unlinkTelegramQuery, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to unlink telegram: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no user found with ID: %s", userID)
+	}
+	return nil
+}
+
 func (s *Storage) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
 	row := s.db.QueryRowContext(ctx, getUserByEmailQuery, email)
 
@@ -57,17 +122,53 @@ func (s *Storage) GetUserByEmail(ctx context.Context, email string) (models.User
 		&user.Name,
 		&user.Email,
 		&user.PasswordHash,
+		&user.EmailVerified,
+		&user.Specification,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return user, fmt.Errorf("user not found")
+			return user, ErrUserNotFound
 		}
 		return user, err
 	}
 	return user, nil
 }
 
+// MarkEmailVerified отмечает email пользователя подтвержденным. Если email с тех пор сменился,
+// подтверждение старого адреса не засчитывается
+func (s *Storage) MarkEmailVerified(ctx context.Context, userID uuid.UUID, email string) error {
+	result, err := s.db.ExecContext(ctx, markEmailVerifiedQuery, time.Now(), userID, email)
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (s *Storage) UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string) error {
+	result, err := s.db.ExecContext(ctx, updatePasswordQuery, passwordHash, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
 func (s *Storage) UpdateUser(ctx context.Context, user models.User) error {
 	if user.ID == uuid.Nil {
 		return fmt.Errorf("user ID cannot be empty")
@@ -0,0 +1,168 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"itam_auth/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	saveWebAuthnCredentialQuery = `INSERT INTO webauthn_credentials (id, user_id, name, credential, created_at)
+		VALUES ($1, $2, $3, $4, $5)`
+	getWebAuthnCredentialsByUserQuery = `SELECT id, user_id, name, credential, last_used_at, created_at
+		FROM webauthn_credentials WHERE user_id = $1 ORDER BY created_at`
+	updateWebAuthnCredentialQuery = `UPDATE webauthn_credentials SET credential = $1, last_used_at = $2 WHERE id = $3`
+	deleteWebAuthnCredentialQuery = `DELETE FROM webauthn_credentials WHERE id = $1 AND user_id = $2`
+	countWebAuthnCredentialsQuery = `SELECT COUNT(*) FROM webauthn_credentials WHERE user_id = $1`
+	saveWebAuthnSessionQuery      = `INSERT INTO webauthn_sessions (id, user_id, purpose, data, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+	consumeWebAuthnSessionQuery = `DELETE FROM webauthn_sessions WHERE id = $1 AND purpose = $2
+		RETURNING id, user_id, purpose, data, expires_at, created_at`
+	deleteExpiredWebAuthnSessionsQuery = `DELETE FROM webauthn_sessions WHERE expires_at < $1`
+)
+
+var (
+	// ErrWebAuthnCredentialNotFound - у пользователя нет ключа доступа с таким идентификатором
+	ErrWebAuthnCredentialNotFound = errors.New("webauthn credential not found")
+	// ErrWebAuthnCredentialExists - ключ доступа с таким идентификатором уже зарегистрирован
+	ErrWebAuthnCredentialExists = errors.New("webauthn credential is already registered")
+	// ErrWebAuthnSessionNotFound - церемония не начиналась, уже завершена или истекла
+	ErrWebAuthnSessionNotFound = errors.New("webauthn session not found or expired")
+)
+
+func (s *Storage) SaveWebAuthnCredential(ctx context.Context, credential models.WebAuthnCredential) error {
+	_, err := s.db.ExecContext(ctx, saveWebAuthnCredentialQuery,
+		credential.ID,
+		credential.UserID,
+		credential.Name,
+		credential.Credential,
+		credential.CreatedAt,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolationCode {
+			return ErrWebAuthnCredentialExists
+		}
+		return fmt.Errorf("failed to save webauthn credential: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) GetWebAuthnCredentialsByUser(ctx context.Context, userID uuid.UUID) ([]models.WebAuthnCredential, error) {
+	rows, err := s.db.QueryContext(ctx, getWebAuthnCredentialsByUserQuery, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webauthn credentials: %w", err)
+	}
+	defer rows.Close()
+
+	var credentials []models.WebAuthnCredential
+	for rows.Next() {
+		var credential models.WebAuthnCredential
+		var lastUsedAt sql.NullTime
+		if err := rows.Scan(
+			&credential.ID,
+			&credential.UserID,
+			&credential.Name,
+			&credential.Credential,
+			&lastUsedAt,
+			&credential.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan webauthn credential: %w", err)
+		}
+		if lastUsedAt.Valid {
+			credential.LastUsedAt = &lastUsedAt.Time
+		}
+		credentials = append(credentials, credential)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate webauthn credentials: %w", err)
+	}
+	return credentials, nil
+}
+
+// UpdateWebAuthnCredential сохраняет запись после входа: счетчик подписей и флаги меняются при каждом использовании
+func (s *Storage) UpdateWebAuthnCredential(ctx context.Context, id []byte, credential []byte, lastUsedAt time.Time) error {
+	if _, err := s.db.ExecContext(ctx, updateWebAuthnCredentialQuery, credential, lastUsedAt, id); err != nil {
+		return fmt.Errorf("failed to update webauthn credential: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) DeleteWebAuthnCredential(ctx context.Context, userID uuid.UUID, id []byte) error {
+	result, err := s.db.ExecContext(ctx, deleteWebAuthnCredentialQuery, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webauthn credential: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrWebAuthnCredentialNotFound
+	}
+	return nil
+}
+
+func (s *Storage) CountWebAuthnCredentials(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	if err := s.db.QueryRowContext(ctx, countWebAuthnCredentialsQuery, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count webauthn credentials: %w", err)
+	}
+	return count, nil
+}
+
+func (s *Storage) SaveWebAuthnSession(ctx context.Context, session models.WebAuthnSession) error {
+	_, err := s.db.ExecContext(ctx, saveWebAuthnSessionQuery,
+		session.ID,
+		session.UserID,
+		session.Purpose,
+		session.Data,
+		session.ExpiresAt,
+		session.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save webauthn session: %w", err)
+	}
+	return nil
+}
+
+// ConsumeWebAuthnSession достает и удаляет сессию церемонии. Каждая сессия используется один раз
+func (s *Storage) ConsumeWebAuthnSession(ctx context.Context, id uuid.UUID, purpose string) (models.WebAuthnSession, error) {
+	var session models.WebAuthnSession
+	var userID uuid.NullUUID
+	err := s.db.QueryRowContext(ctx, consumeWebAuthnSessionQuery, id, purpose).Scan(
+		&session.ID,
+		&userID,
+		&session.Purpose,
+		&session.Data,
+		&session.ExpiresAt,
+		&session.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return session, ErrWebAuthnSessionNotFound
+		}
+		return session, fmt.Errorf("failed to consume webauthn session: %w", err)
+	}
+	if userID.Valid {
+		session.UserID = &userID.UUID
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return session, ErrWebAuthnSessionNotFound
+	}
+	return session, nil
+}
+
+func (s *Storage) DeleteExpiredWebAuthnSessions(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, deleteExpiredWebAuthnSessionsQuery, time.Now()); err != nil {
+		return fmt.Errorf("failed to delete expired webauthn sessions: %w", err)
+	}
+	return nil
+}
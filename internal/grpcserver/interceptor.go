@@ -0,0 +1,160 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"itam_auth/internal/database"
+	"itam_auth/internal/services/auth"
+	"itam_auth/internal/services/jwt"
+	"itam_auth/internal/services/permission"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type claimsKey struct{}
+
+// permissionsKey - права вызова: снимок из access-токена или права персонального токена, ограниченные его scope
+type permissionsKey struct{}
+
+// authInterceptor проверяет access-токен или персональный токен из метаданных authorization так же,
+// как AuthMiddleware, и кладет claims и права вызова в контекст
+func authInterceptor(storage *database.Storage, keys *jwt.KeyRing, revocations jwt.RevocationChecker) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get("authorization")
+		if len(values) == 0 || values[0] == "" {
+			return nil, status.Error(codes.Unauthenticated, "authorization metadata is required")
+		}
+
+		// Как и в REST API, префикс Bearer необязателен
+		tokenString, _ := strings.CutPrefix(values[0], "Bearer ")
+		if auth.IsPersonalToken(tokenString) {
+			ctx, err := authenticatePersonalToken(ctx, storage, tokenString)
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}
+
+		claims, err := jwt.ParseToken(ctx, tokenString, keys, revocations)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+		}
+		if claims.IsClientToken() {
+			return nil, status.Error(codes.PermissionDenied, "token was issued to another application")
+		}
+
+		ctx = context.WithValue(ctx, claimsKey{}, claims)
+		// Как и в REST API, проверки прав используют снимок из токена; без claim perms их прочитает из базы callerPermissions
+		if permissions := claims.User().Permissions; !claims.IsService() && len(permissions) > 0 {
+			ctx = context.WithValue(ctx, permissionsKey{}, permission.FromNames(permissions))
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authenticatePersonalToken кладет в контекст claims и права вызова с персональным токеном
+func authenticatePersonalToken(ctx context.Context, storage *database.Storage, tokenString string) (context.Context, error) {
+	token, user, err := auth.AuthenticatePersonalToken(ctx, storage, tokenString, peerIP(ctx))
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidPersonalToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+		}
+		log.Printf("Failed to authenticate personal access token: %v", err)
+		return nil, status.Error(codes.Internal, "failed to check token")
+	}
+
+	permissions, err := auth.PersonalTokenPermissions(ctx, storage, token)
+	if err != nil {
+		log.Printf("Failed to check permissions (user_id=%s): %v", user.ID, err)
+		return nil, status.Error(codes.Internal, "failed to check permissions")
+	}
+
+	ctx = context.WithValue(ctx, claimsKey{}, auth.PersonalTokenClaims(token, user))
+	return context.WithValue(ctx, permissionsKey{}, permissions), nil
+}
+
+// peerIP возвращает адрес вызывающего без порта
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// callerClaims возвращает claims токена, с которым выполняется вызов
+func callerClaims(ctx context.Context) (*jwt.Claims, error) {
+	claims, ok := ctx.Value(claimsKey{}).(*jwt.Claims)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "not authenticated")
+	}
+	return claims, nil
+}
+
+// requireScope пропускает сервисный аккаунт, только если ему выдан scope. Пользователей не ограничивает,
+// как middleware.RequireScope
+func requireScope(claims *jwt.Claims, scope string) error {
+	if claims.IsService() && !claims.HasScope(scope) {
+		return status.Errorf(codes.PermissionDenied, "scope %s is required", scope)
+	}
+	return nil
+}
+
+// requirePermission пропускает пользователя хотя бы с одним из прав. Сервисные аккаунты не ограничивает:
+// их доступ определяет requireScope, как и в middleware.RequirePermission
+func requirePermission(ctx context.Context, storage *database.Storage, claims *jwt.Claims, names ...string) error {
+	if claims.IsService() {
+		return nil
+	}
+
+	allowed, err := hasPermission(ctx, storage, claims, names...)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return status.Errorf(codes.PermissionDenied, "one of permissions is required: %s", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// hasPermission сообщает, есть ли у пользователя вызова хотя бы одно из прав
+func hasPermission(ctx context.Context, storage *database.Storage, claims *jwt.Claims, names ...string) (bool, error) {
+	permissions, err := callerPermissions(ctx, storage, claims)
+	if err != nil {
+		return false, err
+	}
+	return permissions.Has(names...), nil
+}
+
+// callerPermissions возвращает права пользователя вызова из того же источника, что и middleware.Permissions:
+// снимок из access-токена или права персонального токена, а без claim perms - текущие права из базы
+func callerPermissions(ctx context.Context, storage *database.Storage, claims *jwt.Claims) (permission.Set, error) {
+	if permissions, ok := ctx.Value(permissionsKey{}).(permission.Set); ok {
+		return permissions, nil
+	}
+
+	userID, err := uuid.Parse(claims.UID)
+	if err != nil {
+		return permission.Set{}, status.Error(codes.Unauthenticated, "invalid token subject")
+	}
+
+	permissions, err := permission.Resolve(ctx, storage, userID)
+	if err != nil {
+		log.Printf("Failed to check permissions (user_id=%s): %v", userID, err)
+		return permission.Set{}, status.Error(codes.Internal, "failed to check permissions")
+	}
+	return permissions, nil
+}
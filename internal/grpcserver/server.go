@@ -0,0 +1,253 @@
+// Package grpcserver - gRPC API сервиса авторизации для внутренних сервисов (api/proto/auth/v1/auth.proto).
+// Работает поверх тех же Storage, ключей и сервисов, что и REST API, и с теми же правами доступа
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"itam_auth/internal/config"
+	"itam_auth/internal/database"
+	"itam_auth/internal/models"
+	"itam_auth/internal/services/jwt"
+	"itam_auth/internal/services/oidc"
+	"itam_auth/internal/services/permission"
+	"itam_auth/pkg/authpb"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server реализует authpb.AuthServiceServer
+type Server struct {
+	authpb.UnimplementedAuthServiceServer
+
+	storage     *database.Storage
+	provider    *oidc.Provider
+	revocations jwt.RevocationChecker
+}
+
+// New создает gRPC-сервер с проверкой access-токена на каждом вызове. С GRPC_TLS_CERT_PATH и GRPC_TLS_KEY_PATH
+// сервер принимает только TLS-соединения, без них - открытый текст
+func New(storage *database.Storage, keys *jwt.KeyRing, revocations jwt.RevocationChecker, cfg *config.AppConfig) (*grpc.Server, error) {
+	options := []grpc.ServerOption{grpc.ChainUnaryInterceptor(authInterceptor(storage, keys, revocations))}
+	if cfg.GRPCTLSCertPath != "" || cfg.GRPCTLSKeyPath != "" {
+		creds, err := credentials.NewServerTLSFromFile(cfg.GRPCTLSCertPath, cfg.GRPCTLSKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load gRPC TLS certificate: %w", err)
+		}
+		options = append(options, grpc.Creds(creds))
+	}
+
+	server := grpc.NewServer(options...)
+	authpb.RegisterAuthServiceServer(server, &Server{
+		storage:     storage,
+		provider:    oidc.NewProvider(storage, keys, cfg),
+		revocations: revocations,
+	})
+	return server, nil
+}
+
+// ValidateToken проверяет токен так же, как /auth/oidc/introspect. Доступен сервисным аккаунтам со scope introspection
+func (s *Server) ValidateToken(ctx context.Context, req *authpb.ValidateTokenRequest) (*authpb.ValidateTokenResponse, error) {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !claims.IsService() || !claims.HasScope(models.ScopeIntrospection) {
+		return nil, status.Errorf(codes.PermissionDenied, "scope %s is required", models.ScopeIntrospection)
+	}
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	introspection, err := s.provider.Introspect(ctx, oidc.IntrospectionRequest{
+		Token:         req.GetToken(),
+		TokenTypeHint: oidc.TokenTypeHintAccess,
+	}, s.revocations)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to validate token")
+	}
+	if !introspection.Active {
+		return &authpb.ValidateTokenResponse{}, nil
+	}
+
+	response := &authpb.ValidateTokenResponse{
+		Active:        true,
+		TokenType:     introspection.TokenType,
+		Subject:       introspection.Subject,
+		Username:      introspection.Username,
+		ClientId:      introspection.ClientID,
+		AdminServices: introspection.AdminServices,
+		TokenId:       introspection.TokenID,
+	}
+	if introspection.Scope != "" {
+		response.Scopes = (&jwt.Claims{Scope: introspection.Scope}).Scopes()
+	}
+	if introspection.ExpiresAt != 0 {
+		response.ExpiresAt = timestamppb.New(time.Unix(introspection.ExpiresAt, 0))
+	}
+	if introspection.IssuedAt != 0 {
+		response.IssuedAt = timestamppb.New(time.Unix(introspection.IssuedAt, 0))
+	}
+	return response, nil
+}
+
+// GetUser возвращает профиль пользователя любому аутентифицированному вызову
+func (s *Server) GetUser(ctx context.Context, req *authpb.GetUserRequest) (*authpb.User, error) {
+	userID, err := parseUserID(req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		log.Printf("Failed to get user (user_id=%s): %v", userID, err)
+		return nil, status.Error(codes.Internal, "failed to get user")
+	}
+
+	return &authpb.User{
+		Id:            user.ID.String(),
+		Name:          user.Name,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Specification: string(user.Specification),
+		Telegram:      user.Telegram,
+		PhotoUrl:      user.PhotoURL,
+		About:         user.About,
+		ResumeUrl:     user.ResumeURL,
+		CreatedAt:     timestamppb.New(user.CreatedAt),
+		UpdatedAt:     timestamppb.New(user.UpdatedAt),
+	}, nil
+}
+
+// GetUserPermissions возвращает роли и права пользователя. Чужие права видны администратору (admin_auth)
+// и сервисным аккаунтам со scope introspection. Свои права пользователь получает те же, с которыми выполняется
+// вызов (снимок из токена), как в /auth/api/get_user_permissions; чужие читаются из базы
+func (s *Server) GetUserPermissions(ctx context.Context, req *authpb.GetUserPermissionsRequest) (*authpb.GetUserPermissionsResponse, error) {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := parseUserID(req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	// У сервисного аккаунта нет своего пользователя: любой его запрос - к чужим правам
+	if err := requireScope(claims, models.ScopeIntrospection); err != nil {
+		return nil, err
+	}
+	if !claims.IsService() && claims.UID != userID.String() {
+		if err := requirePermission(ctx, s.storage, claims, models.PermissionAdminAuth); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := s.storage.GetUserByID(ctx, userID); err != nil {
+		if errors.Is(err, database.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		log.Printf("Failed to get user (user_id=%s): %v", userID, err)
+		return nil, status.Error(codes.Internal, "failed to get user")
+	}
+
+	var permissions permission.Set
+	if !claims.IsService() && claims.UID == userID.String() {
+		permissions, err = callerPermissions(ctx, s.storage, claims)
+	} else {
+		permissions, err = permission.Resolve(ctx, s.storage, userID)
+	}
+	if err != nil {
+		log.Printf("Failed to resolve permissions (user_id=%s): %v", userID, err)
+		return nil, status.Error(codes.Internal, "failed to get permissions")
+	}
+	roles, err := s.storage.GetRolesByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to get roles (user_id=%s): %v", userID, err)
+		return nil, status.Error(codes.Internal, "failed to get roles")
+	}
+
+	response := &authpb.GetUserPermissionsResponse{
+		Permissions:   permissions.Names(),
+		AdminServices: permissions.AdminServices(),
+	}
+	for _, role := range roles {
+		response.Roles = append(response.Roles, role.Name)
+	}
+	slices.Sort(response.Roles)
+	return response, nil
+}
+
+// AwardAchievement выдает достижение и уведомляет пользователя, как /auth/api/create_achievement
+func (s *Server) AwardAchievement(ctx context.Context, req *authpb.AwardAchievementRequest) (*authpb.AwardAchievementResponse, error) {
+	claims, err := callerClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireScope(claims, models.ScopeAchievements); err != nil {
+		return nil, err
+	}
+	if err := requirePermission(ctx, s.storage, claims, models.PermissionAdminAchievements); err != nil {
+		return nil, err
+	}
+
+	userID, err := parseUserID(req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetTitle() == "" || req.GetPoints() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid title or points")
+	}
+	if _, err := s.storage.GetUserByID(ctx, userID); err != nil {
+		if errors.Is(err, database.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		log.Printf("Failed to get user (user_id=%s): %v", userID, err)
+		return nil, status.Error(codes.Internal, "failed to get user")
+	}
+
+	achievement := models.Achievement{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Title:       req.GetTitle(),
+		Description: req.Description,
+		Points:      req.GetPoints(),
+		Approved:    req.GetApproved(),
+		CreatedAt:   time.Now(),
+	}
+	if _, err := s.storage.SaveAchievement(ctx, achievement, userID); err != nil {
+		log.Printf("Failed to save achievement (user_id=%s): %v", userID, err)
+		return nil, status.Error(codes.Internal, "failed to save achievement")
+	}
+
+	notification := models.Notification{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Content:   fmt.Sprintf("Добавлено новое достижение: %s", achievement.Title),
+		CreatedAt: time.Now(),
+	}
+	if _, err := s.storage.SaveNotification(ctx, notification); err != nil {
+		// Достижение уже выдано: уведомление не обязательно
+		log.Printf("Failed to create notification (user_id=%s): %v", userID, err)
+	}
+
+	return &authpb.AwardAchievementResponse{AchievementId: achievement.ID.String()}, nil
+}
+
+func parseUserID(value string) (uuid.UUID, error) {
+	userID, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+	return userID, nil
+}
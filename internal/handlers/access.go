@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"itam_auth/internal/database"
+	"itam_auth/internal/middleware"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ownerOrAdmin пропускает владельца записи, пользователя с правом permission и сервисный аккаунт со scope.
+// Пустой scope закрывает доступ сервисным аккаунтам. Иначе сам отвечает ошибкой и возвращает false
+func ownerOrAdmin(c *gin.Context, storage *database.Storage, ownerID uuid.UUID, permission, scope string) bool {
+	if c.GetString("principal") == middleware.PrincipalService {
+		if scope == "" || !slices.Contains(c.GetStringSlice("scopes"), scope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return false
+		}
+		return true
+	}
+	if c.GetString("user_id") == ownerID.String() {
+		return true
+	}
+
+	allowed, err := middleware.HasPermission(c, storage, permission)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions", "details": err.Error()})
+		return false
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return false
+	}
+	return true
+}
+
+// targetUserID возвращает пользователя из параметра user_id, а если он не передан - текущего пользователя
+func targetUserID(c *gin.Context) (uuid.UUID, bool) {
+	value := c.Query("user_id")
+	if value == "" {
+		value = c.GetString("user_id")
+	}
+
+	userID, err := uuid.Parse(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
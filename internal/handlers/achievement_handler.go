@@ -29,7 +29,7 @@ func parseIntQuery(c *gin.Context, paramName string, defaultValue int) (int, err
 }
 
 // @Summary Создать достижение
-// @Description Создает новое достижение
+// @Description Создает новое достижение. Доступно с правом admin_achievements
 // @Tags Achievements
 // @Accept json
 // @Produce json
@@ -37,6 +37,7 @@ func parseIntQuery(c *gin.Context, paramName string, defaultValue int) (int, err
 // @Security OAuth2Password
 // @Success 201 {object} map[string]interface{} "Success message with ID"
 // @Failure 400 {object} map[string]string "Invalid title or points"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
 // @Failure 500 {object} map[string]string "Failed to save achievement"
 // @Router /auth/api/create_achievement [post]
 func CreateAchievement(storage *database.Storage) gin.HandlerFunc {
@@ -84,7 +85,7 @@ func CreateAchievement(storage *database.Storage) gin.HandlerFunc {
 }
 
 // @Summary Обновить достижение
-// @Description Обновляет существующее достижение
+// @Description Обновляет существующее достижение. Доступно с правом admin_achievements
 // @Tags Achievements
 // @Accept json
 // @Produce json
@@ -92,6 +93,7 @@ func CreateAchievement(storage *database.Storage) gin.HandlerFunc {
 // @Security OAuth2Password
 // @Success 200 {object} map[string]string "Success message"
 // @Failure 400 {object} map[string]string "Invalid achievement ID or data"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /auth/api/update_achievement [patch]
 func UpdateAchievement(storage *database.Storage) gin.HandlerFunc {
@@ -229,13 +231,14 @@ func GetAchievementsByUserID(storage *database.Storage) gin.HandlerFunc {
 }
 
 // @Summary Удалить достижение
-// @Description Удаляет достижение по его ID
+// @Description Удаляет достижение по его ID. Доступно с правом admin_achievements
 // @Tags Achievements
 // @Produce json
 // @Param achievement_id query string true "Achievement ID"
 // @Security OAuth2Password
 // @Success 200 {object} map[string]string "Success message"
 // @Failure 400 {object} map[string]string "Invalid achievement ID"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /auth/api/delete_achievement [delete]
 func DeleteAchievement(storage *database.Storage) gin.HandlerFunc {
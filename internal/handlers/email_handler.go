@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"itam_auth/internal/config"
+	"itam_auth/internal/database"
+	"itam_auth/internal/services/auth"
+	"itam_auth/internal/services/jwt"
+	"itam_auth/internal/services/mailer"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyEmailRequest представляет подтверждение email токеном из письма
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+// ResendVerificationRequest представляет запрос на повторное письмо подтверждения
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required" example:"john@example.com"`
+}
+
+// @Summary Подтверждение email
+// @Description Подтверждает email токеном из письма. Каждый токен принимается один раз
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param verify body handlers.VerifyEmailRequest true "Verification token"
+// @Success 200 {object} models.SuccessResponse "Success message"
+// @Failure 400 {object} models.ErrorResponse "Invalid, expired or used token"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/verify_email [post]
+func VerifyEmail(storage *database.Storage, keys *jwt.KeyRing) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req VerifyEmailRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if _, err := auth.VerifyEmail(c.Request.Context(), storage, keys, req.Token); err != nil {
+			if errors.Is(err, database.ErrEmailVerificationTokenInvalid) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token", "details": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+	}
+}
+
+// @Summary Повторное письмо подтверждения
+// @Description Отправляет новое письмо подтверждения, если адрес зарегистрирован и еще не подтвержден. Ответ одинаковый в любом случае
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param resend body handlers.ResendVerificationRequest true "Email"
+// @Success 202 {object} models.SuccessResponse "Success message"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Router /auth/api/resend_verification [post]
+func ResendVerificationEmail(storage *database.Storage, keys *jwt.KeyRing, mail mailer.Mailer, cfg *config.AppConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ResendVerificationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := auth.ResendVerificationEmail(c.Request.Context(), storage, keys, emailVerification(mail, cfg), req.Email); err != nil {
+			log.Printf("Failed to resend verification email (email=%s): %v", req.Email, err)
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"message": "If the email is registered and not verified yet, a new verification link has been sent"})
+	}
+}
+
+func emailVerification(mail mailer.Mailer, cfg *config.AppConfig) auth.EmailVerification {
+	return auth.EmailVerification{Mailer: mail, LinkURL: cfg.EmailVerifyURL}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"itam_auth/internal/config"
 	"itam_auth/internal/database"
+	"itam_auth/internal/models"
 	"itam_auth/internal/services/file"
 	"net/http"
 	"path/filepath"
@@ -88,7 +89,7 @@ func UploadProfileImage(storage *database.Storage, fileService *file.FileService
 }
 
 // @Summary Загрузить изображение достижения
-// @Description Загружает изображение для достижения и обновляет поле image_url
+// @Description Загружает изображение для достижения и обновляет поле image_url. Доступно с правом admin_achievements
 // @Tags Files
 // @Accept multipart/form-data
 // @Produce json
@@ -98,6 +99,7 @@ func UploadProfileImage(storage *database.Storage, fileService *file.FileService
 // @Success 200 {object} models.FileUploadResponse "Success message with file info"
 // @Failure 400 {object} models.ErrorResponse "Invalid file or request"
 // @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
 // @Router /auth/api/upload_achievement_image [post]
 func UploadAchievementImage(storage *database.Storage, fileService *file.FileService) gin.HandlerFunc {
@@ -258,7 +260,7 @@ func GetUserFiles(storage *database.Storage) gin.HandlerFunc {
 }
 
 // @Summary Удалить файл
-// @Description Удаляет загруженный файл (только владелец файла может его удалить)
+// @Description Удаляет загруженный файл (только владелец файла или администратор с правом admin_files)
 // @Tags Files
 // @Produce json
 // @Param file_id path string true "File ID (UUID)"
@@ -279,9 +281,8 @@ func DeleteFile(storage *database.Storage, fileService *file.FileService) gin.Ha
 			return
 		}
 
-		// Преобразуем строку в UUID
-		userID, err := uuid.Parse(userIDStr.(string))
-		if err != nil {
+		// Проверяем формат ID пользователя
+		if _, err := uuid.Parse(userIDStr.(string)); err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID format"})
 			return
 		}
@@ -302,9 +303,8 @@ func DeleteFile(storage *database.Storage, fileService *file.FileService) gin.Ha
 			return
 		}
 
-		// Проверяем, что файл принадлежит пользователю
-		if fileUpload.UserID != userID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		// Удалить файл может владелец или администратор файлов
+		if !ownerOrAdmin(c, storage, fileUpload.UserID, models.PermissionAdminFiles, "") {
 			return
 		}
 
@@ -318,13 +318,13 @@ func DeleteFile(storage *database.Storage, fileService *file.FileService) gin.Ha
 		switch fileUpload.UploadType {
 		case "profile_image":
 			// Очищаем photo_url в таблице пользователей
-			if err := storage.UpdateUserProfileImage(ctx, userID, ""); err != nil {
+			if err := storage.UpdateUserProfileImage(ctx, fileUpload.UserID, ""); err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user profile"})
 				return
 			}
 		case "resume":
 			// Очищаем resume_url в таблице пользователей
-			if err := storage.UpdateUserResumeURL(ctx, userID, ""); err != nil {
+			if err := storage.UpdateUserResumeURL(ctx, fileUpload.UserID, ""); err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user resume"})
 				return
 			}
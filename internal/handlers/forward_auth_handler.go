@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"errors"
+	"itam_auth/internal/config"
+	"itam_auth/internal/database"
+	"itam_auth/internal/services/auth"
+	"itam_auth/internal/services/jwt"
+	"itam_auth/internal/services/permission"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Проверка доступа для reverse proxy
+// @Description Эндпоинт для nginx auth_request и Traefik ForwardAuth. Принимает access-токен или персональный токен из заголовка Authorization или из cookie сессии. С параметром permission пропускает только пользователя, у которого есть хотя бы одно из перечисленных прав. При успехе отвечает 200 с заголовками X-User-Id, X-User-Email и X-Admin-Services (для персонального токена - в пределах его scope, для сервисного аккаунта - X-Client-Id и X-Scopes)
+// @Tags Auth
+// @Produce json
+// @Param permission query []string false "Required permission (any of)" collectionFormat(multi)
+// @Success 200 "Access granted, identity in response headers"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or revoked token"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/forward_auth [get]
+func ForwardAuth(storage *database.Storage, keys *jwt.KeyRing, revocations jwt.RevocationChecker, cfg *config.AppConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "no-store")
+
+		token := forwardAuthToken(c, cfg.ForwardAuthCookie)
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header or session cookie is required"})
+			return
+		}
+
+		required := forwardAuthPermissions(c)
+		if auth.IsPersonalToken(token) {
+			forwardAuthPersonalToken(c, storage, token, required)
+			return
+		}
+
+		claims, err := jwt.ParseToken(c.Request.Context(), token, keys, revocations)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			return
+		}
+		// Токены, выданные сторонним приложениям через OpenID Connect, не открывают приложения за прокси
+		if claims.IsClientToken() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Token was issued to another application"})
+			return
+		}
+
+		if claims.IsService() {
+			// У сервисных аккаунтов нет прав, только scope
+			if len(required) > 0 {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+				return
+			}
+			c.Header("X-Client-Id", claims.ClientID)
+			c.Header("X-Scopes", claims.Scope)
+			c.Status(http.StatusOK)
+			return
+		}
+
+		// Права и X-Admin-Services берутся из одного источника, как в middleware.Permissions: снимок из токена,
+		// а без claim perms - текущие права из базы
+		user := claims.User()
+		permissions := permission.FromNames(user.Permissions)
+		if len(user.Permissions) == 0 {
+			permissions, err = permission.Resolve(c.Request.Context(), storage, user.ID)
+			if err != nil {
+				log.Printf("Failed to check permissions for forward auth (user_id=%s): %v", user.ID, err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+				return
+			}
+		}
+		if len(required) > 0 && !permissions.Has(required...) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			return
+		}
+
+		c.Header("X-User-Id", user.ID.String())
+		c.Header("X-User-Email", user.Email)
+		c.Header("X-Admin-Services", strings.Join(permissions.AdminServices(), ","))
+		c.Status(http.StatusOK)
+	}
+}
+
+// forwardAuthPersonalToken пропускает запрос с персональным токеном. Права - те же, что у токена в API:
+// текущие права владельца в пределах scope токена
+func forwardAuthPersonalToken(c *gin.Context, storage *database.Storage, token string, required []string) {
+	record, user, err := auth.AuthenticatePersonalToken(c.Request.Context(), storage, token, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidPersonalToken) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			return
+		}
+		log.Printf("Failed to check personal access token for forward auth: %v", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check token"})
+		return
+	}
+
+	permissions, err := auth.PersonalTokenPermissions(c.Request.Context(), storage, record)
+	if err != nil {
+		log.Printf("Failed to check permissions for forward auth (user_id=%s): %v", user.ID, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return
+	}
+	if len(required) > 0 && !permissions.Has(required...) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		return
+	}
+
+	c.Header("X-User-Id", user.ID.String())
+	c.Header("X-User-Email", user.Email)
+	c.Header("X-Admin-Services", strings.Join(permissions.AdminServices(), ","))
+	c.Status(http.StatusOK)
+}
+
+// @Summary Открыть сессию для reverse proxy
+// @Description Сохраняет текущий access-токен в HttpOnly cookie, по которой /auth/forward_auth пропускает переходы браузера к приложениям за прокси. Cookie живет, пока действует токен; после обновления токена запрос нужно повторить
+// @Tags Auth
+// @Produce json
+// @Security OAuth2Password
+// @Success 200 {object} models.SuccessResponse "Success message"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Router /auth/api/forward_auth/session [post]
+func CreateForwardAuthSession(cfg *config.AppConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := currentClaims(c)
+		if !ok {
+			return
+		}
+		if claims.IsService() {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		token, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		maxAge := 0
+		if claims.ExpiresAt != nil {
+			maxAge = int(time.Until(claims.ExpiresAt.Time).Seconds())
+		}
+
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(cfg.ForwardAuthCookie, token, maxAge, "/", cfg.ForwardAuthCookieDomain, cfg.ForwardAuthCookieSecure, true)
+		c.JSON(http.StatusOK, gin.H{"message": "Session cookie set successfully"})
+	}
+}
+
+// @Summary Закрыть сессию для reverse proxy
+// @Description Удаляет cookie сессии. Сам токен не отзывается - для этого есть /auth/api/logout
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} models.SuccessResponse "Success message"
+// @Router /auth/api/forward_auth/session [delete]
+func DeleteForwardAuthSession(cfg *config.AppConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(cfg.ForwardAuthCookie, "", -1, "/", cfg.ForwardAuthCookieDomain, cfg.ForwardAuthCookieSecure, true)
+		c.JSON(http.StatusOK, gin.H{"message": "Session cookie deleted successfully"})
+	}
+}
+
+// forwardAuthToken берет токен из заголовка Authorization (как AuthMiddleware), а без него - из cookie сессии
+func forwardAuthToken(c *gin.Context, cookieName string) string {
+	if header := c.GetHeader("Authorization"); header != "" {
+		token, _ := strings.CutPrefix(header, "Bearer ")
+		return strings.TrimSpace(token)
+	}
+	token, err := c.Cookie(cookieName)
+	if err != nil {
+		return ""
+	}
+	return token
+}
+
+// forwardAuthPermissions возвращает права из ?permission=a&permission=b или ?permission=a,b
+func forwardAuthPermissions(c *gin.Context) []string {
+	var permissions []string
+	for _, value := range c.QueryArray("permission") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				permissions = append(permissions, name)
+			}
+		}
+	}
+	return permissions
+}
+
+func currentClaims(c *gin.Context) (*jwt.Claims, bool) {
+	tokenClaims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
+
+	claims, ok := tokenClaims.(*jwt.Claims)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid token data"})
+		return nil, false
+	}
+	return claims, true
+}
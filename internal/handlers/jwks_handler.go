@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"itam_auth/internal/services/jwt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Публичные ключи подписи токенов
+// @Description Возвращает набор публичных ключей (JWKS), которыми другие сервисы могут проверять токены. HMAC-ключи не публикуются
+// @Tags Keys
+// @Produce json
+// @Success 200 {object} jwt.JWKS "JSON Web Key Set"
+// @Router /.well-known/jwks.json [get]
+func JWKS(keys *jwt.KeyRing) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, keys.JWKS())
+	}
+}
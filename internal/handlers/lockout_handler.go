@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"itam_auth/internal/database"
+	"itam_auth/internal/services/auth"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Блокировки входа
+// @Description Возвращает email и IP-адреса с недавними неудачными попытками входа и действующими блокировками. Доступно с правом admin_auth
+// @Tags Admin
+// @Produce json
+// @Security OAuth2Password
+// @Success 200 {array} models.LoginThrottle "Login throttles"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/admin/login_lockouts [get]
+func GetLoginLockouts(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		throttles, err := auth.ListLoginLockouts(c.Request.Context(), storage)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get login lockouts", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, throttles)
+	}
+}
+
+// @Summary Снять блокировку входа
+// @Description Сбрасывает счетчик неудачных попыток и снимает блокировку. Доступно с правом admin_auth
+// @Tags Admin
+// @Produce json
+// @Security OAuth2Password
+// @Param key query string true "Throttle key: account:<email> or ip:<address>"
+// @Success 200 {object} models.SuccessResponse "Success message"
+// @Failure 400 {object} models.ErrorResponse "Key is required"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Lockout not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/admin/login_lockouts [delete]
+func ClearLoginLockout(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.Query("key")
+		if key == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "key is required"})
+			return
+		}
+
+		if err := auth.ClearLoginLockout(c.Request.Context(), storage, key); err != nil {
+			if errors.Is(err, database.ErrLoginThrottleNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Lockout not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear login lockout", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Login lockout cleared successfully"})
+	}
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"errors"
+	"itam_auth/internal/database"
+	"itam_auth/internal/models"
+	"itam_auth/internal/services/auth"
+	"itam_auth/internal/services/jwt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MFALoginRequest представляет второй шаг входа
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	Code     string `json:"code" binding:"required" example:"123456"`
+}
+
+// MFAEnrollRequest представляет запрос на обязательное подключение TOTP во время входа
+type MFAEnrollRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+// MFACodeRequest представляет запрос, подтвержденный кодом второго фактора
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+// @Summary Второй шаг входа
+// @Description Принимает MFA-токен, выданный после пароля, и код TOTP или код восстановления. Если подключение TOTP было обязательным и делалось во время входа, в ответе также будут коды восстановления
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param login body handlers.MFALoginRequest true "MFA token and code"
+// @Success 200 {object} models.LoginResponse "Access and refresh tokens"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid MFA token or code"
+// @Failure 429 {object} models.ErrorResponse "Too many invalid codes"
+// @Router /auth/api/login/mfa [post]
+func LoginMFA(storage *database.Storage, keys *jwt.KeyRing) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req MFALoginRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		tokens, recoveryCodes, err := auth.CompleteMFALogin(c.Request.Context(), storage, req.MFAToken, req.Code, keys)
+		if err != nil {
+			mfaError(c, err)
+			return
+		}
+
+		response := tokenResponse(tokens)
+		if len(recoveryCodes) > 0 {
+			response["recovery_codes"] = recoveryCodes
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// @Summary Подключение TOTP во время входа
+// @Description Для пользователей, которым второй фактор обязателен, но еще не подключен. Возвращает секрет и otpauth URI; подключение подтверждается первым кодом на /auth/api/login/mfa
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param enroll body handlers.MFAEnrollRequest true "MFA token"
+// @Success 200 {object} map[string]string "secret and otpauth_uri"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid MFA token"
+// @Failure 409 {object} models.ErrorResponse "MFA is already enabled"
+// @Router /auth/api/login/mfa/enroll [post]
+func LoginMFAEnroll(storage *database.Storage, keys *jwt.KeyRing) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req MFAEnrollRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		enrollment, err := auth.BeginEnrollmentWithMFAToken(c.Request.Context(), storage, req.MFAToken, keys)
+		if err != nil {
+			mfaError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"secret": enrollment.Secret, "otpauth_uri": enrollment.URI})
+	}
+}
+
+// @Summary Состояние второго фактора
+// @Description Сообщает, включен ли TOTP и сколько осталось неиспользованных кодов восстановления
+// @Tags MFA
+// @Produce json
+// @Security OAuth2Password
+// @Success 200 {object} map[string]interface{} "enabled and recovery_codes_left"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/mfa [get]
+func GetMFAStatus(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userObj, ok := currentUser(c)
+		if !ok {
+			return
+		}
+
+		enabled, left, err := auth.MFAStatus(c.Request.Context(), storage, userObj.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get MFA status", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"enabled": enabled, "recovery_codes_left": left})
+	}
+}
+
+// @Summary Начать подключение TOTP
+// @Description Создает секрет и otpauth URI для приложения-аутентификатора. Второй фактор включится после подтверждения кодом
+// @Tags MFA
+// @Produce json
+// @Security OAuth2Password
+// @Success 200 {object} map[string]string "secret and otpauth_uri"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 409 {object} models.ErrorResponse "MFA is already enabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/mfa/totp/enroll [post]
+func EnrollTOTP(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userObj, ok := currentUser(c)
+		if !ok {
+			return
+		}
+
+		enrollment, err := auth.BeginTOTPEnrollment(c.Request.Context(), storage, userObj.ID)
+		if err != nil {
+			mfaError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"secret": enrollment.Secret, "otpauth_uri": enrollment.URI})
+	}
+}
+
+// @Summary Подтвердить подключение TOTP
+// @Description Включает второй фактор первым кодом из приложения и возвращает коды восстановления. Коды показываются один раз
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Security OAuth2Password
+// @Param confirm body handlers.MFACodeRequest true "TOTP code"
+// @Success 200 {object} map[string][]string "recovery_codes"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid code"
+// @Failure 409 {object} models.ErrorResponse "MFA is already enabled"
+// @Router /auth/api/mfa/totp/confirm [post]
+func ConfirmTOTP(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userObj, ok := currentUser(c)
+		if !ok {
+			return
+		}
+
+		var req MFACodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		codes, err := auth.ConfirmTOTPEnrollment(c.Request.Context(), storage, userObj.ID, req.Code)
+		if err != nil {
+			mfaError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+	}
+}
+
+// @Summary Новые коды восстановления
+// @Description Заменяет коды восстановления новыми. Подтверждается кодом TOTP
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Security OAuth2Password
+// @Param regenerate body handlers.MFACodeRequest true "TOTP code"
+// @Success 200 {object} map[string][]string "recovery_codes"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid code"
+// @Failure 404 {object} models.ErrorResponse "MFA is not enabled"
+// @Router /auth/api/mfa/recovery_codes [post]
+func RegenerateRecoveryCodes(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userObj, ok := currentUser(c)
+		if !ok {
+			return
+		}
+
+		var req MFACodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		codes, err := auth.RegenerateRecoveryCodes(c.Request.Context(), storage, userObj.ID, req.Code)
+		if err != nil {
+			mfaError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+	}
+}
+
+// @Summary Отключить TOTP
+// @Description Отключает второй фактор. Подтверждается кодом TOTP или кодом восстановления
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Security OAuth2Password
+// @Param disable body handlers.MFACodeRequest true "TOTP or recovery code"
+// @Success 200 {object} models.SuccessResponse "Success message"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid code"
+// @Failure 404 {object} models.ErrorResponse "MFA is not enabled"
+// @Router /auth/api/mfa/totp [delete]
+func DisableTOTP(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userObj, ok := currentUser(c)
+		if !ok {
+			return
+		}
+
+		var req MFACodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := auth.DisableTOTP(c.Request.Context(), storage, userObj.ID, req.Code); err != nil {
+			mfaError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "MFA disabled successfully"})
+	}
+}
+
+// currentUser достает пользователя, которого выставил AuthMiddleware, или сам отвечает ошибкой
+func currentUser(c *gin.Context) (models.User, bool) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return models.User{}, false
+	}
+
+	userObj, ok := user.(models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user data"})
+		return models.User{}, false
+	}
+	return userObj, true
+}
+
+func mfaError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, auth.ErrMFALocked):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many invalid codes", "details": err.Error()})
+	case errors.Is(err, database.ErrMFAAlreadyEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": "MFA is already enabled"})
+	case errors.Is(err, database.ErrMFANotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "MFA is not enabled"})
+	case errors.Is(err, auth.ErrInvalidMFACode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+	default:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "MFA verification failed", "details": err.Error()})
+	}
+}
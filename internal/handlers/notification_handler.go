@@ -3,8 +3,10 @@ package handlers
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"itam_auth/internal/database"
+	"itam_auth/internal/middleware"
 	"itam_auth/internal/models"
 	"log"
 	"net/http"
@@ -15,7 +17,7 @@ import (
 )
 
 // @Summary Создать уведомление
-// @Description Создает новое уведомление
+// @Description Создает новое уведомление. Доступно с правом admin_notifications
 // @Tags Notifications
 // @Accept json
 // @Produce json
@@ -23,6 +25,7 @@ import (
 // @Security OAuth2Password
 // @Success 201 {object} map[string]string "Success message"
 // @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /auth/api/create_notification [post]
 func CreateNotification(storage *database.Storage) gin.HandlerFunc {
@@ -52,7 +55,7 @@ func CreateNotification(storage *database.Storage) gin.HandlerFunc {
 
 // UpdateNotification обновляет существующее уведомление
 // @Summary Обновить уведомление
-// @Description Обновляет существующее уведомление
+// @Description Обновляет существующее уведомление. Доступно с правом admin_notifications
 // @Tags Notifications
 // @Accept json
 // @Produce json
@@ -60,6 +63,7 @@ func CreateNotification(storage *database.Storage) gin.HandlerFunc {
 // @Security OAuth2Password
 // @Success 200 {object} map[string]string "Success message"
 // @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /auth/api/update_notification [patch]
 func UpdateNotification(storage *database.Storage) gin.HandlerFunc {
@@ -87,7 +91,7 @@ func UpdateNotification(storage *database.Storage) gin.HandlerFunc {
 }
 
 // @Summary Получить все уведомления
-// @Description Возвращает список всех уведомлений или уведомлений пользователя с пагинацией
+// @Description Возвращает уведомления пользователя с пагинацией. Без user_id пользователь получает свои уведомления, а администратор (admin_notifications) и сервисный аккаунт - все
 // @Tags Notifications
 // @Produce json
 // @Param user_id query string false "User ID"
@@ -96,6 +100,7 @@ func UpdateNotification(storage *database.Storage) gin.HandlerFunc {
 // @Security OAuth2Password
 // @Success 200 {array} models.Notification "List of notifications"
 // @Failure 400 {object} map[string]string "Invalid user ID or pagination parameters"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /auth/api/get_all_notifications [get]
 func GetAllNotifications(storage *database.Storage) gin.HandlerFunc {
@@ -116,12 +121,23 @@ func GetAllNotifications(storage *database.Storage) gin.HandlerFunc {
 		ctx := context.Background()
 		var notifications []models.Notification
 
+		listAll := false
 		if userID == "" {
+			listAll = c.GetString("principal") == middleware.PrincipalService
+			if !listAll {
+				listAll, err = middleware.HasPermission(c, storage, models.PermissionAdminNotifications)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions", "details": err.Error()})
+					return
+				}
+			}
+		}
+
+		if listAll {
 			notifications, err = storage.GetAllNotifications(ctx, limit, offset)
 		} else {
-			uuidUserID, errUUID := uuid.Parse(userID)
-			if errUUID != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			uuidUserID, ok := targetUserID(c)
+			if !ok || !ownerOrAdmin(c, storage, uuidUserID, models.PermissionAdminNotifications, models.ScopeNotifications) {
 				return
 			}
 			notifications, err = storage.GetNotifications(ctx, uuidUserID, limit, offset)
@@ -137,13 +153,14 @@ func GetAllNotifications(storage *database.Storage) gin.HandlerFunc {
 }
 
 // @Summary Получить уведомление по ID
-// @Description Возвращает уведомление по его ID
+// @Description Возвращает уведомление по его ID. Чужие уведомления доступны только с правом admin_notifications
 // @Tags Notifications
 // @Produce json
 // @Param notification_id path string true "Notification ID"
 // @Security OAuth2Password
 // @Success 200 {object} models.Notification "Notification data"
 // @Failure 400 {object} map[string]string "Invalid notification ID"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 404 {object} map[string]string "Notification not found"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /auth/api/get_notification/{notification_id} [get]
@@ -159,26 +176,30 @@ func GetNotification(storage *database.Storage) gin.HandlerFunc {
 		ctx := context.Background()
 		notification, err := storage.GetNotificationByID(ctx, uuidNotificationID)
 		if err != nil {
-			if err == sql.ErrNoRows {
+			if errors.Is(err, sql.ErrNoRows) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
 				return
 			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while fetching notification"})
 			return
 		}
+		if !ownerOrAdmin(c, storage, notification.UserID, models.PermissionAdminNotifications, models.ScopeNotifications) {
+			return
+		}
 
 		c.JSON(http.StatusOK, notification)
 	}
 }
 
 // @Summary Удалить уведомление
-// @Description Удаляет уведомление по ID
+// @Description Удаляет уведомление по ID. Пользователь может удалить только свое уведомление, администратор (admin_notifications) - любое
 // @Tags Notifications
 // @Produce json
 // @Param notification_id query string true "Notification ID"
 // @Security OAuth2Password
 // @Success 200 {object} map[string]string "Success message"
 // @Failure 400 {object} map[string]string "Invalid notification ID"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 404 {object} map[string]string "Notification not found"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /auth/api/delete_notification [delete]
@@ -197,6 +218,19 @@ func DeleteNotification(storage *database.Storage) gin.HandlerFunc {
 		}
 
 		ctx := context.Background()
+		notification, err := storage.GetNotificationByID(ctx, uuidNotificationID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while deleting notification", "details": err.Error()})
+			return
+		}
+		if !ownerOrAdmin(c, storage, notification.UserID, models.PermissionAdminNotifications, models.ScopeNotifications) {
+			return
+		}
+
 		err = storage.DeleteNotification(ctx, uuidNotificationID)
 		if err != nil {
 			if err.Error() == fmt.Sprintf("no notification found with ID: %s", uuidNotificationID) {
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"errors"
+	"itam_auth/internal/database"
+	"itam_auth/internal/models"
+	"itam_auth/internal/services/jwt"
+	"itam_auth/internal/services/oidc"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Документ обнаружения OpenID Connect
+// @Description Возвращает адреса эндпоинтов и возможности OpenID-провайдера. Пока токены подписываются HS256, вход через OpenID Connect выключен и документ не публикуется
+// @Tags OIDC
+// @Produce json
+// @Success 200 {object} map[string]interface{} "OpenID Provider Metadata"
+// @Failure 404 {object} models.ErrorResponse "OpenID Connect is disabled"
+// @Router /.well-known/openid-configuration [get]
+func OIDCDiscovery(provider *oidc.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !provider.SingleSignOnEnabled() {
+			c.JSON(http.StatusNotFound, gin.H{"error": "OpenID Connect is disabled", "details": "tokens must be signed with RS256 or EdDSA"})
+			return
+		}
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, provider.Discovery())
+	}
+}
+
+// @Summary Начало входа через OpenID Connect
+// @Description Проверяет клиента и параметры запроса (authorization code + PKCE S256) и перенаправляет пользователя на страницу входа
+// @Tags OIDC
+// @Produce json
+// @Param response_type query string true "code"
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string true "Registered redirect URI"
+// @Param scope query string true "openid, profile, email"
+// @Param state query string false "State"
+// @Param nonce query string false "Nonce"
+// @Param code_challenge query string true "PKCE code challenge"
+// @Param code_challenge_method query string true "S256"
+// @Success 302 "Redirect to the login page or to redirect_uri with an error"
+// @Failure 400 {object} models.ErrorResponse "Unknown client or redirect_uri"
+// @Router /auth/oidc/authorize [get]
+func OIDCAuthorize(provider *oidc.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req oidc.AuthorizeRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if _, err := provider.ValidateClient(c.Request.Context(), req); err != nil {
+			oidcError(c, http.StatusBadRequest, err)
+			return
+		}
+		if err := provider.ValidateAuthorizeRequest(req); err != nil {
+			c.Redirect(http.StatusFound, oidc.ErrorRedirect(req, err))
+			return
+		}
+
+		c.Redirect(http.StatusFound, provider.LoginRedirect(req))
+	}
+}
+
+// @Summary Выдача кода авторизации
+// @Description Вызывается страницей входа от имени вошедшего пользователя. Возвращает адрес redirect_uri с кодом авторизации, на который нужно перенаправить браузер
+// @Tags OIDC
+// @Accept json
+// @Produce json
+// @Security OAuth2Password
+// @Param authorize body oidc.AuthorizeRequest true "Parameters of the original /authorize request"
+// @Success 200 {object} map[string]string "redirect_to"
+// @Failure 400 {object} models.ErrorResponse "Unknown client or redirect_uri"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/oidc/authorize [post]
+func OIDCAuthorizeConfirm(provider *oidc.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := c.Get("user")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		userObj, ok := user.(models.User)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user data"})
+			return
+		}
+
+		var req oidc.AuthorizeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Время входа переносится из сессии пользователя. У токенов, выпущенных до появления auth_time,
+		// его заменяет момент выпуска токена
+		authTime := time.Now()
+		if tokenClaims, exists := c.Get("claims"); exists {
+			if claims, ok := tokenClaims.(*jwt.Claims); ok {
+				if claims.AuthTime != nil {
+					authTime = claims.AuthTime.Time
+				} else if claims.IssuedAt != nil {
+					authTime = claims.IssuedAt.Time
+				}
+			}
+		}
+
+		redirectTo, err := provider.Authorize(c.Request.Context(), req, userObj, authTime)
+		if err != nil {
+			var oauthErr *oidc.Error
+			if errors.As(err, &oauthErr) {
+				oidcError(c, http.StatusBadRequest, err)
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue authorization code", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"redirect_to": redirectTo})
+	}
+}
+
+// @Summary Токен-эндпоинт OpenID Connect
+// @Description Обменивает код авторизации на access-, refresh- и ID-токены, обновляет токены по refresh-токену или выдает токен сервисному аккаунту (client_credentials). Конфиденциальные клиенты передают секрет через Basic-аутентификацию или в теле запроса
+// @Tags OIDC
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "authorization_code, refresh_token or client_credentials"
+// @Param code formData string false "Authorization code"
+// @Param redirect_uri formData string false "Redirect URI used in /authorize"
+// @Param code_verifier formData string false "PKCE code verifier"
+// @Param refresh_token formData string false "Refresh token"
+// @Param scope formData string false "Space-separated scopes for client_credentials"
+// @Param client_id formData string false "Client ID"
+// @Param client_secret formData string false "Client secret"
+// @Success 200 {object} oidc.TokenResponse "Tokens"
+// @Failure 400 {object} map[string]string "OAuth 2.0 error"
+// @Failure 401 {object} map[string]string "Invalid client"
+// @Router /auth/oidc/token [post]
+func OIDCToken(provider *oidc.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "no-store")
+		c.Header("Pragma", "no-cache")
+
+		var req oidc.TokenRequest
+		if err := c.ShouldBind(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+			return
+		}
+
+		if clientID, clientSecret, ok := c.Request.BasicAuth(); ok {
+			req.ClientID = clientID
+			req.ClientSecret = clientSecret
+		}
+
+		client, err := provider.AuthenticateClient(c.Request.Context(), req.ClientID, req.ClientSecret)
+		if err != nil {
+			oidcError(c, http.StatusUnauthorized, err)
+			return
+		}
+
+		tokens, err := provider.Exchange(c.Request.Context(), client, req)
+		if err != nil {
+			oidcError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, tokens)
+	}
+}
+
+// @Summary Проверка токена (RFC 7662)
+// @Description Сообщает, действителен ли access-, refresh- или персональный токен, выпущенный сервисом, и кому он выдан. Отозванные и просроченные токены возвращаются как {"active": false}. Доступно конфиденциальным клиентам со scope introspection, секрет передается через Basic-аутентификацию или в теле запроса
+// @Tags OIDC
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "Token to introspect"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Param client_id formData string false "Client ID"
+// @Param client_secret formData string false "Client secret"
+// @Success 200 {object} oidc.IntrospectionResponse "Token state"
+// @Failure 400 {object} map[string]string "OAuth 2.0 error"
+// @Failure 401 {object} map[string]string "Invalid client"
+// @Failure 403 {object} map[string]string "Client is not allowed to introspect tokens"
+// @Router /auth/oidc/introspect [post]
+func OIDCIntrospect(provider *oidc.Provider, revocations jwt.RevocationChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "no-store")
+		c.Header("Pragma", "no-cache")
+
+		var req oidc.IntrospectionRequest
+		if err := c.ShouldBind(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+			return
+		}
+
+		if clientID, clientSecret, ok := c.Request.BasicAuth(); ok {
+			req.ClientID = clientID
+			req.ClientSecret = clientSecret
+		}
+
+		client, err := provider.AuthenticateClient(c.Request.Context(), req.ClientID, req.ClientSecret)
+		if err != nil {
+			oidcError(c, http.StatusUnauthorized, err)
+			return
+		}
+		if err := oidc.AuthorizeIntrospection(client); err != nil {
+			oidcError(c, http.StatusForbidden, err)
+			return
+		}
+
+		response, err := provider.Introspect(c.Request.Context(), req, revocations)
+		if err != nil {
+			oidcError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// @Summary Данные пользователя OpenID Connect
+// @Description Возвращает стандартные claims текущего пользователя. Токен приложения получает только claims выданных ему scope (profile, email)
+// @Tags OIDC
+// @Produce json
+// @Security OAuth2Password
+// @Success 200 {object} map[string]interface{} "User claims"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/oidc/userinfo [get]
+func OIDCUserInfo(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := c.Get("user")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		userObj, ok := user.(models.User)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user data"})
+			return
+		}
+
+		fullUser, err := storage.GetUserByID(c.Request.Context(), userObj.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching user data", "details": err.Error()})
+			return
+		}
+
+		// Приложение получает только claims выданных ему scope, токен самого сервиса - все
+		scope := oidc.AllUserInfoScopes
+		if tokenClaims, exists := c.Get("claims"); exists {
+			if claims, ok := tokenClaims.(*jwt.Claims); ok && claims.IsClientToken() {
+				scope = claims.Scope
+			}
+		}
+
+		c.JSON(http.StatusOK, oidc.UserInfo(fullUser, scope))
+	}
+}
+
+// oidcError отвечает ошибкой в формате OAuth 2.0. Внутренние ошибки не раскрываются клиенту
+func oidcError(c *gin.Context, status int, err error) {
+	var oauthErr *oidc.Error
+	if !errors.As(err, &oauthErr) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	if oauthErr.Code == "invalid_client" && status == http.StatusUnauthorized {
+		c.Header("WWW-Authenticate", `Basic realm="oidc"`)
+	}
+	c.JSON(status, gin.H{"error": oauthErr.Code, "error_description": oauthErr.Description})
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"itam_auth/internal/config"
+	"itam_auth/internal/database"
+	"itam_auth/internal/services/auth"
+	"itam_auth/internal/services/jwt"
+	"itam_auth/internal/services/mailer"
+	"itam_auth/internal/services/revocation"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ForgotPasswordRequest представляет запрос ссылки для сброса пароля
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required" example:"john@example.com"`
+}
+
+// ResetPasswordRequest представляет установку нового пароля по токену из письма
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required" example:"mY2Xy1oZ9dM1lSs8bRZ0bQ..."`
+	Password string `json:"password" binding:"required" example:"newpassword123"`
+}
+
+// ChangePasswordRequest представляет смену пароля вошедшим пользователем
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required" example:"password123"`
+	NewPassword     string `json:"new_password" binding:"required" example:"newpassword123"`
+}
+
+// @Summary Запросить сброс пароля
+// @Description Отправляет на email ссылку для сброса пароля. Ответ одинаковый независимо от того, зарегистрирован ли адрес
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param forgot body handlers.ForgotPasswordRequest true "Email"
+// @Success 202 {object} models.SuccessResponse "Success message"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Router /auth/api/forgot_password [post]
+func ForgotPassword(storage *database.Storage, mail mailer.Mailer, cfg *config.AppConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ForgotPasswordRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		reset := auth.PasswordReset{Mailer: mail, LinkURL: cfg.PasswordResetURL}
+		if err := auth.RequestPasswordReset(c.Request.Context(), storage, reset, req.Email); err != nil {
+			log.Printf("Failed to request password reset (email=%s): %v", req.Email, err)
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"message": "If the email is registered, a password reset link has been sent"})
+	}
+}
+
+// @Summary Сбросить пароль
+// @Description Задает новый пароль по токену из письма. Токен принимается один раз; все сессии и персональные токены пользователя завершаются
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param reset body handlers.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} models.SuccessResponse "Success message"
+// @Failure 400 {object} models.ErrorResponse "Invalid token or password"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/reset_password [post]
+func ResetPassword(storage *database.Storage, revocations *revocation.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ResetPasswordRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := auth.ResetPassword(c.Request.Context(), storage, revocations, req.Token, req.Password); err != nil {
+			if errors.Is(err, database.ErrPasswordResetTokenInvalid) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to reset password", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+	}
+}
+
+// @Summary Сменить пароль
+// @Description Меняет пароль текущего пользователя. Нужен текущий пароль; неверный пароль учитывается в блокировке входа по email (429 и Retry-After). Все остальные сессии и персональные токены пользователя завершаются, а в ответе - новая пара токенов для текущей
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security OAuth2Password
+// @Param change body handlers.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} models.LoginResponse "New access and refresh tokens"
+// @Failure 400 {object} models.ErrorResponse "Invalid new password"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Current password is incorrect"
+// @Failure 429 {object} models.ErrorResponse "Too many failed attempts"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/change_password [post]
+func ChangePassword(storage *database.Storage, revocations *revocation.Store, keys *jwt.KeyRing) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userObj, ok := currentUser(c)
+		if !ok {
+			return
+		}
+
+		var req ChangePasswordRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		tokens, err := auth.ChangePassword(c.Request.Context(), storage, revocations, userObj.ID, req.CurrentPassword, req.NewPassword, keys)
+		if err != nil {
+			var locked *auth.LoginLockedError
+			switch {
+			case errors.As(err, &locked):
+				respondLoginLocked(c, locked)
+			case errors.Is(err, auth.ErrInvalidCurrentPassword):
+				c.JSON(http.StatusForbidden, gin.H{"error": "Current password is incorrect"})
+			case errors.Is(err, auth.ErrNoPassword):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "User has no password", "details": err.Error()})
+			default:
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to change password", "details": err.Error()})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, tokenResponse(tokens))
+	}
+}
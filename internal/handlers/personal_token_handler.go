@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"itam_auth/internal/database"
+	"itam_auth/internal/models"
+	"itam_auth/internal/services/auth"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreatePersonalTokenRequest представляет запрос на выпуск персонального токена
+type CreatePersonalTokenRequest struct {
+	Name          string   `json:"name" binding:"required" example:"achievements import"`
+	Scopes        []string `json:"scopes" example:"admin_achievements"`
+	ExpiresInDays int      `json:"expires_in_days" example:"90"`
+}
+
+// @Summary Персональные токены
+// @Description Возвращает неотозванные персональные токены текущего пользователя (без самих токенов)
+// @Tags Personal tokens
+// @Produce json
+// @Security OAuth2Password
+// @Success 200 {array} models.PersonalTokenResponse "Personal access tokens"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Personal access tokens are not allowed here"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/personal_tokens [get]
+func GetPersonalTokens(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userObj, ok := currentUser(c)
+		if !ok {
+			return
+		}
+
+		tokens, err := storage.GetPersonalTokensByUser(c.Request.Context(), userObj.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get personal access tokens", "details": err.Error()})
+			return
+		}
+
+		response := make([]models.PersonalTokenResponse, 0, len(tokens))
+		for _, token := range tokens {
+			response = append(response, token.Response())
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// @Summary Выпустить персональный токен
+// @Description Выпускает токен для скриптов и CI. Токен передается в Authorization вместо access-токена и дает доступ от имени пользователя, но только с правами из scopes (подмножество текущих прав пользователя). Срок - от 1 до 365 дней, по умолчанию 90. Токен показывается только в этом ответе
+// @Tags Personal tokens
+// @Accept json
+// @Produce json
+// @Security OAuth2Password
+// @Param token body handlers.CreatePersonalTokenRequest true "Token name, scopes and expiry"
+// @Success 201 {object} models.PersonalTokenResponse "Created token"
+// @Failure 400 {object} models.ErrorResponse "Invalid name, scopes or expiry"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Personal access tokens are not allowed here"
+// @Failure 409 {object} models.ErrorResponse "Too many personal access tokens"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/personal_tokens [post]
+func CreatePersonalToken(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userObj, ok := currentUser(c)
+		if !ok {
+			return
+		}
+
+		var req CreatePersonalTokenRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+			return
+		}
+
+		secret, token, err := auth.CreatePersonalToken(c.Request.Context(), storage, userObj.ID, auth.NewPersonalToken{
+			Name:          req.Name,
+			Scopes:        req.Scopes,
+			ExpiresInDays: req.ExpiresInDays,
+		})
+		switch {
+		case errors.Is(err, auth.ErrInvalidPersonalTokenRequest):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid personal access token", "details": err.Error()})
+			return
+		case errors.Is(err, auth.ErrTooManyPersonalTokens):
+			c.JSON(http.StatusConflict, gin.H{"error": "Too many personal access tokens", "details": err.Error()})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create personal access token", "details": err.Error()})
+			return
+		}
+
+		response := token.Response()
+		response.Token = secret
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+// @Summary Отозвать персональный токен
+// @Description Отзывает персональный токен текущего пользователя. Запросы с ним сразу перестают проходить
+// @Tags Personal tokens
+// @Produce json
+// @Security OAuth2Password
+// @Param token_id path string true "Token ID (UUID)"
+// @Success 200 {object} models.SuccessResponse "Success message"
+// @Failure 400 {object} models.ErrorResponse "Invalid token ID"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Personal access tokens are not allowed here"
+// @Failure 404 {object} models.ErrorResponse "Token not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/personal_tokens/{token_id} [delete]
+func RevokePersonalToken(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userObj, ok := currentUser(c)
+		if !ok {
+			return
+		}
+
+		tokenID, err := uuid.Parse(c.Param("token_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+			return
+		}
+
+		if err := storage.RevokePersonalToken(c.Request.Context(), userObj.ID, tokenID); err != nil {
+			if errors.Is(err, database.ErrPersonalTokenNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke personal access token", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Personal access token revoked successfully"})
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"itam_auth/internal/database"
 	"itam_auth/internal/models"
@@ -83,19 +84,19 @@ func CreateUserRequest(storage *database.Storage) gin.HandlerFunc {
 // @Description Возвращает список запросов пользователя с пагинацией
 // @Tags Requests
 // @Produce json
-// @Param user_id query string true "User ID"
+// @Param user_id query string false "User ID (по умолчанию - текущий пользователь; чужие запросы видны с правом admin_requests)"
 // @Param limit query int false "Limit" default(10)
 // @Param offset query int false "Offset" default(0)
 // @Security OAuth2Password
 // @Success 200 {object} map[string]interface{} "Request data"
 // @Failure 400 {object} map[string]string "Invalid user ID or pagination parameters"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /auth/api/get_request [get]
 func GetRequest(storage *database.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userID, err := uuid.Parse(c.Query("user_id"))
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		userID, ok := targetUserID(c)
+		if !ok || !ownerOrAdmin(c, storage, userID, models.PermissionAdminRequests, models.ScopeRequests) {
 			return
 		}
 
@@ -126,19 +127,19 @@ func GetRequest(storage *database.Storage) gin.HandlerFunc {
 // @Description Возвращает список всех запросов текущего пользователя с пагинацией
 // @Tags Requests
 // @Produce json
-// @Param user_id query string true "User ID"
+// @Param user_id query string false "User ID (по умолчанию - текущий пользователь; чужие запросы видны с правом admin_requests)"
 // @Param limit query int false "Limit" default(10)
 // @Param offset query int false "Offset" default(0)
 // @Security OAuth2Password
 // @Success 200 {object} map[string]interface{} "All requests"
 // @Failure 400 {object} map[string]string "Invalid user ID or pagination parameters"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /auth/api/get_all_requests [get]
 func GetAllRequests(storage *database.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userID, err := uuid.Parse(c.Query("user_id"))
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		userID, ok := targetUserID(c)
+		if !ok || !ownerOrAdmin(c, storage, userID, models.PermissionAdminRequests, models.ScopeRequests) {
 			return
 		}
 
@@ -166,7 +167,7 @@ func GetAllRequests(storage *database.Storage) gin.HandlerFunc {
 }
 
 // @Summary Обновить статус запроса
-// @Description Обновляет статус указанного запроса
+// @Description Обновляет статус указанного запроса. Доступно с правом admin_requests
 // @Tags Requests
 // @Accept json
 // @Produce json
@@ -174,6 +175,7 @@ func GetAllRequests(storage *database.Storage) gin.HandlerFunc {
 // @Security OAuth2Password
 // @Success 200 {object} map[string]string "Success message"
 // @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /auth/api/update_request_status [patch]
 func UpdateRequestStatus(storage *database.Storage) gin.HandlerFunc {
@@ -200,13 +202,14 @@ func UpdateRequestStatus(storage *database.Storage) gin.HandlerFunc {
 }
 
 // @Summary Удалить запрос
-// @Description Удаляет запрос по его ID
+// @Description Удаляет запрос по его ID. Пользователь может удалить только свой запрос, администратор (admin_requests) - любой
 // @Tags Requests
 // @Produce json
 // @Param request_id query string true "Request ID"
 // @Security OAuth2Password
 // @Success 200 {object} map[string]string "Success message"
 // @Failure 400 {object} map[string]string "Invalid request ID"
+// @Failure 403 {object} map[string]string "Access denied"
 // @Failure 404 {object} map[string]string "Request not found"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /auth/api/delete_request [delete]
@@ -225,6 +228,19 @@ func DeleteRequest(storage *database.Storage) gin.HandlerFunc {
 		}
 
 		ctx := c.Request.Context()
+		request, err := storage.GetRequestByID(ctx, requestID)
+		if err != nil {
+			if errors.Is(err, database.ErrRequestNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Request not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while deleting request", "details": err.Error()})
+			return
+		}
+		if !ownerOrAdmin(c, storage, request.UserID, models.PermissionAdminRequests, models.ScopeRequests) {
+			return
+		}
+
 		err = storage.DeleteRequest(ctx, requestID)
 		if err != nil {
 			if err.Error() == fmt.Sprintf("no request found with ID: %s", requestID) {
@@ -0,0 +1,699 @@
+package handlers
+
+import (
+	"errors"
+	"itam_auth/internal/database"
+	"itam_auth/internal/models"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CreateRoleRequest struct {
+	Name     string     `json:"name" binding:"required,max=255" example:"Moderator"`
+	ParentID *uuid.UUID `json:"parent_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"` // Роль, от которой наследуются права
+}
+
+type RenameRoleRequest struct {
+	RoleID uuid.UUID `json:"role_id" binding:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Name   string    `json:"name" binding:"required,max=255" example:"Moderator"`
+}
+
+type SetRoleParentRequest struct {
+	RoleID   uuid.UUID  `json:"role_id" binding:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ParentID *uuid.UUID `json:"parent_id" example:"550e8400-e29b-41d4-a716-446655440000"` // null убирает родителя
+}
+
+type CreatePermissionRequest struct {
+	Name string `json:"name" binding:"required,max=255" example:"admin_events"`
+}
+
+type RenamePermissionRequest struct {
+	PermissionID uuid.UUID `json:"permission_id" binding:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Name         string    `json:"name" binding:"required,max=255" example:"admin_events"`
+}
+
+type RolePermissionRequest struct {
+	RoleID       uuid.UUID `json:"role_id" binding:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
+	PermissionID uuid.UUID `json:"permission_id" binding:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
+}
+
+type UserRoleRequest struct {
+	UserID uuid.UUID `json:"user_id" binding:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
+	RoleID uuid.UUID `json:"role_id" binding:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
+}
+
+// @Summary Список ролей
+// @Description Возвращает все роли с их родительскими ролями. Доступно с правом admin_auth
+// @Tags Admin
+// @Produce json
+// @Security OAuth2Password
+// @Success 200 {array} models.Role "Roles"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/admin/roles [get]
+func GetRoles(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roles, err := storage.GetAllRoles(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get roles", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, roles)
+	}
+}
+
+// @Summary Создать роль
+// @Description Создает роль, при необходимости с родительской. Доступно с правом admin_auth
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body handlers.CreateRoleRequest true "Role"
+// @Security OAuth2Password
+// @Success 201 {object} map[string]interface{} "Success message with role ID"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Parent role not found"
+// @Failure 409 {object} models.ErrorResponse "Role already exists or hierarchy is too deep"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/admin/roles [post]
+func CreateRole(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req CreateRoleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+			return
+		}
+		name, ok := adminName(c, req.Name)
+		if !ok {
+			return
+		}
+		event, ok := adminAuditEvent(c)
+		if !ok {
+			return
+		}
+
+		role := models.Role{ID: uuid.New(), Name: name, ParentID: req.ParentID}
+		if err := storage.CreateRole(c.Request.Context(), role, event); err != nil {
+			roleError(c, err, "Failed to create role")
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Role created successfully", "id": role.ID})
+	}
+}
+
+// @Summary Переименовать роль
+// @Description Меняет название роли. Доступно с правом admin_auth
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body handlers.RenameRoleRequest true "Role ID and new name"
+// @Security OAuth2Password
+// @Success 200 {object} models.SuccessResponse "Success message"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Role not found"
+// @Failure 409 {object} models.ErrorResponse "Role already exists"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/admin/roles [patch]
+func RenameRole(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req RenameRoleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+			return
+		}
+		name, ok := adminName(c, req.Name)
+		if !ok {
+			return
+		}
+		event, ok := adminAuditEvent(c)
+		if !ok {
+			return
+		}
+
+		if err := storage.RenameRole(c.Request.Context(), req.RoleID, name, event); err != nil {
+			roleError(c, err, "Failed to rename role")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Role renamed successfully"})
+	}
+}
+
+// @Summary Сменить родительскую роль
+// @Description Делает роль наследником другой роли; parent_id = null убирает родителя. Роль не может наследовать от себя или своих потомков. Доступно с правом admin_auth
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body handlers.SetRoleParentRequest true "Role ID and parent role ID"
+// @Security OAuth2Password
+// @Success 200 {object} models.SuccessResponse "Success message"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Role not found"
+// @Failure 409 {object} models.ErrorResponse "Hierarchy cycle or hierarchy is too deep"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/admin/roles/parent [put]
+func SetRoleParent(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req SetRoleParentRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+			return
+		}
+		event, ok := adminAuditEvent(c)
+		if !ok {
+			return
+		}
+
+		if err := storage.SetRoleParent(c.Request.Context(), req.RoleID, req.ParentID, event); err != nil {
+			roleError(c, err, "Failed to set role parent")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Role parent updated successfully"})
+	}
+}
+
+// @Summary Удалить роль
+// @Description Удаляет роль: пользователи ее теряют, дочерние роли остаются без родителя. Доступно с правом admin_auth
+// @Tags Admin
+// @Produce json
+// @Param role_id query string true "Role ID"
+// @Security OAuth2Password
+// @Success 200 {object} models.SuccessResponse "Success message"
+// @Failure 400 {object} models.ErrorResponse "Invalid role ID"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Role not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/admin/roles [delete]
+func DeleteRole(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roleID, ok := uuidQuery(c, "role_id")
+		if !ok {
+			return
+		}
+		event, ok := adminAuditEvent(c)
+		if !ok {
+			return
+		}
+
+		if err := storage.DeleteRole(c.Request.Context(), roleID, event); err != nil {
+			roleError(c, err, "Failed to delete role")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Role deleted successfully"})
+	}
+}
+
+// @Summary Список прав
+// @Description Возвращает все права. Доступно с правом admin_auth
+// @Tags Admin
+// @Produce json
+// @Security OAuth2Password
+// @Success 200 {array} models.Permission "Permissions"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/admin/permissions [get]
+func GetPermissions(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		permissions, err := storage.GetAllPermissions(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get permissions", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, permissions)
+	}
+}
+
+// @Summary Создать право
+// @Description Создает право. Доступно с правом admin_auth
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body handlers.CreatePermissionRequest true "Permission"
+// @Security OAuth2Password
+// @Success 201 {object} map[string]interface{} "Success message with permission ID"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 409 {object} models.ErrorResponse "Permission already exists"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/admin/permissions [post]
+func CreatePermission(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req CreatePermissionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+			return
+		}
+		name, ok := adminName(c, req.Name)
+		if !ok {
+			return
+		}
+		event, ok := adminAuditEvent(c)
+		if !ok {
+			return
+		}
+
+		permission := models.Permission{ID: uuid.New(), Name: name}
+		if err := storage.CreatePermission(c.Request.Context(), permission, event); err != nil {
+			roleError(c, err, "Failed to create permission")
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Permission created successfully", "id": permission.ID})
+	}
+}
+
+// @Summary Переименовать право
+// @Description Меняет название права. Переименование прав admin_* отключает проверки, которые на них опираются. Доступно с правом admin_auth
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body handlers.RenamePermissionRequest true "Permission ID and new name"
+// @Security OAuth2Password
+// @Success 200 {object} models.SuccessResponse "Success message"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Permission not found"
+// @Failure 409 {object} models.ErrorResponse "Permission already exists"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/admin/permissions [patch]
+func RenamePermission(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req RenamePermissionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+			return
+		}
+		name, ok := adminName(c, req.Name)
+		if !ok {
+			return
+		}
+		event, ok := adminAuditEvent(c)
+		if !ok {
+			return
+		}
+
+		if err := storage.RenamePermission(c.Request.Context(), req.PermissionID, name, event); err != nil {
+			roleError(c, err, "Failed to rename permission")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Permission renamed successfully"})
+	}
+}
+
+// @Summary Удалить право
+// @Description Удаляет право и его привязки к ролям. Доступно с правом admin_auth
+// @Tags Admin
+// @Produce json
+// @Param permission_id query string true "Permission ID"
+// @Security OAuth2Password
+// @Success 200 {object} models.SuccessResponse "Success message"
+// @Failure 400 {object} models.ErrorResponse "Invalid permission ID"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Permission not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/admin/permissions [delete]
+func DeletePermission(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		permissionID, ok := uuidQuery(c, "permission_id")
+		if !ok {
+			return
+		}
+		event, ok := adminAuditEvent(c)
+		if !ok {
+			return
+		}
+
+		if err := storage.DeletePermission(c.Request.Context(), permissionID, event); err != nil {
+			roleError(c, err, "Failed to delete permission")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Permission deleted successfully"})
+	}
+}
+
+// @Summary Права роли
+// @Description Возвращает права, привязанные к роли напрямую, без прав родительских ролей. Доступно с правом admin_auth
+// @Tags Admin
+// @Produce json
+// @Param role_id query string true "Role ID"
+// @Security OAuth2Password
+// @Success 200 {array} models.Permission "Role permissions"
+// @Failure 400 {object} models.ErrorResponse "Invalid role ID"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/admin/role_permissions [get]
+func GetRolePermissions(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roleID, ok := uuidQuery(c, "role_id")
+		if !ok {
+			return
+		}
+
+		permissions, err := storage.GetPermissionsByRoleID(c.Request.Context(), roleID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get role permissions", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, permissions)
+	}
+}
+
+// @Summary Привязать право к роли
+// @Description Выдает право всем пользователям роли и ее наследников. Доступно с правом admin_auth
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body handlers.RolePermissionRequest true "Role ID and permission ID"
+// @Security OAuth2Password
+// @Success 201 {object} models.SuccessResponse "Success message"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Role or permission not found"
+// @Failure 409 {object} models.ErrorResponse "Permission is already attached"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/admin/role_permissions [post]
+func AttachPermission(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req RolePermissionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+			return
+		}
+		event, ok := adminAuditEvent(c)
+		if !ok {
+			return
+		}
+
+		rolePermission := models.RolePermission{ID: uuid.New(), RoleID: req.RoleID, PermissionID: req.PermissionID}
+		if err := storage.AttachPermission(c.Request.Context(), rolePermission, event); err != nil {
+			roleError(c, err, "Failed to attach permission")
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Permission attached successfully"})
+	}
+}
+
+// @Summary Отвязать право от роли
+// @Description Доступно с правом admin_auth
+// @Tags Admin
+// @Produce json
+// @Param role_id query string true "Role ID"
+// @Param permission_id query string true "Permission ID"
+// @Security OAuth2Password
+// @Success 200 {object} models.SuccessResponse "Success message"
+// @Failure 400 {object} models.ErrorResponse "Invalid role or permission ID"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Permission is not attached to the role"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/admin/role_permissions [delete]
+func DetachPermission(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roleID, ok := uuidQuery(c, "role_id")
+		if !ok {
+			return
+		}
+		permissionID, ok := uuidQuery(c, "permission_id")
+		if !ok {
+			return
+		}
+		event, ok := adminAuditEvent(c)
+		if !ok {
+			return
+		}
+
+		if err := storage.DetachPermission(c.Request.Context(), roleID, permissionID, event); err != nil {
+			roleError(c, err, "Failed to detach permission")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Permission detached successfully"})
+	}
+}
+
+// @Summary Роли пользователя
+// @Description Возвращает роли, назначенные пользователю напрямую. Доступно с правом admin_auth
+// @Tags Admin
+// @Produce json
+// @Param user_id query string true "User ID"
+// @Security OAuth2Password
+// @Success 200 {array} models.Role "User roles"
+// @Failure 400 {object} models.ErrorResponse "Invalid user ID"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/admin/user_roles [get]
+func GetAssignedRoles(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := uuidQuery(c, "user_id")
+		if !ok {
+			return
+		}
+
+		roles, err := storage.GetRolesByUserID(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user roles", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, roles)
+	}
+}
+
+// @Summary Назначить роль пользователю
+// @Description Новые права действуют сразу; admin_services в токене обновятся при следующем входе или обновлении токена. Доступно с правом admin_auth
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body handlers.UserRoleRequest true "User ID and role ID"
+// @Security OAuth2Password
+// @Success 201 {object} models.SuccessResponse "Success message"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "User or role not found"
+// @Failure 409 {object} models.ErrorResponse "Role is already granted"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/admin/user_roles [post]
+func GrantRole(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req UserRoleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+			return
+		}
+		event, ok := adminAuditEvent(c)
+		if !ok {
+			return
+		}
+
+		userRole := models.UserRole{ID: uuid.New(), UserID: req.UserID, RoleID: req.RoleID}
+		if err := storage.GrantRole(c.Request.Context(), userRole, event); err != nil {
+			roleError(c, err, "Failed to grant role")
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Role granted successfully"})
+	}
+}
+
+// @Summary Снять роль с пользователя
+// @Description Доступно с правом admin_auth
+// @Tags Admin
+// @Produce json
+// @Param user_id query string true "User ID"
+// @Param role_id query string true "Role ID"
+// @Security OAuth2Password
+// @Success 200 {object} models.SuccessResponse "Success message"
+// @Failure 400 {object} models.ErrorResponse "Invalid user or role ID"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Role is not granted to the user"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/admin/user_roles [delete]
+func RevokeRole(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := uuidQuery(c, "user_id")
+		if !ok {
+			return
+		}
+		roleID, ok := uuidQuery(c, "role_id")
+		if !ok {
+			return
+		}
+		event, ok := adminAuditEvent(c)
+		if !ok {
+			return
+		}
+
+		if err := storage.RevokeRole(c.Request.Context(), userID, roleID, event); err != nil {
+			roleError(c, err, "Failed to revoke role")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Role revoked successfully"})
+	}
+}
+
+// @Summary Пользователи роли
+// @Description Возвращает пользователей, которым роль назначена напрямую, с пагинацией. Доступно с правом admin_auth
+// @Tags Admin
+// @Produce json
+// @Param role_id query string true "Role ID"
+// @Param limit query int false "Limit" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Security OAuth2Password
+// @Success 200 {array} models.User "Users"
+// @Failure 400 {object} models.ErrorResponse "Invalid role ID or pagination parameters"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/admin/role_users [get]
+func GetRoleUsers(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roleID, ok := uuidQuery(c, "role_id")
+		if !ok {
+			return
+		}
+		limit, offset, ok := pagination(c, 50)
+		if !ok {
+			return
+		}
+
+		users, err := storage.GetUsersByRoleID(c.Request.Context(), roleID, limit, offset)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get role users", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, users)
+	}
+}
+
+// @Summary Журнал аудита
+// @Description Возвращает изменения ролей, прав и их назначений, новые первыми. target_id оставляет изменения одной роли, права или пользователя. Доступно с правом admin_auth
+// @Tags Admin
+// @Produce json
+// @Param target_id query string false "Role, permission or user ID"
+// @Param limit query int false "Limit" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Security OAuth2Password
+// @Success 200 {array} models.AuditEvent "Audit events"
+// @Failure 400 {object} models.ErrorResponse "Invalid target ID or pagination parameters"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/admin/audit_log [get]
+func GetAuditLog(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var targetID *uuid.UUID
+		if c.Query("target_id") != "" {
+			id, ok := uuidQuery(c, "target_id")
+			if !ok {
+				return
+			}
+			targetID = &id
+		}
+		limit, offset, ok := pagination(c, 50)
+		if !ok {
+			return
+		}
+
+		events, err := storage.GetAuditEvents(c.Request.Context(), targetID, limit, offset)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get audit log", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, events)
+	}
+}
+
+// adminAuditEvent возвращает запись журнала аудита, автор которой - текущий пользователь
+func adminAuditEvent(c *gin.Context) (models.AuditEvent, bool) {
+	user, ok := currentUser(c)
+	if !ok {
+		return models.AuditEvent{}, false
+	}
+	return models.AuditEvent{ActorID: &user.ID, IP: c.ClientIP()}, true
+}
+
+// adminName убирает пробелы по краям названия роли или права; пустое название отклоняется
+func adminName(c *gin.Context, name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+		return "", false
+	}
+	return name, true
+}
+
+// uuidQuery разбирает обязательный параметр запроса с UUID
+func uuidQuery(c *gin.Context, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Query(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// pagination разбирает limit и offset. В отличие от parseIntQuery сам отвечает ошибкой
+func pagination(c *gin.Context, defaultLimit int) (limit, offset int, ok bool) {
+	limit, err := parseIntQuery(c, "limit", defaultLimit)
+	if err == nil {
+		offset, err = parseIntQuery(c, "offset", 0)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pagination parameters", "details": err.Error()})
+		return 0, 0, false
+	}
+	return limit, offset, true
+}
+
+func roleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, database.ErrRoleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+	case errors.Is(err, database.ErrPermissionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Permission not found"})
+	case errors.Is(err, database.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, database.ErrRolePermissionNotFound), errors.Is(err, database.ErrUserRoleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, database.ErrRoleExists),
+		errors.Is(err, database.ErrPermissionExists),
+		errors.Is(err, database.ErrRolePermissionExists),
+		errors.Is(err, database.ErrUserRoleExists),
+		errors.Is(err, database.ErrRoleCycle),
+		errors.Is(err, database.ErrRoleHierarchyTooDeep):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"errors"
+	"itam_auth/internal/config"
+	"itam_auth/internal/database"
+	"itam_auth/internal/models"
+	"itam_auth/internal/services/auth"
+	"itam_auth/internal/services/jwt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Вход через Telegram
+// @Description Проверяет данные виджета входа Telegram (подпись и свежесть auth_date) и выдает токены. Если аккаунт Telegram еще не привязан ни к одному пользователю, создается новый пользователь
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param telegram body auth.TelegramAuth true "Telegram Login Widget data"
+// @Success 200 {object} models.LoginResponse "Access and refresh tokens"
+// @Success 202 {object} models.MFAChallengeResponse "Second factor required"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid or expired Telegram data"
+// @Router /auth/api/login/telegram [post]
+func LoginTelegram(storage *database.Storage, keys *jwt.KeyRing, cfg *config.AppConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req auth.TelegramAuth
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ttl := time.Duration(cfg.TelegramAuthTTL) * time.Second
+		tokens, err := auth.AuthenticateTelegram(c.Request.Context(), storage, req, cfg.TelegramBotToken, ttl, loginPolicy(cfg), keys)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Telegram authorization", "details": err.Error()})
+			return
+		}
+
+		respondWithTokens(c, tokens)
+	}
+}
+
+// @Summary Привязать Telegram
+// @Description Привязывает аккаунт Telegram к текущему пользователю, чтобы входить через него
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security OAuth2Password
+// @Param telegram body auth.TelegramAuth true "Telegram Login Widget data"
+// @Success 200 {object} models.SuccessResponse "Success message"
+// @Failure 400 {object} models.ErrorResponse "Invalid or expired Telegram data"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 409 {object} models.ErrorResponse "Telegram account is linked to another user"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/link_telegram [post]
+func LinkTelegram(storage *database.Storage, cfg *config.AppConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := c.Get("user")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		userObj, ok := user.(models.User)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user data"})
+			return
+		}
+
+		var req auth.TelegramAuth
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ttl := time.Duration(cfg.TelegramAuthTTL) * time.Second
+		err := auth.LinkTelegram(c.Request.Context(), storage, userObj.ID, req, cfg.TelegramBotToken, ttl)
+		if err != nil {
+			if errors.Is(err, database.ErrTelegramAlreadyLinked) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Telegram account is linked to another user"})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to link Telegram", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Telegram linked successfully"})
+	}
+}
+
+// @Summary Отвязать Telegram
+// @Description Отвязывает аккаунт Telegram от текущего пользователя. Недоступно, если у пользователя нет email и пароля
+// @Tags User
+// @Produce json
+// @Security OAuth2Password
+// @Success 200 {object} models.SuccessResponse "Success message"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 409 {object} models.ErrorResponse "Telegram is the only sign-in method"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/unlink_telegram [delete]
+func UnlinkTelegram(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := c.Get("user")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		userObj, ok := user.(models.User)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user data"})
+			return
+		}
+
+		if err := auth.UnlinkTelegram(c.Request.Context(), storage, userObj.ID); err != nil {
+			if errors.Is(err, auth.ErrLastSignInMethod) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Telegram is the only sign-in method", "details": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink Telegram", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Telegram unlinked successfully"})
+	}
+}
@@ -2,27 +2,26 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"itam_auth/internal/config"
 	"itam_auth/internal/database"
+	"itam_auth/internal/middleware"
 	"itam_auth/internal/models"
 	"itam_auth/internal/services/auth"
+	"itam_auth/internal/services/jwt"
+	"itam_auth/internal/services/mailer"
+	"itam_auth/internal/services/permission"
+	"itam_auth/internal/services/revocation"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-// type TelegramAuth struct {
-// 	ID        int64  `json:"id"`
-// 	FirstName string `json:"first_name"`
-// 	LastName  string `json:"last_name"`
-// 	Username  string `json:"username"`
-// 	PhotoURL  string `json:"photo_url"`
-// 	AuthDate  int64  `json:"auth_date"`
-// 	Hash      string `json:"hash"`
-// }
-
 // RegisterRequest представляет запрос на регистрацию пользователя
 type RegisterRequest struct {
 	Name     string `json:"name" binding:"required" example:"John Doe"`
@@ -36,8 +35,18 @@ type LoginRequest struct {
 	Password string `json:"password" form:"password" binding:"required" example:"password123"`
 }
 
+// RefreshRequest представляет запрос на обновление токенов
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" form:"refresh_token" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+// LogoutRequest представляет запрос на выход. Если передан refresh-токен, отзывается и он
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
 // @Summary Регистрация нового пользователя
-// @Description Регистрация нового пользователя в системе
+// @Description Регистрация нового пользователя в системе. На email отправляется ссылка для подтверждения адреса
 // @Tags User
 // @Accept json
 // @Produce json
@@ -46,7 +55,7 @@ type LoginRequest struct {
 // @Failure 400 {object} models.ErrorResponse "Invalid request"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
 // @Router /auth/api/register [post]
-func Register(storage *database.Storage) gin.HandlerFunc {
+func Register(storage *database.Storage, keys *jwt.KeyRing, mail mailer.Mailer, cfg *config.AppConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req RegisterRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -61,22 +70,30 @@ func Register(storage *database.Storage) gin.HandlerFunc {
 			return
 		}
 
+		// Пользователь уже создан: если письмо не ушло, его можно запросить повторно
+		if err := auth.SendVerificationEmail(ctx, storage, keys, emailVerification(mail, cfg), user); err != nil {
+			log.Printf("Failed to send verification email after registration (email=%s, id=%s): %v", user.Email, user.ID, err)
+		}
+
 		c.JSON(http.StatusCreated, gin.H{"message": "User registered successfully", "user": user})
 	}
 }
 
 // @Summary Логин пользователя
-// @Description Авторизация пользователя с использованием логина и пароля
+// @Description Авторизация пользователя с использованием логина и пароля. Если у пользователя включен второй фактор (или он обязателен), вместо токенов возвращается MFA-токен для /auth/api/login/mfa. После нескольких неудачных попыток вход по email или с IP-адреса временно блокируется (429 и Retry-After)
 // @Tags User
 // @Accept json,x-www-form-urlencoded
 // @Produce json
 // @Param login body handlers.LoginRequest true "Login credentials"
-// @Success 200 {object} models.LoginResponse "JWT token"
+// @Success 200 {object} models.LoginResponse "Access and refresh tokens"
+// @Success 202 {object} models.MFAChallengeResponse "Second factor required"
 // @Failure 400 {object} models.ErrorResponse "Invalid request"
 // @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Email is not verified"
+// @Failure 429 {object} models.ErrorResponse "Too many failed login attempts"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
 // @Router /auth/api/login [post]
-func Login(storage *database.Storage, hmacSecret string) gin.HandlerFunc {
+func Login(storage *database.Storage, keys *jwt.KeyRing, cfg *config.AppConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req LoginRequest
 
@@ -94,108 +111,177 @@ func Login(storage *database.Storage, hmacSecret string) gin.HandlerFunc {
 		}
 
 		ctx := context.Background()
-		tokenString, err := auth.AuthenticateUser(ctx, storage, req.Email, req.Password, hmacSecret)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password", "details": err.Error()})
+		tokens, err := auth.AuthenticateUser(ctx, storage, req.Email, req.Password, c.ClientIP(), loginPolicy(cfg), keys)
+		var locked *auth.LoginLockedError
+		switch {
+		case errors.As(err, &locked):
+			respondLoginLocked(c, locked)
+			return
+		case errors.Is(err, auth.ErrEmailNotVerified):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Email is not verified", "details": err.Error()})
+			return
+		case err != nil:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 			return
 		}
 
 		// Формат ответа для совместимости с OAuth2
-		c.JSON(http.StatusOK, gin.H{
-			"access_token": tokenString,
-			"token_type":   "Bearer",
-			"expires_in":   2592000, // 30 дней в секундах
+		respondWithTokens(c, tokens)
+	}
+}
+
+// @Summary Обновление токенов
+// @Description Обменивает refresh-токен на новую пару токенов. Использованный refresh-токен становится недействительным, а его повторное предъявление отзывает все токены этой сессии
+// @Tags User
+// @Accept json,x-www-form-urlencoded
+// @Produce json
+// @Param refresh body handlers.RefreshRequest true "Refresh token"
+// @Success 200 {object} models.LoginResponse "Access and refresh tokens"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid or reused refresh token"
+// @Router /auth/api/refresh [post]
+func Refresh(storage *database.Storage, keys *jwt.KeyRing) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req RefreshRequest
+
+		// Поддержка как JSON, так и form-data
+		if c.ContentType() == "application/json" {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		} else {
+			if err := c.ShouldBind(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		tokens, err := auth.RefreshTokens(c.Request.Context(), storage, req.RefreshToken, "", keys)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, tokenResponse(tokens))
+	}
+}
+
+// @Summary Выход из системы
+// @Description Отзывает текущий access-токен и, если он передан, refresh-токен этой сессии
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security OAuth2Password
+// @Param logout body handlers.LogoutRequest false "Refresh token of the current session"
+// @Success 200 {object} models.SuccessResponse "Success message"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/logout [post]
+func Logout(storage *database.Storage, revocations *revocation.Store, keys *jwt.KeyRing) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenClaims, exists := c.Get("claims")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		claims, ok := tokenClaims.(*jwt.Claims)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid token data"})
+			return
+		}
+		if claims.IsService() {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		var req LogoutRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		if err := auth.Logout(c.Request.Context(), storage, revocations, claims, req.RefreshToken, keys); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+	}
+}
+
+// @Summary Выход со всех устройств
+// @Description Отзывает все access- и refresh-токены и персональные токены текущего пользователя
+// @Tags User
+// @Produce json
+// @Security OAuth2Password
+// @Success 200 {object} models.SuccessResponse "Success message"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/logout_all [post]
+func LogoutAll(revocations *revocation.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := c.Get("user")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		userObj, ok := user.(models.User)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user data"})
+			return
+		}
+
+		if err := auth.LogoutAll(c.Request.Context(), revocations, userObj.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Logged out from all sessions"})
+	}
+}
+
+// respondWithTokens отвечает парой токенов или, если нужен второй фактор, MFA-челленджем со статусом 202
+func respondWithTokens(c *gin.Context, tokens auth.TokenPair) {
+	if tokens.MFAToken != "" {
+		c.JSON(http.StatusAccepted, gin.H{
+			"mfa_required":            true,
+			"mfa_token":               tokens.MFAToken,
+			"mfa_enrollment_required": tokens.MFAEnrollmentRequired,
+			"expires_in":              tokens.ExpiresIn,
 		})
+		return
+	}
+	c.JSON(http.StatusOK, tokenResponse(tokens))
+}
+
+// respondLoginLocked отвечает 429 с Retry-After до конца блокировки
+func respondLoginLocked(c *gin.Context, locked *auth.LoginLockedError) {
+	retryAfter := int(math.Ceil(time.Until(locked.Until).Seconds()))
+	c.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts", "details": locked.Error()})
+}
+
+func loginPolicy(cfg *config.AppConfig) auth.LoginPolicy {
+	return auth.LoginPolicy{
+		RequireMFAForAdmins:  cfg.MFARequireAdmins,
+		RequireVerifiedEmail: cfg.RequireVerifiedEmail,
 	}
 }
 
-// TELEGRAM WEB APP
-// func Login(c *gin.Context, storage *database.Storage) {
-// 	var auth TelegramAuth
-// 	if err := c.ShouldBindJSON(&auth); err != nil {
-// 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
-// 		return
-// 	}
-
-// 	data := url.Values{
-// 		"id":         {fmt.Sprintf("%d", auth.ID)},
-// 		"first_name": {auth.FirstName},
-// 		"last_name":  {auth.LastName},
-// 		"username":   {auth.Username},
-// 		"photo_url":  {auth.PhotoURL},
-// 		"auth_date":  {fmt.Sprintf("%d", auth.AuthDate)},
-// 	}
-
-// 	if !utils.ValidateTelegramAuth(data, "") {
-// 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Telegram authorization"})
-// 		return
-// 	}
-
-// 	// Генерация UUID
-// 	userID := uuid.New()
-
-// 	ctx := context.Background()
-// 	user, err := storage.GetUserByID(ctx, userID)
-// 	if err != nil {
-// 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-// 		return
-// 	}
-
-// 	tokenString, err := utils.GenerateJWT(user.Email)
-// 	if err != nil {
-// 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
-// 		return
-// 	}
-
-// 	c.JSON(http.StatusOK, gin.H{"token": tokenString})
-// }
-
-// TELEGRAM WEB APP
-// func Register(c *gin.Context, storage *database.Storage) {
-// 	var auth TelegramAuth
-
-// 	if err := c.ShouldBindJSON(&auth); err != nil {
-// 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
-// 		return
-// 	}
-
-// 	data := url.Values{
-// 		"id":         {fmt.Sprintf("%d", auth.ID)},
-// 		"first_name": {auth.FirstName},
-// 		"last_name":  {auth.LastName},
-// 		"username":   {auth.Username},
-// 		"photo_url":  {auth.PhotoURL},
-// 		"auth_date":  {fmt.Sprintf("%d", auth.AuthDate)},
-// 	}
-
-// 	if !utils.ValidateTelegramAuth(data, "your_bot_token") {
-// 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Telegram authorization"})
-// 		return
-// 	}
-
-// 	userID := uuid.New()
-
-// 	user := models.User{
-// 		ID:           userID,
-// 		Name:         fmt.Sprintf("%s %s", auth.FirstName, auth.LastName),
-// 		Email:        fmt.Sprintf("%d@telegram.com", auth.ID),
-// 		Telegram:     auth.Username,
-// 		PasswordHash: "",
-// 		PhotoURL:     auth.PhotoURL,
-// 		CreatedAt:    time.Now(),
-// 		UpdatedAt:    time.Now(),
-// 	}
-
-// 	ctx := context.Background()
-
-// 	_, err := storage.SaveUser(ctx, user)
-// 	if err != nil {
-// 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while saving user"})
-// 		return
-// 	}
-
-// 	c.JSON(http.StatusCreated, gin.H{"message": "User registered successfully"})
-// }
+func tokenResponse(tokens auth.TokenPair) gin.H {
+	return gin.H{
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    "Bearer",
+		"expires_in":    tokens.ExpiresIn,
+	}
+}
 
 // @Summary Получить информацию о пользователе
 // @Description Возвращает данные пользователя по ID
@@ -264,44 +350,65 @@ func GetUserRoles(storage *database.Storage) gin.HandlerFunc {
 }
 
 // @Summary Получить свойства пользователя
-// @Description Возвращает список свойств текущего пользователя
+// @Description Возвращает права текущего пользователя, собранные по всем его ролям (каждое право один раз)
 // @Tags User
 // @Produce json
 // @Security OAuth2Password
-// @Success 200 {object} map[string]string "User properties"
+// @Success 200 {array} models.Permission "User permissions"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
 // @Router /auth/api/get_user_properties [get]
 func GetUserPermissions(storage *database.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Get the user from context that was set by AuthMiddleware
-		user, exists := c.Get("user")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		user, ok := currentUser(c)
+		if !ok {
 			return
 		}
 
-		userObj, ok := user.(models.User)
-		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user data"})
+		permissions, err := middleware.Permissions(c, storage)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while fetching user permissions", "details": err.Error()})
 			return
 		}
 
-		uuidUserID := userObj.ID
-
-		ctx := context.Background()
-		userRoles, err := storage.GetUserRoles(ctx, uuidUserID)
+		// Права запроса могут быть снимком из токена, где есть только названия: идентификаторы берем из базы
+		resolved, err := permission.Resolve(c.Request.Context(), storage, user.ID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while fetching user roles", "details": err.Error()})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while fetching user permissions", "details": err.Error()})
 			return
 		}
 
-		var permissions []models.RolePermission
-		for _, role := range userRoles {
-			rolePermissions, err := storage.GetRolePermissions(ctx, role.RoleID)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while fetching role permissions", "details": err.Error()})
-				return
-			}
-			permissions = append(permissions, rolePermissions...)
+		c.JSON(http.StatusOK, resolved.Restrict(permissions.Names()).Permissions())
+	}
+}
+
+// @Summary Происхождение прав пользователя
+// @Description Возвращает права пользователя с учетом наследования ролей и для каждого - путь от назначенной роли до роли, которой право выдано. Право, полученное несколькими путями, повторяется для каждого пути. Права другого пользователя доступны с правом admin_auth
+// @Tags User
+// @Produce json
+// @Security OAuth2Password
+// @Param user_id query string false "User ID (по умолчанию - текущий пользователь)"
+// @Success 200 {array} models.EffectivePermission "Effective permissions with their sources"
+// @Failure 400 {object} models.ErrorResponse "Invalid user ID"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Access denied"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/get_user_permission_sources [get]
+func GetPermissionSources(storage *database.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := currentUser(c); !ok {
+			return
+		}
+
+		userID, ok := targetUserID(c)
+		if !ok || !ownerOrAdmin(c, storage, userID, models.PermissionAdminAuth, "") {
+			return
+		}
+
+		permissions, err := permission.Explain(c.Request.Context(), storage, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while fetching permission sources", "details": err.Error()})
+			return
 		}
 
 		c.JSON(http.StatusOK, permissions)
@@ -413,7 +520,7 @@ func UpdateUserInfo(storage *database.Storage) gin.HandlerFunc {
 }
 
 // @Summary Получить информацию о текущем пользователе
-// @Description Возвращает данные авторизованного пользователя
+// @Description Возвращает данные авторизованного пользователя. Роли и права - снимок из access-токена (если выпускаются по JWT_CLAIMS)
 // @Tags User
 // @Produce json
 // @Security OAuth2Password
@@ -446,6 +553,9 @@ func GetCurrentUser(storage *database.Storage) gin.HandlerFunc {
 
 		// Remove sensitive information
 		fullUser.PasswordHash = ""
+		// Роли и права - снимок из access-токена, по ним пользователь и проходит проверки
+		fullUser.Roles = userObj.Roles
+		fullUser.Permissions = userObj.Permissions
 
 		c.JSON(http.StatusOK, fullUser)
 	}
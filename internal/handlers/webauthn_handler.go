@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"itam_auth/internal/database"
+	"itam_auth/internal/services/auth"
+	"itam_auth/internal/services/passkey"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PasskeyRegisterRequest представляет завершение регистрации ключа доступа
+type PasskeyRegisterRequest struct {
+	SessionID  uuid.UUID       `json:"session_id" binding:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Name       string          `json:"name" example:"MacBook"`
+	Credential json.RawMessage `json:"credential" binding:"required" swaggertype:"object"` // Результат navigator.credentials.create()
+}
+
+// PasskeyLoginRequest представляет завершение входа по ключу доступа
+type PasskeyLoginRequest struct {
+	SessionID  uuid.UUID       `json:"session_id" binding:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Credential json.RawMessage `json:"credential" binding:"required" swaggertype:"object"` // Результат navigator.credentials.get()
+}
+
+// @Summary Начать регистрацию ключа доступа
+// @Description Возвращает параметры для navigator.credentials.create() и идентификатор сессии церемонии
+// @Tags WebAuthn
+// @Produce json
+// @Security OAuth2Password
+// @Success 200 {object} map[string]interface{} "session_id and options"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/webauthn/register/begin [post]
+func BeginPasskeyRegistration(passkeys *passkey.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userObj, ok := currentUser(c)
+		if !ok {
+			return
+		}
+
+		sessionID, options, err := passkeys.BeginRegistration(c.Request.Context(), userObj.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin passkey registration", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"session_id": sessionID, "options": options})
+	}
+}
+
+// @Summary Завершить регистрацию ключа доступа
+// @Description Проверяет ответ аутентификатора и сохраняет ключ доступа
+// @Tags WebAuthn
+// @Accept json
+// @Produce json
+// @Security OAuth2Password
+// @Param passkey body handlers.PasskeyRegisterRequest true "Session ID and authenticator response"
+// @Success 201 {object} models.PasskeyResponse "Registered passkey"
+// @Failure 400 {object} models.ErrorResponse "Invalid request or authenticator response"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 409 {object} models.ErrorResponse "Passkey is already registered"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/webauthn/register/finish [post]
+func FinishPasskeyRegistration(passkeys *passkey.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userObj, ok := currentUser(c)
+		if !ok {
+			return
+		}
+
+		var req PasskeyRegisterRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		record, err := passkeys.FinishRegistration(c.Request.Context(), userObj.ID, req.SessionID, req.Name, req.Credential)
+		if err != nil {
+			passkeyError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, passkey.Response(record))
+	}
+}
+
+// @Summary Начать вход по ключу доступа
+// @Description Возвращает параметры для navigator.credentials.get() и идентификатор сессии церемонии. Пользователя указывать не нужно: браузер предложит сохраненные ключи
+// @Tags WebAuthn
+// @Produce json
+// @Success 200 {object} map[string]interface{} "session_id and options"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/webauthn/login/begin [post]
+func BeginPasskeyLogin(passkeys *passkey.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionID, options, err := passkeys.BeginLogin(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin passkey login", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"session_id": sessionID, "options": options})
+	}
+}
+
+// @Summary Вход по ключу доступа
+// @Description Проверяет ответ аутентификатора и выдает токены, как обычный вход. Второй фактор не запрашивается: ключ доступа уже требует проверки пользователя на устройстве
+// @Tags WebAuthn
+// @Accept json
+// @Produce json
+// @Param passkey body handlers.PasskeyLoginRequest true "Session ID and authenticator response"
+// @Success 200 {object} models.LoginResponse "Access and refresh tokens"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid passkey"
+// @Failure 403 {object} models.ErrorResponse "Email is not verified"
+// @Router /auth/api/webauthn/login/finish [post]
+func FinishPasskeyLogin(passkeys *passkey.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req PasskeyLoginRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		tokens, err := passkeys.FinishLogin(c.Request.Context(), req.SessionID, req.Credential)
+		switch {
+		case errors.Is(err, auth.ErrEmailNotVerified):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Email is not verified", "details": err.Error()})
+			return
+		case err != nil:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid passkey", "details": err.Error()})
+			return
+		}
+
+		respondWithTokens(c, tokens)
+	}
+}
+
+// @Summary Ключи доступа пользователя
+// @Description Возвращает зарегистрированные ключи доступа текущего пользователя
+// @Tags WebAuthn
+// @Produce json
+// @Security OAuth2Password
+// @Success 200 {array} models.PasskeyResponse "Passkeys"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/webauthn/credentials [get]
+func GetPasskeys(passkeys *passkey.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userObj, ok := currentUser(c)
+		if !ok {
+			return
+		}
+
+		list, err := passkeys.List(c.Request.Context(), userObj.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get passkeys", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, list)
+	}
+}
+
+// @Summary Удалить ключ доступа
+// @Description Удаляет ключ доступа текущего пользователя. Последний ключ удалить нельзя, если у пользователя нет пароля и Telegram
+// @Tags WebAuthn
+// @Produce json
+// @Security OAuth2Password
+// @Param credential_id path string true "Passkey ID (base64url)"
+// @Success 200 {object} models.SuccessResponse "Success message"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Passkey not found"
+// @Failure 409 {object} models.ErrorResponse "Passkey is the only sign-in method"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/api/webauthn/credentials/{credential_id} [delete]
+func DeletePasskey(passkeys *passkey.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userObj, ok := currentUser(c)
+		if !ok {
+			return
+		}
+
+		if err := passkeys.Delete(c.Request.Context(), userObj.ID, c.Param("credential_id")); err != nil {
+			passkeyError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Passkey deleted successfully"})
+	}
+}
+
+func passkeyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, database.ErrWebAuthnCredentialNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Passkey not found"})
+	case errors.Is(err, database.ErrWebAuthnCredentialExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "Passkey is already registered"})
+	case errors.Is(err, auth.ErrLastSignInMethod):
+		c.JSON(http.StatusConflict, gin.H{"error": "Passkey is the only sign-in method", "details": err.Error()})
+	case errors.Is(err, database.ErrWebAuthnSessionNotFound), errors.Is(err, passkey.ErrInvalidCredential):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid passkey response", "details": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Passkey operation failed", "details": err.Error()})
+	}
+}
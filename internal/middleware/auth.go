@@ -1,14 +1,37 @@
 package middleware
 
 import (
+	"errors"
+	"itam_auth/internal/database"
+	"itam_auth/internal/services/auth"
 	"itam_auth/internal/services/jwt"
+	"itam_auth/internal/services/permission"
+	"log"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(hmacSecret string) gin.HandlerFunc {
+// Виды субъектов, от имени которых выполняется запрос
+const (
+	PrincipalUser    = "user"
+	PrincipalService = "service"
+)
+
+// AuthMiddleware пропускает запрос с действительным access-токеном (JWT) или персональным токеном.
+// Токены, которые пользователь выдал приложениям через OpenID Connect, отклоняются: API сервиса им недоступно
+func AuthMiddleware(storage *database.Storage, keys *jwt.KeyRing, revocations jwt.RevocationChecker) gin.HandlerFunc {
+	return authenticate(storage, keys, revocations, false)
+}
+
+// OIDCAuthMiddleware - AuthMiddleware, который пропускает и токены приложений. У такого запроса нет прав
+// пользователя, доступ определяют выданные приложению scope (RequireScope)
+func OIDCAuthMiddleware(storage *database.Storage, keys *jwt.KeyRing, revocations jwt.RevocationChecker) gin.HandlerFunc {
+	return authenticate(storage, keys, revocations, true)
+}
+
+func authenticate(storage *database.Storage, keys *jwt.KeyRing, revocations jwt.RevocationChecker, allowClientTokens bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -32,15 +55,117 @@ func AuthMiddleware(hmacSecret string) gin.HandlerFunc {
 			tokenString = authHeader
 		}
 
-		user, err := jwt.ValidateToken(tokenString, hmacSecret)
+		if auth.IsPersonalToken(tokenString) {
+			authenticatePersonalToken(c, storage, tokenString)
+			return
+		}
+
+		claims, err := jwt.ParseToken(c.Request.Context(), tokenString, keys, revocations)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token", "details": err.Error()})
 			return
 		}
 
+		if claims.IsClientToken() && !allowClientTokens {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Token was issued to another application", "details": "client_id " + claims.ClientID})
+			return
+		}
+
+		c.Set("claims", claims)
+
+		// Сервисному аккаунту не выставляем user: обработчики, которым нужен пользователь, ответят 401
+		if claims.IsService() {
+			c.Set("principal", PrincipalService)
+			c.Set("client_id", claims.ClientID)
+			c.Set("scopes", claims.Scopes())
+			c.Next()
+			return
+		}
+
+		user := claims.User()
+		c.Set("principal", PrincipalUser)
 		c.Set("user", user)
 		c.Set("user_id", user.ID.String())
 
+		// Приложение действует от имени пользователя только в пределах scope: прав пользователя у него нет
+		if claims.IsClientToken() {
+			c.Set("client_id", claims.ClientID)
+			c.Set("scopes", claims.Scopes())
+			c.Set("permissions", permission.Set{})
+		} else if len(user.Permissions) > 0 {
+			// Проверки прав используют снимок из токена; без claim perms их прочитает из базы Permissions
+			c.Set("permissions", permission.FromNames(user.Permissions))
+		}
+
+		c.Next()
+	}
+}
+
+// authenticatePersonalToken пропускает запрос от имени владельца персонального токена. Права запроса -
+// пересечение scope токена с текущими правами пользователя: их кеширует Permissions, поэтому RequirePermission
+// и остальные проверки видят только их
+func authenticatePersonalToken(c *gin.Context, storage *database.Storage, tokenString string) {
+	token, user, err := auth.AuthenticatePersonalToken(c.Request.Context(), storage, tokenString, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidPersonalToken) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token", "details": err.Error()})
+			return
+		}
+		log.Printf("Failed to authenticate personal access token: %v", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check token", "details": err.Error()})
+		return
+	}
+
+	permissions, err := auth.PersonalTokenPermissions(c.Request.Context(), storage, token)
+	if err != nil {
+		log.Printf("Failed to check permissions (user_id=%s): %v", user.ID, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions", "details": err.Error()})
+		return
+	}
+
+	c.Set("claims", auth.PersonalTokenClaims(token, user))
+	c.Set("principal", PrincipalUser)
+	c.Set("user", user)
+	c.Set("user_id", user.ID.String())
+	c.Set("personal_token_id", token.ID.String())
+	c.Set("permissions", permissions)
+
+	c.Next()
+}
+
+// RejectPersonalTokens закрывает маршрут для персональных токенов: управлять сессиями, паролем, вторым
+// фактором и самими токенами можно только после входа. Ставится после AuthMiddleware
+func RejectPersonalTokens() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get("personal_token_id"); exists {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Personal access tokens are not allowed here"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireScope пропускает сервисный аккаунт или приложение, только если ему выдан scope. Токены самого
+// сервиса и персональные токены пользователей не ограничивает. Ставится после AuthMiddleware
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenClaims, exists := c.Get("claims")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+			return
+		}
+
+		claims, ok := tokenClaims.(*jwt.Claims)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Invalid token data"})
+			return
+		}
+
+		if claims.ClientID != "" && !claims.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient scope", "details": "scope " + scope + " is required"})
+			return
+		}
+
 		c.Next()
 	}
 }
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"itam_auth/internal/database"
+	"itam_auth/internal/services/permission"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Permissions возвращает права текущего пользователя по всем его ролям. AuthMiddleware кладет в контекст
+// права из access-токена (снимок на момент выпуска) или права персонального токена; если их там нет
+// (claim perms выключен в JWT_CLAIMS или пуст), права читаются из role_permissions и запоминаются
+// до конца запроса. Ставится после AuthMiddleware
+func Permissions(c *gin.Context, storage *database.Storage) (permission.Set, error) {
+	if cached, exists := c.Get("permissions"); exists {
+		if permissions, ok := cached.(permission.Set); ok {
+			return permissions, nil
+		}
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		return permission.Set{}, err
+	}
+
+	permissions, err := permission.Resolve(c.Request.Context(), storage, userID)
+	if err != nil {
+		return permission.Set{}, err
+	}
+	c.Set("permissions", permissions)
+	return permissions, nil
+}
+
+// HasPermission сообщает, есть ли у текущего пользователя хотя бы одно из прав
+func HasPermission(c *gin.Context, storage *database.Storage, names ...string) (bool, error) {
+	permissions, err := Permissions(c, storage)
+	if err != nil {
+		return false, err
+	}
+	return permissions.Has(names...), nil
+}
+
+// RequirePermission пропускает пользователя, у которого есть хотя бы одно из прав. Сервисные аккаунты
+// не ограничивает: их доступ определяет RequireScope. Ставится после AuthMiddleware
+func RequirePermission(storage *database.Storage, permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("principal") == PrincipalService {
+			c.Next()
+			return
+		}
+		if c.GetString("principal") != PrincipalUser {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+			return
+		}
+
+		allowed, err := HasPermission(c, storage, permissions...)
+		if err != nil {
+			log.Printf("Failed to check permissions (user_id=%s): %v", c.GetString("user_id"), err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions", "details": err.Error()})
+			return
+		}
+		if !allowed {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions", "details": "one of permissions is required: " + strings.Join(permissions, ", ")})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireAdminService пропускает только пользователей с правом admin_<service>. Сервисным аккаунтам
+// администрирование недоступно. Ставится после AuthMiddleware
+func RequireAdminService(storage *database.Storage, service string) gin.HandlerFunc {
+	requirePermission := RequirePermission(storage, "admin_"+service)
+	return func(c *gin.Context) {
+		if c.GetString("principal") == PrincipalService {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions", "details": "admin_" + service + " permission is required"})
+			return
+		}
+		requirePermission(c)
+	}
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Действия, которые записываются в журнал аудита
+const (
+	AuditRoleCreate       = "role.create"
+	AuditRoleRename       = "role.rename"
+	AuditRoleDelete       = "role.delete"
+	AuditRoleSetParent    = "role.set_parent"
+	AuditPermissionCreate = "permission.create"
+	AuditPermissionRename = "permission.rename"
+	AuditPermissionDelete = "permission.delete"
+	AuditPermissionAttach = "role.attach_permission"
+	AuditPermissionDetach = "role.detach_permission"
+	AuditUserRoleGrant    = "user.grant_role"
+	AuditUserRoleRevoke   = "user.revoke_role"
+)
+
+// Объекты, над которыми выполняется действие
+const (
+	AuditTargetRole       = "role"
+	AuditTargetPermission = "permission"
+	AuditTargetUser       = "user"
+)
+
+// AuditEvent - запись журнала изменений ролей, прав и их назначений
+type AuditEvent struct {
+	ID         uuid.UUID         `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ActorID    *uuid.UUID        `json:"actor_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"` // Пусто для изменений из CLI
+	Action     string            `json:"action" example:"role.create"`
+	TargetType string            `json:"target_type" example:"role"`
+	TargetID   uuid.UUID         `json:"target_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Details    map[string]string `json:"details,omitempty"`
+	IP         string            `json:"ip,omitempty" example:"192.168.1.10"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
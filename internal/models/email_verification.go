@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmailVerificationToken - запись о выданном токене подтверждения email
+type EmailVerificationToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Email     string
+	UsedAt    *time.Time
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+const (
+	LoginThrottleAccount = "account"
+	LoginThrottleIP      = "ip"
+)
+
+// LoginThrottle - счетчик неудачных попыток входа по email или IP-адресу
+type LoginThrottle struct {
+	Key            string     `json:"key" example:"account:john@example.com"`
+	Kind           string     `json:"kind" example:"account"`
+	FailedAttempts int        `json:"failed_attempts" example:"5"`
+	LastFailedAt   time.Time  `json:"last_failed_at"`
+	LockedUntil    *time.Time `json:"locked_until,omitempty"`
+}
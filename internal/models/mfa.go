@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserMFA - TOTP второго фактора пользователя
+type UserMFA struct {
+	UserID         uuid.UUID
+	TOTPSecret     string
+	LastUsedStep   int64
+	FailedAttempts int
+	LockedUntil    *time.Time
+	ConfirmedAt    *time.Time // nil, пока пользователь не подтвердил подключение первым кодом
+	CreatedAt      time.Time
+}
+
+// Enabled сообщает, что подключение подтверждено и при входе нужен второй фактор
+func (m UserMFA) Enabled() bool {
+	return m.ConfirmedAt != nil
+}
+
+// RecoveryCode - одноразовый код восстановления
+type RecoveryCode struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	CodeHash  string
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
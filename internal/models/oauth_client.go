@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scope, которые могут получить сервисные аккаунты. Каждый открывает соответствующую группу маршрутов API
+const (
+	ScopeAchievements  = "achievements"
+	ScopeNotifications = "notifications"
+	ScopeRequests      = "requests"
+	ScopeIntrospection = "introspection" // Проверка чужих токенов через /auth/oidc/introspect
+)
+
+// ServiceScopes - все scope, доступные сервисным аккаунтам
+var ServiceScopes = []string{ScopeAchievements, ScopeNotifications, ScopeRequests, ScopeIntrospection}
+
+// OAuthClient - приложение, зарегистрированное для входа через OpenID Connect. Конфиденциальный
+// клиент с непустым Scopes может также входить сам как сервисный аккаунт (client_credentials)
+type OAuthClient struct {
+	ID           string
+	Name         string
+	SecretHash   *string // nil для публичных клиентов
+	RedirectURIs []string
+	Scopes       []string // scope, доступные через client_credentials
+	CreatedAt    time.Time
+}
+
+// IsPublic сообщает, что клиент не может хранить секрет и аутентифицируется только через PKCE
+func (c OAuthClient) IsPublic() bool {
+	return c.SecretHash == nil
+}
+
+// AllowsRedirectURI проверяет redirect_uri по списку разрешенных. Сравнение посимвольное, без шаблонов
+func (c OAuthClient) AllowsRedirectURI(uri string) bool {
+	for _, allowed := range c.RedirectURIs {
+		if allowed == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsScope проверяет, что клиенту разрешено получать scope через client_credentials
+func (c OAuthClient) AllowsScope(scope string) bool {
+	for _, allowed := range c.Scopes {
+		if allowed == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizationCode - выданный, но еще не обменянный на токены код авторизации
+type AuthorizationCode struct {
+	CodeHash      string
+	ClientID      string
+	UserID        uuid.UUID
+	RedirectURI   string
+	Scope         string
+	Nonce         string
+	CodeChallenge string
+	AuthTime      time.Time
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+}
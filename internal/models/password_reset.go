@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PasswordResetToken - выданный токен сброса пароля. Хранится только хеш токена
+type PasswordResetToken struct {
+	TokenHash string
+	UserID    uuid.UUID
+	UsedAt    *time.Time
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
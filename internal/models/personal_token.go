@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PersonalAccessToken - токен, который пользователь выпустил для скриптов и CI. Дает доступ от имени
+// пользователя, но только с правами из Scopes
+type PersonalAccessToken struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	TokenHash  string
+	Scopes     []string
+	ExpiresAt  time.Time
+	LastUsedAt *time.Time
+	LastUsedIP *string
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// PersonalTokenResponse - персональный токен в ответах API. Token заполняется только при создании
+type PersonalTokenResponse struct {
+	ID         uuid.UUID  `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Name       string     `json:"name" example:"achievements import"`
+	Token      string     `json:"token,omitempty" example:"itam_pat_5cQm3o6JY0a2n8Q3xw8Xk2bF1mK9pR4tV7yZ0cE6hLs"`
+	Scopes     []string   `json:"scopes" example:"admin_achievements"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP *string    `json:"last_used_ip,omitempty" example:"203.0.113.7"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Response возвращает токен в виде для API, без секрета
+func (t PersonalAccessToken) Response() PersonalTokenResponse {
+	return PersonalTokenResponse{
+		ID:         t.ID,
+		Name:       t.Name,
+		Scopes:     t.Scopes,
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		LastUsedIP: t.LastUsedIP,
+		CreatedAt:  t.CreatedAt,
+	}
+}
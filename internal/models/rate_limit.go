@@ -0,0 +1,39 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// TokenBucket - состояние ограничителя частоты запросов: bucket вмещает burst жетонов,
+// пополняется со скоростью rate жетонов в секунду, каждый запрос забирает один жетон
+type TokenBucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// NewTokenBucket возвращает полный bucket: первые burst запросов проходят сразу
+func NewTokenBucket(burst int, now time.Time) TokenBucket {
+	return TokenBucket{Tokens: float64(burst), UpdatedAt: now}
+}
+
+// Take пополняет bucket за прошедшее время и забирает жетон, если он есть
+func (b *TokenBucket) Take(now time.Time, rate float64, burst int) bool {
+	if elapsed := now.Sub(b.UpdatedAt).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(float64(burst), b.Tokens+elapsed*rate)
+		b.UpdatedAt = now
+	}
+	if b.Tokens < 1 {
+		return false
+	}
+	b.Tokens--
+	return true
+}
+
+// Until возвращает, через сколько в bucket будет n жетонов
+func (b *TokenBucket) Until(n float64, rate float64) time.Duration {
+	if b.Tokens >= n {
+		return 0
+	}
+	return time.Duration((n - b.Tokens) / rate * float64(time.Second))
+}
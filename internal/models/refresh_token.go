@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken описывает выданный refresh-токен. ID совпадает с jti токена,
+// FamilyID объединяет все токены, полученные ротацией от одного логина.
+// ClientID, Scope и AuthTime общие для всего семейства и переходят к новому токену при ротации.
+type RefreshToken struct {
+	ID        uuid.UUID
+	FamilyID  uuid.UUID
+	UserID    uuid.UUID
+	ClientID  *string   // OIDC-клиент, которому выдано семейство; nil - токены самого сервиса
+	Scope     string    // scope, выданный клиенту
+	AuthTime  time.Time // Момент входа пользователя, с которого началось семейство
+	ExpiresAt time.Time
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type KeyState string
+
+const (
+	KeyStatePending  KeyState = "pending"  // Ключ опубликован в JWKS, но еще не подписывает токены
+	KeyStateActive   KeyState = "active"   // Ключ подписывает новые токены
+	KeyStateRetiring KeyState = "retiring" // Ключ больше не подписывает, но токены, подписанные им, еще принимаются
+	KeyStateRetired  KeyState = "retired"  // Ключ выведен из обращения, токены с ним отклоняются
+)
+
+// SigningKey - запись таблицы signing_keys
+type SigningKey struct {
+	ID          string
+	Algorithm   string
+	PrivateKey  string // PEM для RS256/EdDSA, base64 секрета для HS256; с префиксом enc:v1: - зашифрован JWT_KEY_ENCRYPTION_KEY
+	State       KeyState
+	CreatedAt   time.Time
+	ActivatedAt *time.Time
+	RetiredAt   *time.Time
+}
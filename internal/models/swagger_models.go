@@ -29,9 +29,18 @@ type SuccessResponse struct {
 
 // LoginResponse представляет ответ на успешную авторизацию
 type LoginResponse struct {
-	AccessToken string `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
-	TokenType   string `json:"token_type" example:"Bearer"`
-	ExpiresIn   int    `json:"expires_in" example:"2592000"`
+	AccessToken  string `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string `json:"refresh_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	TokenType    string `json:"token_type" example:"Bearer"`
+	ExpiresIn    int    `json:"expires_in" example:"900"`
+}
+
+// MFAChallengeResponse - ответ на вход, когда после пароля нужен второй фактор
+type MFAChallengeResponse struct {
+	MFARequired           bool   `json:"mfa_required" example:"true"`
+	MFAToken              string `json:"mfa_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	MFAEnrollmentRequired bool   `json:"mfa_enrollment_required" example:"false"`
+	ExpiresIn             int    `json:"expires_in" example:"300"`
 }
 
 // RegisterResponse представляет ответ на успешную регистрацию
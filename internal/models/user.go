@@ -4,7 +4,6 @@ import (
 	"database/sql/driver"
 	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -51,48 +50,15 @@ type User struct {
 	Name          string    `json:"name" example:"John Doe"`
 	Email         string    `json:"email" example:"john@example.com"`
 	Telegram      *string   `json:"telegram,omitempty" example:"@johndoe"`
+	TelegramID    *int64    `json:"telegram_id,omitempty" example:"123456789"` // Привязанный аккаунт Telegram для входа
 	PasswordHash  string    `json:"-"` // Не отображается в JSON
+	EmailVerified bool      `json:"email_verified" example:"true"`
 	PhotoURL      *string   `json:"photo_url,omitempty" example:"/uploads/profile.jpg"`
 	About         *string   `json:"about,omitempty" example:"Software developer with 5 years of experience"`
 	ResumeURL     *string   `json:"resume_url,omitempty" example:"/uploads/resume.pdf"`
 	Specification Specification `json:"specification" example:"Backend"`
 	CreatedAt     time.Time `json:"created_at" example:"2023-01-01T00:00:00Z"`
 	UpdatedAt     time.Time `json:"updated_at" example:"2023-01-01T00:00:00Z"`
-}
-
-func (u *User) GetAdminServices(userRoles []UserRole, roles []Role, rolePermissions []RolePermission, permissions []Permission) []string {
-	adminServices := make(map[string]struct{})
-
-	roleMap := make(map[uuid.UUID]Role)
-	for _, r := range roles {
-		roleMap[r.ID] = r
-	}
-
-	rolePermMap := make(map[uuid.UUID][]uuid.UUID)
-	for _, rp := range rolePermissions {
-		rolePermMap[rp.RoleID] = append(rolePermMap[rp.RoleID], rp.PermissionID)
-	}
-
-	permMap := make(map[uuid.UUID]Permission)
-	for _, p := range permissions {
-		permMap[p.ID] = p
-	}
-
-	for _, ur := range userRoles {
-		if ur.UserID != u.ID {
-			continue
-		}
-		for _, permID := range rolePermMap[ur.RoleID] {
-			if perm, ok := permMap[permID]; ok && strings.HasPrefix(perm.Name, "admin_") {
-				service := strings.TrimPrefix(perm.Name, "admin_")
-				adminServices[service] = struct{}{}
-			}
-		}
-	}
-
-	result := make([]string, 0, len(adminServices))
-	for service := range adminServices {
-		result = append(result, service)
-	}
-	return result
+	Roles         []string  `json:"roles,omitempty" example:"User"` // Из access-токена, в базе не хранится
+	Permissions   []string  `json:"permissions,omitempty" example:"admin_auth"` // Из access-токена, в базе не хранится
 }
@@ -2,6 +2,15 @@ package models
 
 import "github.com/google/uuid"
 
+// Права администраторов сервисов. Право admin_<service> попадает в admin_services токена как <service>
+const (
+	PermissionAdminAuth          = "admin_auth"
+	PermissionAdminAchievements  = "admin_achievements"
+	PermissionAdminNotifications = "admin_notifications"
+	PermissionAdminRequests      = "admin_requests"
+	PermissionAdminFiles         = "admin_files"
+)
+
 type UserRole struct {
 	ID     uuid.UUID
 	UserID uuid.UUID
@@ -9,8 +18,9 @@ type UserRole struct {
 }
 
 type Role struct {
-	ID   uuid.UUID
-	Name string
+	ID       uuid.UUID
+	Name     string
+	ParentID *uuid.UUID // Роль наследует все права родителя и его предков
 }
 
 type RolePermission struct {
@@ -23,3 +33,14 @@ type Permission struct {
 	ID   uuid.UUID
 	Name string
 }
+
+// EffectivePermission - право пользователя и путь по иерархии ролей, которым оно получено
+type EffectivePermission struct {
+	PermissionID uuid.UUID `json:"permission_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Permission   string    `json:"permission" example:"admin_auth"`
+	RoleID       uuid.UUID `json:"role_id" example:"550e8400-e29b-41d4-a716-446655440000"` // Роль, которой право выдано напрямую
+	Role         string    `json:"role" example:"Admin"`
+	AssignedRole string    `json:"assigned_role" example:"HeadAdmin"` // Роль, назначенная пользователю
+	Path         []string  `json:"path" example:"HeadAdmin,Admin"`    // От назначенной роли до роли с правом
+	Inherited    bool      `json:"inherited" example:"true"`          // Право получено от родительской роли
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	WebAuthnPurposeRegistration = "registration"
+	WebAuthnPurposeLogin        = "login"
+)
+
+// WebAuthnCredential - ключ доступа (passkey) пользователя
+type WebAuthnCredential struct {
+	ID         []byte
+	UserID     uuid.UUID
+	Name       string
+	Credential []byte // Запись WebAuthn в JSON
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// WebAuthnSession - состояние незавершенной церемонии регистрации или входа
+type WebAuthnSession struct {
+	ID        uuid.UUID
+	UserID    *uuid.UUID // nil для входа без указания пользователя
+	Purpose   string
+	Data      []byte // Данные сессии WebAuthn в JSON
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// PasskeyResponse - ключ доступа в ответах API
+type PasskeyResponse struct {
+	ID         string     `json:"id" example:"mY2Xy1oZ9dM1lSs8bRZ0bQ"`
+	Name       string     `json:"name" example:"MacBook"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
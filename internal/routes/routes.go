@@ -5,7 +5,15 @@ import (
 	"itam_auth/internal/database"
 	"itam_auth/internal/handlers"
 	"itam_auth/internal/middleware"
+	"itam_auth/internal/models"
 	"itam_auth/internal/services/file"
+	"itam_auth/internal/services/jwt"
+	"itam_auth/internal/services/mailer"
+	"itam_auth/internal/services/oidc"
+	"itam_auth/internal/services/passkey"
+	"itam_auth/internal/services/ratelimit"
+	"itam_auth/internal/services/revocation"
+	"log"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -13,12 +21,19 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func SetupRoutes(storage *database.Storage, hmacSecret string, cfg *config.AppConfig) *gin.Engine {
+func SetupRoutes(storage *database.Storage, keys *jwt.KeyRing, revocations *revocation.Store, passkeys *passkey.Service, mail mailer.Mailer, limiter *ratelimit.Limiter, cfg *config.AppConfig) *gin.Engine {
 
 	// gin.SetMode(gin.ReleaseMode)
 
 	router := gin.Default()
 
+	// Адрес клиента (c.ClientIP) берется из X-Forwarded-For только от доверенных прокси. Иначе любой клиент
+	// подставит чужой адрес и обойдет блокировку входа и ограничения частоты по IP
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Printf("Invalid TRUSTED_PROXIES, no proxies are trusted: %v", err)
+		router.SetTrustedProxies(nil)
+	}
+
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 
@@ -35,65 +50,183 @@ func SetupRoutes(storage *database.Storage, hmacSecret string, cfg *config.AppCo
 		},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", "X-Requested-With", "Accept"},
-		ExposeHeaders:    []string{"Content-Length", "Content-Type"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Type", "Retry-After", "RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"},
 		AllowCredentials: true,
 		MaxAge:           12 * 60 * 60,
 	}
 	router.Use(cors.New(config))
 
+	// Проверка доступа для reverse proxy. Прокси вызывает ее на каждый запрос к защищенному приложению,
+	// поэтому она зарегистрирована до общего ограничения частоты: gin не применяет к маршруту middleware,
+	// добавленные после его регистрации
+	router.Any("/auth/forward_auth", handlers.ForwardAuth(storage, keys, revocations, cfg))
+
+	router.Use(middleware.RateLimit(limiter, "default"))
+
 	// Инициализируем файловый сервис
 	fileService := file.NewFileService(cfg)
 
+	// Публичные ключи для проверки токенов другими сервисами
+	router.GET("/.well-known/jwks.json", handlers.JWKS(keys))
+
+	// OpenID Connect: вход в приложения ITaM через этот сервис
+	provider := oidc.NewProvider(storage, keys, cfg)
+	router.GET("/.well-known/openid-configuration", handlers.OIDCDiscovery(provider))
+
 	auth := router.Group("/auth")
 	{
 		api := auth.Group("/api")
 		{
 			// Public routes that don't require authorization
 			api.GET("/ping", pingHandler)
-			api.POST("/login", handlers.Login(storage, hmacSecret))
-			api.POST("/register", handlers.Register(storage))
+			login := middleware.RateLimit(limiter, "login")
+			api.POST("/login", login, handlers.Login(storage, keys, cfg))
+			api.POST("/login/mfa", login, handlers.LoginMFA(storage, keys))
+			api.POST("/login/mfa/enroll", login, handlers.LoginMFAEnroll(storage, keys))
+			api.POST("/login/telegram", login, handlers.LoginTelegram(storage, keys, cfg))
+			api.POST("/webauthn/login/begin", login, handlers.BeginPasskeyLogin(passkeys))
+			api.POST("/webauthn/login/finish", login, handlers.FinishPasskeyLogin(passkeys))
+			api.POST("/refresh", login, handlers.Refresh(storage, keys))
+			api.POST("/register", middleware.RateLimit(limiter, "register"), handlers.Register(storage, keys, mail, cfg))
+			email := middleware.RateLimit(limiter, "email")
+			api.POST("/verify_email", email, handlers.VerifyEmail(storage, keys))
+			api.POST("/resend_verification", email, handlers.ResendVerificationEmail(storage, keys, mail, cfg))
+			api.POST("/forgot_password", email, handlers.ForgotPassword(storage, mail, cfg))
+			api.POST("/reset_password", email, handlers.ResetPassword(storage, revocations))
 			api.GET("/get_user/:user_id", handlers.GetUser(storage))
+			api.DELETE("/forward_auth/session", handlers.DeleteForwardAuthSession(cfg))
 
 			// Protected routes that require authorization
 			protected := api.Group("/")
-			protected.Use(middleware.AuthMiddleware(hmacSecret))
+			protected.Use(middleware.AuthMiddleware(storage, keys, revocations), middleware.RateLimit(limiter, "user"))
 			{
+				// Сессиями, паролем, вторым фактором и привязками управляют только после входа, не персональным токеном
+				noPersonalTokens := middleware.RejectPersonalTokens()
+				protected.POST("/logout", noPersonalTokens, handlers.Logout(storage, revocations, keys))
+				protected.POST("/logout_all", noPersonalTokens, handlers.LogoutAll(revocations))
 				protected.GET("/me", handlers.GetCurrentUser(storage))
 				protected.PATCH("/update_user_info", handlers.UpdateUserInfo(storage))
+				protected.POST("/change_password", noPersonalTokens, handlers.ChangePassword(storage, revocations, keys))
+				protected.POST("/link_telegram", noPersonalTokens, handlers.LinkTelegram(storage, cfg))
+				protected.DELETE("/unlink_telegram", noPersonalTokens, handlers.UnlinkTelegram(storage))
+				protected.POST("/forward_auth/session", noPersonalTokens, handlers.CreateForwardAuthSession(cfg))
+
+				//* MFA ROUTES
+				mfa := protected.Group("/mfa", noPersonalTokens)
+				{
+					mfa.GET("", handlers.GetMFAStatus(storage))
+					mfa.POST("/totp/enroll", handlers.EnrollTOTP(storage))
+					mfa.POST("/totp/confirm", handlers.ConfirmTOTP(storage))
+					mfa.DELETE("/totp", handlers.DisableTOTP(storage))
+					mfa.POST("/recovery_codes", handlers.RegenerateRecoveryCodes(storage))
+				}
+
+				//* WEBAUTHN ROUTES
+				webauthn := protected.Group("/webauthn", noPersonalTokens)
+				{
+					webauthn.POST("/register/begin", handlers.BeginPasskeyRegistration(passkeys))
+					webauthn.POST("/register/finish", handlers.FinishPasskeyRegistration(passkeys))
+					webauthn.GET("/credentials", handlers.GetPasskeys(passkeys))
+					webauthn.DELETE("/credentials/:credential_id", handlers.DeletePasskey(passkeys))
+				}
+
+				//* PERSONAL ACCESS TOKEN ROUTES
+				personalTokens := protected.Group("/personal_tokens", noPersonalTokens)
+				{
+					personalTokens.GET("", handlers.GetPersonalTokens(storage))
+					personalTokens.POST("", handlers.CreatePersonalToken(storage))
+					personalTokens.DELETE("/:token_id", handlers.RevokePersonalToken(storage))
+				}
+
 				protected.GET("/get_user_roles", handlers.GetUserRoles(storage))
 				protected.GET("/get_user_properties", handlers.GetUserPermissions(storage))
+				protected.GET("/get_user_permission_sources", handlers.GetPermissionSources(storage))
 
 				//* REQUEST ROUTES
-				protected.POST("/create_user_request", handlers.CreateUserRequest(storage))
-				protected.GET("/get_request", handlers.GetRequest(storage))
-				protected.GET("/get_all_requests", handlers.GetAllRequests(storage))
-				protected.PATCH("/update_request_status", handlers.UpdateRequestStatus(storage))
-				protected.DELETE("/delete_request", handlers.DeleteRequest(storage))
+				requests := protected.Group("/", middleware.RequireScope(models.ScopeRequests))
+				{
+					requests.POST("/create_user_request", handlers.CreateUserRequest(storage))
+					requests.GET("/get_request", handlers.GetRequest(storage))
+					requests.GET("/get_all_requests", handlers.GetAllRequests(storage))
+					requests.PATCH("/update_request_status", middleware.RequirePermission(storage, models.PermissionAdminRequests), handlers.UpdateRequestStatus(storage))
+					requests.DELETE("/delete_request", handlers.DeleteRequest(storage))
+				}
 
 				//* ACHIEVEMENT ROUTES
-				protected.GET("/get_user_achievements", handlers.GetAchievementsByUserID(storage))
-				protected.POST("/create_achievement", handlers.CreateAchievement(storage))
-				protected.PATCH("/update_achievement", handlers.UpdateAchievement(storage))
-				protected.GET("/get_achievement", handlers.GetAchievementByID(storage))
-				protected.GET("/get_all_achievements", handlers.GetAllAchievements(storage))
-				protected.DELETE("/delete_achievement", handlers.DeleteAchievement(storage))
+				achievements := protected.Group("/", middleware.RequireScope(models.ScopeAchievements))
+				adminAchievements := middleware.RequirePermission(storage, models.PermissionAdminAchievements)
+				{
+					achievements.GET("/get_user_achievements", handlers.GetAchievementsByUserID(storage))
+					achievements.POST("/create_achievement", adminAchievements, handlers.CreateAchievement(storage))
+					achievements.PATCH("/update_achievement", adminAchievements, handlers.UpdateAchievement(storage))
+					achievements.GET("/get_achievement", handlers.GetAchievementByID(storage))
+					achievements.GET("/get_all_achievements", handlers.GetAllAchievements(storage))
+					achievements.DELETE("/delete_achievement", adminAchievements, handlers.DeleteAchievement(storage))
+				}
 
 				//* NOTIFICATION ROUTES
-				protected.POST("/create_notification", handlers.CreateNotification(storage))
-				protected.PATCH("/update_notification", handlers.UpdateNotification(storage))
-				protected.GET("/get_all_notifications", handlers.GetAllNotifications(storage))
-				protected.GET("/get_notification/:notification_id", handlers.GetNotification(storage))
-				protected.DELETE("/delete_notification", handlers.DeleteNotification(storage))
+				notifications := protected.Group("/", middleware.RequireScope(models.ScopeNotifications))
+				adminNotifications := middleware.RequirePermission(storage, models.PermissionAdminNotifications)
+				{
+					notifications.POST("/create_notification", adminNotifications, handlers.CreateNotification(storage))
+					notifications.PATCH("/update_notification", adminNotifications, handlers.UpdateNotification(storage))
+					notifications.GET("/get_all_notifications", handlers.GetAllNotifications(storage))
+					notifications.GET("/get_notification/:notification_id", handlers.GetNotification(storage))
+					notifications.DELETE("/delete_notification", handlers.DeleteNotification(storage))
+				}
 
 				//* FILE ROUTES
-				protected.POST("/upload_profile_image", handlers.UploadProfileImage(storage, fileService))
-				protected.POST("/upload_achievement_image", handlers.UploadAchievementImage(storage, fileService))
-				protected.POST("/upload_resume", handlers.UploadResume(storage, fileService))
+				upload := middleware.RateLimit(limiter, "upload")
+				protected.POST("/upload_profile_image", upload, handlers.UploadProfileImage(storage, fileService))
+				protected.POST("/upload_achievement_image", upload, adminAchievements, handlers.UploadAchievementImage(storage, fileService))
+				protected.POST("/upload_resume", upload, handlers.UploadResume(storage, fileService))
 				protected.GET("/get_user_files", handlers.GetUserFiles(storage))
 				protected.DELETE("/delete_file/:file_id", handlers.DeleteFile(storage, fileService))
+
+				//* ADMIN ROUTES
+				admin := protected.Group("/admin", middleware.RequireAdminService(storage, "auth"))
+				{
+					admin.GET("/login_lockouts", handlers.GetLoginLockouts(storage))
+					admin.DELETE("/login_lockouts", handlers.ClearLoginLockout(storage))
+
+					admin.GET("/roles", handlers.GetRoles(storage))
+					admin.POST("/roles", handlers.CreateRole(storage))
+					admin.PATCH("/roles", handlers.RenameRole(storage))
+					admin.DELETE("/roles", handlers.DeleteRole(storage))
+					admin.PUT("/roles/parent", handlers.SetRoleParent(storage))
+					admin.GET("/role_users", handlers.GetRoleUsers(storage))
+
+					admin.GET("/permissions", handlers.GetPermissions(storage))
+					admin.POST("/permissions", handlers.CreatePermission(storage))
+					admin.PATCH("/permissions", handlers.RenamePermission(storage))
+					admin.DELETE("/permissions", handlers.DeletePermission(storage))
+
+					admin.GET("/role_permissions", handlers.GetRolePermissions(storage))
+					admin.POST("/role_permissions", handlers.AttachPermission(storage))
+					admin.DELETE("/role_permissions", handlers.DetachPermission(storage))
+
+					admin.GET("/user_roles", handlers.GetAssignedRoles(storage))
+					admin.POST("/user_roles", handlers.GrantRole(storage))
+					admin.DELETE("/user_roles", handlers.RevokeRole(storage))
+
+					admin.GET("/audit_log", handlers.GetAuditLog(storage))
+				}
 			}
 		}
 
+		oidcGroup := auth.Group("/oidc")
+		{
+			oidcGroup.GET("/authorize", handlers.OIDCAuthorize(provider))
+			oidcGroup.POST("/token", handlers.OIDCToken(provider))
+			oidcGroup.POST("/introspect", handlers.OIDCIntrospect(provider, revocations))
+
+			// Код авторизации выдает страница входа самого сервиса, а userinfo вызывают и приложения своими токенами
+			noPersonalTokens := middleware.RejectPersonalTokens()
+			oidcGroup.POST("/authorize", middleware.AuthMiddleware(storage, keys, revocations), noPersonalTokens, handlers.OIDCAuthorizeConfirm(provider))
+			oidcGroup.GET("/userinfo", middleware.OIDCAuthMiddleware(storage, keys, revocations), noPersonalTokens,
+				middleware.RequireScope(oidc.ScopeOpenID), handlers.OIDCUserInfo(storage))
+		}
+
 		auth.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	}
 
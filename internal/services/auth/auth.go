@@ -2,12 +2,15 @@ package auth
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
 	"itam_auth/internal/database"
 	"itam_auth/internal/models"
 	"itam_auth/internal/services/jwt"
+	"itam_auth/internal/services/permission"
+	"itam_auth/internal/services/revocation"
 	"log"
+	"slices"
 	"strings"
 	"time"
 
@@ -17,12 +20,41 @@ import (
 
 const (
 	defaultUserSpecification = "Frontend"          // Спецификация пользователя по умолчанию
-	tokenDuration            = 30 * 24 * time.Hour // Длительность действия токена (30 дней)
+	accessTokenDuration      = 15 * time.Minute    // Длительность действия access-токена
+	refreshTokenDuration     = 30 * 24 * time.Hour // Длительность действия refresh-токена (30 дней)
 	minPasswordLength        = 8                   // Минимальная длина пароля
 	bcryptCost               = bcrypt.DefaultCost  // Стоимость хеширования пароля
 	defaultRoleName          = "User"              // Роль по умолчанию для новых пользователей
 )
 
+// ErrRefreshTokenClientMismatch - refresh-токен предъявлен не тем клиентом, которому выдано его семейство
+var ErrRefreshTokenClientMismatch = errors.New("refresh token was issued to another client")
+
+// TokenPair - пара токенов, которую получает клиент при логине и при обновлении
+// Если после пароля нужен второй фактор, вместо пары выдается только MFAToken
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int // Время жизни access-токена (или MFA-токена) в секундах
+
+	MFAToken              string
+	MFAEnrollmentRequired bool // Второй фактор обязателен, но еще не подключен
+}
+
+// LoginPolicy - дополнительные требования при входе
+type LoginPolicy struct {
+	RequireMFAForAdmins  bool // Требовать MFA у всех, у кого есть права admin_*
+	RequireVerifiedEmail bool // Не пускать по паролю и ключу доступа, пока email не подтвержден
+}
+
+// CheckEmail возвращает ErrEmailNotVerified, если политика требует подтвержденный email, а он не подтвержден
+func (p LoginPolicy) CheckEmail(user models.User) error {
+	if p.RequireVerifiedEmail && !user.EmailVerified {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
 func validateUserData(name, email, password string) error {
 	if strings.TrimSpace(email) == "" {
 		return fmt.Errorf("email cannot be empty")
@@ -32,10 +64,14 @@ func validateUserData(name, email, password string) error {
 		return fmt.Errorf("invalid email format")
 	}
 
+	return validatePassword(password)
+}
+
+// validatePassword проверяет пароль по тем же правилам, что и при регистрации
+func validatePassword(password string) error {
 	if len(password) < minPasswordLength {
 		return fmt.Errorf("password must be at least %d characters long", minPasswordLength)
 	}
-
 	return nil
 }
 
@@ -68,89 +104,312 @@ func RegisterUser(ctx context.Context, storage *database.Storage, name, email, p
 		return models.User{}, fmt.Errorf("failed to save user: %w", err)
 	}
 
+	if err := assignDefaultRole(ctx, storage, user); err != nil {
+		return models.User{}, err
+	}
+
+	log.Printf("User registered successfully (email=%s, id=%s)", email, userID)
+	return user, nil
+}
+
+// assignDefaultRole выдает новому пользователю роль по умолчанию
+func assignDefaultRole(ctx context.Context, storage *database.Storage, user models.User) error {
 	role, err := storage.GetRoleByName(ctx, defaultRoleName)
 	if err != nil {
-		log.Printf("Failed to get default role '%s' for user (email=%s, id=%s): %v", defaultRoleName, email, userID, err)
-		return models.User{}, fmt.Errorf("failed to get default role: %w", err)
+		log.Printf("Failed to get default role '%s' for user (email=%s, id=%s): %v", defaultRoleName, user.Email, user.ID, err)
+		return fmt.Errorf("failed to get default role: %w", err)
 	}
 
 	userRole := models.UserRole{
 		ID:     uuid.New(),
-		UserID: userID,
+		UserID: user.ID,
 		RoleID: role.ID,
 	}
 
 	_, err = storage.SaveUserRole(ctx, userRole)
 	if err != nil {
-		log.Printf("Failed to save user role for user (email=%s, id=%s): %v", email, userID, err)
-		return models.User{}, fmt.Errorf("failed to save user role: %w", err)
+		log.Printf("Failed to save user role for user (email=%s, id=%s): %v", user.Email, user.ID, err)
+		return fmt.Errorf("failed to save user role: %w", err)
 	}
 
-	log.Printf("User registered successfully (email=%s, id=%s)", email, userID)
-	return user, nil
+	return nil
 }
 
-func AuthenticateUser(ctx context.Context, storage *database.Storage, email, password, hmacSecret string) (string, error) {
+// AuthenticateUser проверяет email и пароль. Неудачи считаются отдельно по email и по IP-адресу клиента;
+// после нескольких неудач подряд вход временно блокируется (LoginLockedError). Неверный пароль и
+// незарегистрированный email неразличимы: оба дают ErrInvalidCredentials
+func AuthenticateUser(ctx context.Context, storage *database.Storage, email, password, ip string, policy LoginPolicy, keys *jwt.KeyRing) (TokenPair, error) {
 	if strings.TrimSpace(email) == "" {
-		return "", fmt.Errorf("email cannot be empty")
+		return TokenPair{}, fmt.Errorf("email cannot be empty")
 	}
 	if strings.TrimSpace(password) == "" {
-		return "", fmt.Errorf("password cannot be empty")
+		return TokenPair{}, fmt.Errorf("password cannot be empty")
+	}
+
+	accountKey, ipKey := loginThrottleKeys(email, ip)
+	if err := checkLoginLock(ctx, storage, accountKey, ipKey); err != nil {
+		log.Printf("Login rejected (email=%s, ip=%s): %v", email, ip, err)
+		return TokenPair{}, err
 	}
 
 	user, err := storage.GetUserByEmail(ctx, email)
-	if err != nil {
+	if err != nil && !errors.Is(err, database.ErrUserNotFound) {
 		log.Printf("Failed to get user by email (email=%s): %v", email, err)
-		if err == sql.ErrNoRows {
-			return "", fmt.Errorf("user not found")
-		}
-		return "", fmt.Errorf("failed to get user: %w", err)
+		return TokenPair{}, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// Пользователи, вошедшие только через Telegram или ключ доступа, пароля не имеют
+	hasPassword := err == nil && user.PasswordHash != ""
+	passwordHash := []byte(user.PasswordHash)
+	if !hasPassword {
+		passwordHash = dummyPasswordHash()
+	}
+	if bcrypt.CompareHashAndPassword(passwordHash, []byte(password)) != nil || !hasPassword {
+		log.Printf("Invalid login attempt (email=%s, ip=%s, user_found=%t)", email, ip, err == nil)
+		recordLoginFailure(ctx, storage, accountKey, models.LoginThrottleAccount, accountFreeAttempts, accountMaxLock)
+		recordLoginFailure(ctx, storage, ipKey, models.LoginThrottleIP, ipFreeAttempts, ipMaxLock)
+		return TokenPair{}, ErrInvalidCredentials
+	}
+
+	if err := storage.DeleteLoginThrottle(ctx, accountKey); err != nil && !errors.Is(err, database.ErrLoginThrottleNotFound) {
+		log.Printf("Failed to reset login failures (email=%s): %v", email, err)
+	}
+
+	if err := policy.CheckEmail(user); err != nil {
+		log.Printf("Login rejected, email is not verified (email=%s, id=%s)", email, user.ID)
+		return TokenPair{}, err
+	}
+
+	tokens, err := startSession(ctx, storage, user, policy, keys)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	log.Printf("User authenticated successfully (email=%s, id=%s, mfa_required=%t)", email, user.ID, tokens.MFAToken != "")
+	return tokens, nil
+}
+
+// IssueTokens начинает новую сессию для уже аутентифицированного пользователя:
+// выпускает access-токен и refresh-токен нового семейства
+func IssueTokens(ctx context.Context, storage *database.Storage, user models.User, keys *jwt.KeyRing) (TokenPair, error) {
+	return startFamily(ctx, storage, user, models.RefreshToken{AuthTime: time.Now()}, keys)
+}
+
+// IssueClientTokens выпускает токены, которые пользователь выдал приложению (OIDC-клиенту): access-токен
+// с aud/cid приложения и выданным scope без ролей и прав и refresh-токен семейства, привязанного к клиенту.
+// authTime - момент входа пользователя, после которого он разрешил доступ
+func IssueClientTokens(ctx context.Context, storage *database.Storage, user models.User, clientID, scope string, authTime time.Time, keys *jwt.KeyRing) (TokenPair, error) {
+	return startFamily(ctx, storage, user, models.RefreshToken{ClientID: &clientID, Scope: scope, AuthTime: authTime}, keys)
+}
+
+// startFamily выпускает первую пару токенов нового семейства с параметрами family
+func startFamily(ctx context.Context, storage *database.Storage, user models.User, family models.RefreshToken, keys *jwt.KeyRing) (TokenPair, error) {
+	family.FamilyID = uuid.New()
+	tokens, refreshToken, err := newSession(ctx, storage, user, family, keys)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	if _, err := storage.SaveRefreshToken(ctx, refreshToken); err != nil {
+		log.Printf("Failed to save refresh token for user (email=%s, id=%s): %v", user.Email, user.ID, err)
+		return TokenPair{}, fmt.Errorf("failed to save refresh token: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// RefreshTokens обменивает refresh-токен на новую пару токенов. Старый токен
+// становится недействительным; его повторное предъявление считается признаком
+// кражи, и тогда отзывается все семейство токенов.
+//
+// clientID - OIDC-клиент, который предъявил токен, или пустая строка для /api/refresh. Токен
+// принимается, только если его семейство выдано этому же клиенту (ErrRefreshTokenClientMismatch)
+func RefreshTokens(ctx context.Context, storage *database.Storage, refreshTokenString, clientID string, keys *jwt.KeyRing) (TokenPair, error) {
+	claims, err := jwt.ValidateRefreshToken(refreshTokenString, keys)
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("invalid refresh token: %w", err)
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+	tokenID := uuid.MustParse(claims.ID)
+	stored, err := storage.GetRefreshToken(ctx, tokenID)
 	if err != nil {
-		log.Printf("Invalid password for user (email=%s, id=%s)", email, user.ID)
-		return "", fmt.Errorf("invalid password: %w", err)
+		log.Printf("Failed to get refresh token (id=%s): %v", tokenID, err)
+		return TokenPair{}, fmt.Errorf("invalid refresh token: %w", err)
+	}
+
+	if familyClientID(stored) != clientID {
+		log.Printf("Refresh token presented by another client (family=%s, client_id=%q, presented_by=%q)",
+			stored.FamilyID, familyClientID(stored), clientID)
+		return TokenPair{}, ErrRefreshTokenClientMismatch
 	}
 
-	userRoles, err := storage.GetUserRoles(ctx, user.ID)
+	if stored.RevokedAt != nil {
+		return TokenPair{}, fmt.Errorf("refresh token has been revoked")
+	}
+
+	if stored.RotatedAt != nil {
+		revokeFamilyOnReuse(ctx, storage, stored)
+		return TokenPair{}, database.ErrRefreshTokenReused
+	}
+
+	user, err := storage.GetUserByID(ctx, stored.UserID)
+	if err != nil {
+		log.Printf("Failed to get user for refresh token (id=%s, user_id=%s): %v", tokenID, stored.UserID, err)
+		return TokenPair{}, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	tokens, refreshToken, err := newSession(ctx, storage, user, stored, keys)
 	if err != nil {
-		log.Printf("Failed to get user roles for user (email=%s, id=%s): %v", email, user.ID, err)
-		return "", fmt.Errorf("failed to get user roles: %w", err)
+		return TokenPair{}, err
 	}
 
-	roleIDs := make([]uuid.UUID, len(userRoles))
-	for i, ur := range userRoles {
-		roleIDs[i] = ur.RoleID
+	if err := storage.RotateRefreshToken(ctx, tokenID, refreshToken); err != nil {
+		if errors.Is(err, database.ErrRefreshTokenReused) {
+			revokeFamilyOnReuse(ctx, storage, stored)
+		}
+		return TokenPair{}, err
 	}
-	roles, err := storage.GetRolesByIDs(ctx, roleIDs)
+
+	log.Printf("Tokens refreshed successfully (id=%s, family=%s)", user.ID, stored.FamilyID)
+	return tokens, nil
+}
+
+// Logout отзывает текущий access-токен и, если передан refresh-токен той же сессии, все его семейство
+func Logout(ctx context.Context, storage *database.Storage, revocations *revocation.Store, claims *jwt.Claims, refreshTokenString string, keys *jwt.KeyRing) error {
+	userID, err := uuid.Parse(claims.UID)
 	if err != nil {
-		log.Printf("Failed to get roles for user (email=%s, id=%s): %v", email, user.ID, err)
-		return "", fmt.Errorf("failed to get roles: %w", err)
+		return fmt.Errorf("invalid user ID in token: %w", err)
+	}
+
+	if claims.ID != "" {
+		tokenID, err := uuid.Parse(claims.ID)
+		if err != nil {
+			return fmt.Errorf("invalid token ID: %w", err)
+		}
+		expiresAt := time.Now().Add(accessTokenDuration)
+		if claims.ExpiresAt != nil {
+			expiresAt = claims.ExpiresAt.Time
+		}
+		if err := revocations.RevokeToken(ctx, tokenID, userID, expiresAt); err != nil {
+			log.Printf("Failed to revoke access token (id=%s, jti=%s): %v", userID, tokenID, err)
+			return fmt.Errorf("failed to revoke access token: %w", err)
+		}
+	}
+
+	if refreshTokenString != "" {
+		refreshClaims, err := jwt.ValidateRefreshToken(refreshTokenString, keys)
+		if err != nil {
+			return fmt.Errorf("invalid refresh token: %w", err)
+		}
+		if refreshClaims.UID != claims.UID {
+			return fmt.Errorf("refresh token belongs to another user")
+		}
+		if err := storage.RevokeRefreshTokenFamily(ctx, uuid.MustParse(refreshClaims.FamilyID)); err != nil {
+			log.Printf("Failed to revoke refresh token family (id=%s, family=%s): %v", userID, refreshClaims.FamilyID, err)
+			return err
+		}
 	}
 
-	rolePermissions, err := storage.GetRolePermissions(ctx, roleIDs[0])
+	log.Printf("User logged out (id=%s)", userID)
+	return nil
+}
+
+// LogoutAll отзывает все токены пользователя, выпущенные до текущего момента, на всех устройствах
+func LogoutAll(ctx context.Context, revocations *revocation.Store, userID uuid.UUID) error {
+	if err := revocations.RevokeAllUserTokens(ctx, userID); err != nil {
+		log.Printf("Failed to revoke all tokens for user (id=%s): %v", userID, err)
+		return fmt.Errorf("failed to revoke tokens: %w", err)
+	}
+
+	log.Printf("User logged out from all sessions (id=%s)", userID)
+	return nil
+}
+
+func revokeFamilyOnReuse(ctx context.Context, storage *database.Storage, token models.RefreshToken) {
+	log.Printf("Refresh token reuse detected, revoking family (user_id=%s, family=%s)", token.UserID, token.FamilyID)
+	if err := storage.RevokeRefreshTokenFamily(ctx, token.FamilyID); err != nil {
+		log.Printf("Failed to revoke refresh token family (family=%s): %v", token.FamilyID, err)
+	}
+}
+
+// familyClientID возвращает OIDC-клиента семейства refresh-токенов или пустую строку для токенов самого сервиса
+func familyClientID(token models.RefreshToken) string {
+	if token.ClientID == nil {
+		return ""
+	}
+	return *token.ClientID
+}
+
+// newSession выпускает access-токен и refresh-токен семейства family: у нового токена те же
+// FamilyID, клиент, scope и время входа. Запись о refresh-токене возвращается вызывающему для сохранения.
+func newSession(ctx context.Context, storage *database.Storage, user models.User, family models.RefreshToken, keys *jwt.KeyRing) (TokenPair, models.RefreshToken, error) {
+	var accessToken string
+	var err error
+	if clientID := familyClientID(family); clientID != "" {
+		accessToken, err = jwt.NewClientToken(user, clientID, family.Scope, family.AuthTime, accessTokenDuration, keys)
+	} else {
+		accessToken, err = newUserToken(ctx, storage, user, family.AuthTime, keys)
+	}
 	if err != nil {
-		log.Printf("Failed to get role permissions for user (email=%s, id=%s): %v", email, user.ID, err)
-		return "", fmt.Errorf("failed to get role permissions: %w", err)
+		log.Printf("Failed to generate JWT token for user (email=%s, id=%s): %v", user.Email, user.ID, err)
+		return TokenPair{}, models.RefreshToken{}, fmt.Errorf("failed to generate token: %w", err)
 	}
 
-	permissionIDs := make([]uuid.UUID, len(rolePermissions))
-	for i, rp := range rolePermissions {
-		permissionIDs[i] = rp.PermissionID
+	now := time.Now()
+	refreshToken := models.RefreshToken{
+		ID:        uuid.New(),
+		FamilyID:  family.FamilyID,
+		UserID:    user.ID,
+		ClientID:  family.ClientID,
+		Scope:     family.Scope,
+		AuthTime:  family.AuthTime,
+		ExpiresAt: now.Add(refreshTokenDuration),
+		CreatedAt: now,
 	}
-	permissions, err := storage.GetPermissionsByIDs(ctx, permissionIDs)
+
+	refreshTokenString, err := jwt.NewRefreshToken(user, refreshToken.ID, family.FamilyID, refreshTokenDuration, keys)
 	if err != nil {
-		log.Printf("Failed to get permissions for user (email=%s, id=%s): %v", email, user.ID, err)
-		return "", fmt.Errorf("failed to get permissions: %w", err)
+		log.Printf("Failed to generate refresh token for user (email=%s, id=%s): %v", user.Email, user.ID, err)
+		return TokenPair{}, models.RefreshToken{}, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
-	tokenString, err := jwt.NewToken(user, tokenDuration, hmacSecret, userRoles, roles, rolePermissions, permissions)
+	return TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshTokenString,
+		ExpiresIn:    int(accessTokenDuration.Seconds()),
+	}, refreshToken, nil
+}
+
+// newUserToken выпускает access-токен самого сервиса с ролями, правами и профилем пользователя
+// в пределах JWT_CLAIMS. admin_services выпускается всегда
+func newUserToken(ctx context.Context, storage *database.Storage, user models.User, authTime time.Time, keys *jwt.KeyRing) (string, error) {
+	permissions, err := permission.Resolve(ctx, storage, user.ID)
 	if err != nil {
-		log.Printf("Failed to generate JWT token for user (email=%s, id=%s): %v", email, user.ID, err)
-		return "", fmt.Errorf("failed to generate token: %w", err)
+		log.Printf("Failed to resolve permissions for user (email=%s, id=%s): %v", user.Email, user.ID, err)
+		return "", err
+	}
+
+	grants := jwt.Grants{AdminServices: permissions.AdminServices()}
+	if hasTokenClaim(ClaimPermissions) {
+		grants.Permissions = permissions.Names()
+	}
+	if hasTokenClaim(ClaimName) {
+		grants.Name = user.Name
+	}
+	if hasTokenClaim(ClaimSpecification) {
+		grants.Specification = string(user.Specification)
+	}
+	if hasTokenClaim(ClaimRoles) {
+		roles, err := storage.GetRolesByUserID(ctx, user.ID)
+		if err != nil {
+			log.Printf("Failed to get roles for user (email=%s, id=%s): %v", user.Email, user.ID, err)
+			return "", err
+		}
+		for _, role := range roles {
+			grants.Roles = append(grants.Roles, role.Name)
+		}
+		slices.Sort(grants.Roles)
 	}
 
-	log.Printf("User authenticated successfully (email=%s, id=%s)", email, user.ID)
-	return tokenString, nil
+	return jwt.NewToken(user, authTime, accessTokenDuration, keys, grants)
 }
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Необязательные claims access-токена пользователя. Какие из них выпускаются, задает JWT_CLAIMS
+const (
+	ClaimRoles         = "roles"
+	ClaimPermissions   = "permissions"
+	ClaimName          = "name"
+	ClaimSpecification = "specification"
+)
+
+var optionalClaims = []string{ClaimRoles, ClaimPermissions, ClaimName, ClaimSpecification}
+
+// tokenClaims - необязательные claims, которые попадают в access-токены пользователей. По умолчанию
+// выпускаются все, как при JWT_CLAIMS по умолчанию
+var tokenClaims = optionalClaims
+
+// ConfigureTokenClaims задает необязательные claims access-токенов из JWT_CLAIMS. none выключает все.
+// Вызывается один раз при запуске, до выпуска токенов
+func ConfigureTokenClaims(names []string) error {
+	claims := []string{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch {
+		case name == "" || name == "none":
+			continue
+		case !slices.Contains(optionalClaims, name):
+			return fmt.Errorf("unknown claim in JWT_CLAIMS: %s (expected %s or none)", name, strings.Join(optionalClaims, ", "))
+		}
+		claims = append(claims, name)
+	}
+	tokenClaims = claims
+	return nil
+}
+
+func hasTokenClaim(name string) bool {
+	return slices.Contains(tokenClaims, name)
+}
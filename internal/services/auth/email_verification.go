@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"itam_auth/internal/database"
+	"itam_auth/internal/models"
+	"itam_auth/internal/services/jwt"
+	"itam_auth/internal/services/mailer"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const emailVerificationDuration = 24 * time.Hour // Сколько действует ссылка из письма подтверждения
+
+// ErrEmailNotVerified - вход по паролю закрыт, пока email не подтвержден
+var ErrEmailNotVerified = errors.New("email is not verified")
+
+// EmailVerification - как отправлять письма подтверждения email
+type EmailVerification struct {
+	Mailer  mailer.Mailer
+	LinkURL string // Страница фронтенда, токен добавляется в параметр token
+}
+
+// SendVerificationEmail выпускает одноразовый токен подтверждения и отправляет ссылку на email пользователя
+func SendVerificationEmail(ctx context.Context, storage *database.Storage, keys *jwt.KeyRing, verification EmailVerification, user models.User) error {
+	now := time.Now()
+	record := models.EmailVerificationToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Email:     user.Email,
+		ExpiresAt: now.Add(emailVerificationDuration),
+		CreatedAt: now,
+	}
+
+	token, err := jwt.NewEmailVerificationToken(user.ID, user.Email, record.ID, emailVerificationDuration, keys)
+	if err != nil {
+		return err
+	}
+
+	if err := storage.SaveEmailVerificationToken(ctx, record); err != nil {
+		log.Printf("Failed to save email verification token (email=%s, id=%s): %v", user.Email, user.ID, err)
+		return err
+	}
+	if err := storage.DeleteExpiredEmailVerificationTokens(ctx); err != nil {
+		log.Printf("Failed to delete expired email verification tokens: %v", err)
+	}
+
+	link, err := withQuery(verification.LinkURL, "token", token)
+	if err != nil {
+		return err
+	}
+
+	err = verification.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Подтверждение email в ITaM",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы подтвердить email, перейдите по ссылке:\n%s\n\n"+
+			"Ссылка действует %d часа. Если вы не регистрировались в ITaM, просто проигнорируйте это письмо.\n",
+			user.Name, link, int(emailVerificationDuration.Hours())),
+	})
+	if err != nil {
+		log.Printf("Failed to send verification email (email=%s, id=%s): %v", user.Email, user.ID, err)
+		return err
+	}
+
+	log.Printf("Verification email sent (email=%s, id=%s)", user.Email, user.ID)
+	return nil
+}
+
+// VerifyEmail подтверждает email по токену из письма. Токен принимается один раз и только
+// для адреса, на который было отправлено письмо
+func VerifyEmail(ctx context.Context, storage *database.Storage, keys *jwt.KeyRing, token string) (uuid.UUID, error) {
+	claims, err := jwt.ValidateEmailVerificationToken(token, keys)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %v", database.ErrEmailVerificationTokenInvalid, err)
+	}
+
+	userID := uuid.MustParse(claims.UID)
+	if err := storage.UseEmailVerificationToken(ctx, uuid.MustParse(claims.ID), userID); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := storage.MarkEmailVerified(ctx, userID, claims.Email); err != nil {
+		if errors.Is(err, database.ErrUserNotFound) {
+			return uuid.Nil, database.ErrEmailVerificationTokenInvalid
+		}
+		log.Printf("Failed to mark email verified (id=%s): %v", userID, err)
+		return uuid.Nil, err
+	}
+
+	log.Printf("Email verified (email=%s, id=%s)", claims.Email, userID)
+	return userID, nil
+}
+
+// ResendVerificationEmail отправляет новое письмо, если такой пользователь есть и email еще не подтвержден.
+// Иначе ничего не делает, чтобы по ответу нельзя было узнать, зарегистрирован ли адрес
+func ResendVerificationEmail(ctx context.Context, storage *database.Storage, keys *jwt.KeyRing, verification EmailVerification, email string) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return nil
+	}
+
+	user, err := storage.GetUserByEmail(ctx, email)
+	if err != nil || user.EmailVerified {
+		return nil
+	}
+	return SendVerificationEmail(ctx, storage, keys, verification, user)
+}
+
+func withQuery(rawURL, key, value string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid link url: %w", err)
+	}
+	query := u.Query()
+	query.Set(key, value)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"itam_auth/internal/database"
+	"itam_auth/internal/models"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	accountFreeAttempts = 3                // Сколько неудач подряд по одному email проходят без блокировки
+	ipFreeAttempts      = 20               // То же для одного IP-адреса: за ним может быть целая аудитория
+	loginFailureWindow  = time.Hour        // Через сколько после последней неудачи счетчик начинается заново
+	accountMaxLock      = 15 * time.Minute // Максимальная блокировка email
+	ipMaxLock           = time.Hour        // Максимальная блокировка IP-адреса
+)
+
+// ErrInvalidCredentials - единственная ошибка входа по паролю, не раскрывающая, зарегистрирован ли email
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// LoginLockedError - попытки входа временно отклоняются из-за множества неудач
+type LoginLockedError struct {
+	Until time.Time
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again after %s", e.Until.Format(time.RFC3339))
+}
+
+// dummyPasswordHash сравнивается с паролем, если email не найден, чтобы время ответа не выдавало,
+// зарегистрирован ли адрес
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, err := bcrypt.GenerateFromPassword([]byte("itam-dummy-password"), bcryptCost)
+	if err != nil {
+		panic(fmt.Sprintf("failed to hash dummy password: %v", err))
+	}
+	return hash
+})
+
+// loginThrottleKeys возвращает ключи счетчиков неудач для email и IP-адреса. IP может быть пустым
+func loginThrottleKeys(email, ip string) (account, address string) {
+	account = models.LoginThrottleAccount + ":" + strings.ToLower(strings.TrimSpace(email))
+	if ip != "" {
+		address = models.LoginThrottleIP + ":" + ip
+	}
+	return account, address
+}
+
+// checkLoginLock возвращает LoginLockedError, если email или IP-адрес сейчас заблокированы
+func checkLoginLock(ctx context.Context, storage *database.Storage, keys ...string) error {
+	var lookup []string
+	for _, key := range keys {
+		if key != "" {
+			lookup = append(lookup, key)
+		}
+	}
+
+	throttles, err := storage.GetLoginThrottles(ctx, lookup)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var until time.Time
+	for _, throttle := range throttles {
+		if throttle.LockedUntil != nil && throttle.LockedUntil.After(now) && throttle.LockedUntil.After(until) {
+			until = *throttle.LockedUntil
+		}
+	}
+	if !until.IsZero() {
+		return &LoginLockedError{Until: until}
+	}
+	return nil
+}
+
+// recordLoginFailure учитывает неудачную попытку. После бесплатных попыток каждая следующая неудача
+// блокирует вход на удвоенное время: 1с, 2с, 4с... но не дольше maxLock
+func recordLoginFailure(ctx context.Context, storage *database.Storage, key, kind string, freeAttempts int, maxLock time.Duration) {
+	if key == "" {
+		return
+	}
+
+	failures, err := storage.RecordLoginFailure(ctx, key, kind, loginFailureWindow)
+	if err != nil {
+		log.Printf("Failed to record login failure (key=%s): %v", key, err)
+		return
+	}
+	if failures <= freeAttempts {
+		return
+	}
+
+	lock := maxLock
+	if shift := failures - freeAttempts - 1; shift < 32 {
+		lock = min(time.Second<<shift, maxLock)
+	}
+	if err := storage.SetLoginLock(ctx, key, time.Now().Add(lock)); err != nil {
+		log.Printf("Failed to set login lock (key=%s): %v", key, err)
+		return
+	}
+	log.Printf("Login locked (key=%s, failures=%d, duration=%s)", key, failures, lock)
+}
+
+// ListLoginLockouts возвращает заблокированные email и IP-адреса и счетчики с недавними неудачами
+func ListLoginLockouts(ctx context.Context, storage *database.Storage) ([]models.LoginThrottle, error) {
+	if err := storage.DeleteStaleLoginThrottles(ctx, loginFailureWindow); err != nil {
+		log.Printf("Failed to delete stale login throttles: %v", err)
+	}
+	return storage.ListActiveLoginThrottles(ctx, loginFailureWindow)
+}
+
+// ClearLoginLockout снимает блокировку и сбрасывает счетчик по ключу вида account:<email> или ip:<адрес>
+func ClearLoginLockout(ctx context.Context, storage *database.Storage, key string) error {
+	if err := storage.DeleteLoginThrottle(ctx, key); err != nil {
+		return err
+	}
+	log.Printf("Login lockout cleared (key=%s)", key)
+	return nil
+}
@@ -0,0 +1,316 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"itam_auth/internal/database"
+	"itam_auth/internal/models"
+	"itam_auth/internal/services/jwt"
+	"itam_auth/internal/services/mfa"
+	"itam_auth/internal/services/permission"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	mfaTokenDuration = 5 * time.Minute  // Сколько действует MFA-челлендж после проверки пароля
+	maxMFAAttempts   = 5                // Сколько неверных кодов подряд допускается
+	mfaLockDuration  = 15 * time.Minute // На сколько блокируется ввод кодов после серии ошибок
+)
+
+var (
+	// ErrInvalidMFACode - неверный код TOTP или код восстановления
+	ErrInvalidMFACode = errors.New("invalid mfa code")
+	// ErrMFALocked - ввод кодов временно заблокирован из-за серии ошибок
+	ErrMFALocked = errors.New("too many invalid mfa codes, try again later")
+)
+
+// TOTPEnrollment - данные для подключения приложения-аутентификатора
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// startSession завершает первый шаг входа: выдает токены или, если нужен второй фактор, MFA-токен
+func startSession(ctx context.Context, storage *database.Storage, user models.User, policy LoginPolicy, keys *jwt.KeyRing) (TokenPair, error) {
+	state, err := storage.GetUserMFA(ctx, user.ID)
+	if err != nil && !errors.Is(err, database.ErrMFANotFound) {
+		log.Printf("Failed to get mfa state for user (email=%s, id=%s): %v", user.Email, user.ID, err)
+		return TokenPair{}, fmt.Errorf("failed to get mfa state: %w", err)
+	}
+	enabled := err == nil && state.Enabled()
+
+	enrollmentRequired := false
+	if !enabled && policy.RequireMFAForAdmins {
+		permissions, err := permission.Resolve(ctx, storage, user.ID)
+		if err != nil {
+			log.Printf("Failed to resolve permissions for user (email=%s, id=%s): %v", user.Email, user.ID, err)
+			return TokenPair{}, err
+		}
+		enrollmentRequired = len(permissions.AdminServices()) > 0
+	}
+
+	if !enabled && !enrollmentRequired {
+		return IssueTokens(ctx, storage, user, keys)
+	}
+
+	mfaToken, err := jwt.NewMFAToken(user.ID, mfaTokenDuration, keys)
+	if err != nil {
+		log.Printf("Failed to generate mfa token for user (email=%s, id=%s): %v", user.Email, user.ID, err)
+		return TokenPair{}, err
+	}
+
+	return TokenPair{
+		MFAToken:              mfaToken,
+		MFAEnrollmentRequired: enrollmentRequired,
+		ExpiresIn:             int(mfaTokenDuration.Seconds()),
+	}, nil
+}
+
+// CompleteMFALogin завершает вход вторым фактором. Если подключение было обязательным и делалось
+// прямо во время входа, код его подтверждает, и вместе с токенами возвращаются коды восстановления
+func CompleteMFALogin(ctx context.Context, storage *database.Storage, mfaToken, code string, keys *jwt.KeyRing) (TokenPair, []string, error) {
+	userID, err := jwt.ValidateMFAToken(mfaToken, keys)
+	if err != nil {
+		return TokenPair{}, nil, err
+	}
+
+	state, err := storage.GetUserMFA(ctx, userID)
+	if err != nil {
+		return TokenPair{}, nil, err
+	}
+
+	var recoveryCodes []string
+	if state.Enabled() {
+		err = verifySecondFactor(ctx, storage, state, code)
+	} else {
+		recoveryCodes, err = confirmEnrollment(ctx, storage, state, code)
+	}
+	if err != nil {
+		return TokenPair{}, nil, err
+	}
+
+	user, err := storage.GetUserByID(ctx, userID)
+	if err != nil {
+		return TokenPair{}, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	tokens, err := IssueTokens(ctx, storage, user, keys)
+	if err != nil {
+		return TokenPair{}, nil, err
+	}
+
+	log.Printf("User passed mfa (id=%s)", userID)
+	return tokens, recoveryCodes, nil
+}
+
+// BeginEnrollmentWithMFAToken начинает обязательное подключение TOTP во время входа
+func BeginEnrollmentWithMFAToken(ctx context.Context, storage *database.Storage, mfaToken string, keys *jwt.KeyRing) (TOTPEnrollment, error) {
+	userID, err := jwt.ValidateMFAToken(mfaToken, keys)
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+	return BeginTOTPEnrollment(ctx, storage, userID)
+}
+
+// BeginTOTPEnrollment создает новый секрет. Второй фактор заработает после подтверждения первым кодом
+func BeginTOTPEnrollment(ctx context.Context, storage *database.Storage, userID uuid.UUID) (TOTPEnrollment, error) {
+	user, err := storage.GetUserByID(ctx, userID)
+	if err != nil {
+		return TOTPEnrollment{}, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	secret, err := mfa.GenerateSecret()
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+
+	if err := storage.StartMFAEnrollment(ctx, userID, secret); err != nil {
+		log.Printf("Failed to start mfa enrollment (id=%s): %v", userID, err)
+		return TOTPEnrollment{}, err
+	}
+
+	account := user.Email
+	if account == "" {
+		account = user.Name
+	}
+
+	log.Printf("MFA enrollment started (id=%s)", userID)
+	return TOTPEnrollment{Secret: secret, URI: mfa.URI(account, secret)}, nil
+}
+
+// ConfirmTOTPEnrollment включает второй фактор и возвращает коды восстановления
+func ConfirmTOTPEnrollment(ctx context.Context, storage *database.Storage, userID uuid.UUID, code string) ([]string, error) {
+	state, err := storage.GetUserMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if state.Enabled() {
+		return nil, database.ErrMFAAlreadyEnabled
+	}
+	return confirmEnrollment(ctx, storage, state, code)
+}
+
+// RegenerateRecoveryCodes заменяет коды восстановления. Нужен действующий код TOTP
+func RegenerateRecoveryCodes(ctx context.Context, storage *database.Storage, userID uuid.UUID, code string) ([]string, error) {
+	state, err := storage.GetUserMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !state.Enabled() {
+		return nil, database.ErrMFANotFound
+	}
+	if err := verifyTOTP(ctx, storage, state, code); err != nil {
+		return nil, err
+	}
+
+	codes, records, err := newRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.ReplaceRecoveryCodes(ctx, userID, records); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Recovery codes regenerated (id=%s)", userID)
+	return codes, nil
+}
+
+// DisableTOTP отключает второй фактор. Подтверждается кодом TOTP или кодом восстановления
+func DisableTOTP(ctx context.Context, storage *database.Storage, userID uuid.UUID, code string) error {
+	state, err := storage.GetUserMFA(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if state.Enabled() {
+		if err := verifySecondFactor(ctx, storage, state, code); err != nil {
+			return err
+		}
+	}
+
+	if err := storage.DeleteUserMFA(ctx, userID); err != nil {
+		log.Printf("Failed to disable mfa (id=%s): %v", userID, err)
+		return err
+	}
+
+	log.Printf("MFA disabled (id=%s)", userID)
+	return nil
+}
+
+// MFAStatus сообщает, включен ли второй фактор и сколько осталось кодов восстановления
+func MFAStatus(ctx context.Context, storage *database.Storage, userID uuid.UUID) (bool, int, error) {
+	state, err := storage.GetUserMFA(ctx, userID)
+	if errors.Is(err, database.ErrMFANotFound) {
+		return false, 0, nil
+	}
+	if err != nil {
+		return false, 0, err
+	}
+	if !state.Enabled() {
+		return false, 0, nil
+	}
+
+	left, err := storage.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		return false, 0, err
+	}
+	return true, left, nil
+}
+
+// verifySecondFactor принимает код TOTP или, если он не подошел, код восстановления
+func verifySecondFactor(ctx context.Context, storage *database.Storage, state models.UserMFA, code string) error {
+	if isLocked(state) {
+		return ErrMFALocked
+	}
+
+	if step, ok := mfa.Validate(state.TOTPSecret, code, time.Now()); ok {
+		if err := storage.AcceptTOTPStep(ctx, state.UserID, step); err != nil {
+			return rejectCode(ctx, storage, state, err)
+		}
+		return nil
+	}
+
+	if err := storage.UseRecoveryCode(ctx, state.UserID, mfa.HashRecoveryCode(code)); err != nil {
+		return rejectCode(ctx, storage, state, err)
+	}
+	if err := storage.ResetMFAFailures(ctx, state.UserID); err != nil {
+		log.Printf("Failed to reset mfa failures (id=%s): %v", state.UserID, err)
+	}
+
+	log.Printf("Recovery code used (id=%s)", state.UserID)
+	return nil
+}
+
+// verifyTOTP принимает только код TOTP
+func verifyTOTP(ctx context.Context, storage *database.Storage, state models.UserMFA, code string) error {
+	if isLocked(state) {
+		return ErrMFALocked
+	}
+
+	step, ok := mfa.Validate(state.TOTPSecret, code, time.Now())
+	if !ok {
+		return rejectCode(ctx, storage, state, ErrInvalidMFACode)
+	}
+	if err := storage.AcceptTOTPStep(ctx, state.UserID, step); err != nil {
+		return rejectCode(ctx, storage, state, err)
+	}
+	return nil
+}
+
+func confirmEnrollment(ctx context.Context, storage *database.Storage, state models.UserMFA, code string) ([]string, error) {
+	if isLocked(state) {
+		return nil, ErrMFALocked
+	}
+
+	step, ok := mfa.Validate(state.TOTPSecret, code, time.Now())
+	if !ok {
+		return nil, rejectCode(ctx, storage, state, ErrInvalidMFACode)
+	}
+
+	codes, records, err := newRecoveryCodes(state.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.ConfirmMFA(ctx, state.UserID, step, records); err != nil {
+		log.Printf("Failed to confirm mfa enrollment (id=%s): %v", state.UserID, err)
+		return nil, err
+	}
+
+	log.Printf("MFA enabled (id=%s)", state.UserID)
+	return codes, nil
+}
+
+// rejectCode учитывает неудачную попытку и возвращает ошибку для клиента
+func rejectCode(ctx context.Context, storage *database.Storage, state models.UserMFA, cause error) error {
+	log.Printf("Invalid mfa code (id=%s): %v", state.UserID, cause)
+	if err := storage.RecordMFAFailure(ctx, state.UserID, maxMFAAttempts, time.Now().Add(mfaLockDuration)); err != nil {
+		log.Printf("Failed to record mfa failure (id=%s): %v", state.UserID, err)
+	}
+	return ErrInvalidMFACode
+}
+
+func isLocked(state models.UserMFA) bool {
+	return state.LockedUntil != nil && time.Now().Before(*state.LockedUntil)
+}
+
+func newRecoveryCodes(userID uuid.UUID) ([]string, []models.RecoveryCode, error) {
+	codes, hashes, err := mfa.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	records := make([]models.RecoveryCode, len(hashes))
+	for i, hash := range hashes {
+		records[i] = models.RecoveryCode{
+			ID:        uuid.New(),
+			UserID:    userID,
+			CodeHash:  hash,
+			CreatedAt: now,
+		}
+	}
+	return codes, records, nil
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"itam_auth/internal/database"
+	"itam_auth/internal/models"
+	"itam_auth/internal/services/jwt"
+	"itam_auth/internal/services/revocation"
+	"log"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvalidCurrentPassword - текущий пароль указан неверно
+	ErrInvalidCurrentPassword = errors.New("current password is incorrect")
+	// ErrNoPassword - пользователь входит без пароля (через Telegram), менять нечего
+	ErrNoPassword = errors.New("user has no password")
+)
+
+// ChangePassword меняет пароль после проверки текущего. Все выданные ранее токены пользователя
+// отзываются, а текущей сессии выдается новая пара, чтобы она продолжила работать.
+// Неверный текущий пароль учитывается в том же счетчике неудач, что и вход по email, поэтому
+// украденным access-токеном нельзя подбирать пароль (LoginLockedError)
+func ChangePassword(ctx context.Context, storage *database.Storage, revocations *revocation.Store, userID uuid.UUID, currentPassword, newPassword string, keys *jwt.KeyRing) (TokenPair, error) {
+	user, err := storage.GetUserByID(ctx, userID)
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.PasswordHash == "" {
+		return TokenPair{}, ErrNoPassword
+	}
+
+	accountKey, _ := loginThrottleKeys(user.Email, "")
+	if err := checkLoginLock(ctx, storage, accountKey); err != nil {
+		log.Printf("Password change rejected (id=%s): %v", userID, err)
+		return TokenPair{}, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(currentPassword)); err != nil {
+		log.Printf("Invalid current password on password change (id=%s)", userID)
+		recordLoginFailure(ctx, storage, accountKey, models.LoginThrottleAccount, accountFreeAttempts, accountMaxLock)
+		return TokenPair{}, ErrInvalidCurrentPassword
+	}
+
+	if err := storage.DeleteLoginThrottle(ctx, accountKey); err != nil && !errors.Is(err, database.ErrLoginThrottleNotFound) {
+		log.Printf("Failed to reset login failures (id=%s): %v", userID, err)
+	}
+
+	if err := validatePassword(newPassword); err != nil {
+		return TokenPair{}, fmt.Errorf("invalid password: %w", err)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcryptCost)
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if err := storage.UpdatePassword(ctx, userID, string(hashedPassword)); err != nil {
+		log.Printf("Failed to update password (id=%s): %v", userID, err)
+		return TokenPair{}, err
+	}
+
+	if err := revocations.RevokeAllUserTokens(ctx, userID); err != nil {
+		log.Printf("Failed to revoke tokens after password change (id=%s): %v", userID, err)
+		return TokenPair{}, fmt.Errorf("failed to revoke tokens: %w", err)
+	}
+
+	tokens, err := IssueTokens(ctx, storage, user, keys)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	log.Printf("Password changed (id=%s)", userID)
+	return tokens, nil
+}
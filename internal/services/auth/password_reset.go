@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"itam_auth/internal/database"
+	"itam_auth/internal/models"
+	"itam_auth/internal/services/mailer"
+	"itam_auth/internal/services/revocation"
+	"log"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const passwordResetDuration = time.Hour // Сколько действует ссылка сброса пароля
+
+// PasswordReset - как отправлять письма сброса пароля
+type PasswordReset struct {
+	Mailer  mailer.Mailer
+	LinkURL string // Страница фронтенда, токен добавляется в параметр token
+}
+
+// RequestPasswordReset отправляет ссылку сброса пароля, если пользователь с таким email есть.
+// Для несуществующего адреса ничего не происходит и ошибка не возвращается. Письмо уходит в фоне,
+// чтобы по времени ответа (ожиданию почтового сервера) нельзя было понять, зарегистрирован ли адрес
+func RequestPasswordReset(ctx context.Context, storage *database.Storage, reset PasswordReset, email string) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return nil
+	}
+
+	user, err := storage.GetUserByEmail(ctx, email)
+	if err != nil {
+		log.Printf("Password reset requested for unknown email (email=%s)", email)
+		return nil
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate password reset token: %w", err)
+	}
+
+	now := time.Now()
+	err = storage.SavePasswordResetToken(ctx, models.PasswordResetToken{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: now.Add(passwordResetDuration),
+		CreatedAt: now,
+	})
+	if err != nil {
+		log.Printf("Failed to save password reset token (email=%s, id=%s): %v", user.Email, user.ID, err)
+		return err
+	}
+	if err := storage.DeleteExpiredPasswordResetTokens(ctx); err != nil {
+		log.Printf("Failed to delete expired password reset tokens: %v", err)
+	}
+
+	link, err := withQuery(reset.LinkURL, "token", token)
+	if err != nil {
+		return err
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Сброс пароля в ITaM",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы задать новый пароль, перейдите по ссылке:\n%s\n\n"+
+			"Ссылка действует %d минут и сработает один раз. Если вы не запрашивали сброс пароля, "+
+			"просто проигнорируйте это письмо - пароль останется прежним.\n",
+			user.Name, link, int(passwordResetDuration.Minutes())),
+	}
+	go func() {
+		if err := reset.Mailer.Send(context.WithoutCancel(ctx), msg); err != nil {
+			log.Printf("Failed to send password reset email (email=%s, id=%s): %v", user.Email, user.ID, err)
+			return
+		}
+		log.Printf("Password reset email sent (email=%s, id=%s)", user.Email, user.ID)
+	}()
+	return nil
+}
+
+// ResetPassword задает новый пароль по токену из письма и отзывает все сессии пользователя
+func ResetPassword(ctx context.Context, storage *database.Storage, revocations *revocation.Store, token, password string) error {
+	if err := validatePassword(password); err != nil {
+		return fmt.Errorf("invalid password: %w", err)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	userID, err := storage.ResetPassword(ctx, hashToken(strings.TrimSpace(token)), string(hashedPassword))
+	if err != nil {
+		return err
+	}
+
+	if err := revocations.RevokeAllUserTokens(ctx, userID); err != nil {
+		log.Printf("Failed to revoke tokens after password reset (id=%s): %v", userID, err)
+		return fmt.Errorf("failed to revoke tokens: %w", err)
+	}
+
+	log.Printf("Password reset (id=%s)", userID)
+	return nil
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"itam_auth/internal/database"
+	"itam_auth/internal/models"
+	"itam_auth/internal/services/jwt"
+	"itam_auth/internal/services/permission"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// PersonalTokenType - typ в claims запроса с персональным токеном
+	PersonalTokenType = "personal"
+	// PersonalTokenPrefix отличает персональные токены от JWT и помогает сканерам секретов находить их в коде
+	PersonalTokenPrefix = "itam_pat_"
+
+	defaultPersonalTokenDays   = 90
+	maxPersonalTokenDays       = 365
+	maxPersonalTokens          = 50 // Действующих токенов у одного пользователя
+	maxPersonalTokenNameLength = 100
+)
+
+var (
+	// ErrInvalidPersonalToken - токена нет, он отозван, истек или его владелец удален
+	ErrInvalidPersonalToken = errors.New("invalid or expired personal access token")
+	// ErrInvalidPersonalTokenRequest - неверное название, срок или scope нового токена
+	ErrInvalidPersonalTokenRequest = errors.New("invalid personal access token request")
+	// ErrTooManyPersonalTokens - у пользователя уже maxPersonalTokens действующих токенов
+	ErrTooManyPersonalTokens = errors.New("too many personal access tokens")
+)
+
+// NewPersonalToken - параметры нового персонального токена
+type NewPersonalToken struct {
+	Name string
+	// Scopes - права пользователя, которые получит токен. Пустой список - доступ без прав
+	Scopes []string
+	// ExpiresInDays - срок действия; 0 - значение по умолчанию
+	ExpiresInDays int
+}
+
+// IsPersonalToken сообщает, что токен выглядит как персональный, а не как JWT
+func IsPersonalToken(token string) bool {
+	return strings.HasPrefix(token, PersonalTokenPrefix)
+}
+
+// CreatePersonalToken выпускает персональный токен. Scope должны быть среди текущих прав пользователя.
+// Сам токен возвращается только здесь: в базе хранится его SHA-256
+func CreatePersonalToken(ctx context.Context, storage *database.Storage, userID uuid.UUID, req NewPersonalToken) (string, models.PersonalAccessToken, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len([]rune(name)) > maxPersonalTokenNameLength {
+		return "", models.PersonalAccessToken{}, fmt.Errorf("%w: name must be 1-%d characters long", ErrInvalidPersonalTokenRequest, maxPersonalTokenNameLength)
+	}
+
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = defaultPersonalTokenDays
+	}
+	if days < 0 || days > maxPersonalTokenDays {
+		return "", models.PersonalAccessToken{}, fmt.Errorf("%w: expiry must be 1-%d days", ErrInvalidPersonalTokenRequest, maxPersonalTokenDays)
+	}
+
+	permissions, err := permission.Resolve(ctx, storage, userID)
+	if err != nil {
+		return "", models.PersonalAccessToken{}, err
+	}
+	scopes := slices.Compact(slices.Sorted(slices.Values(req.Scopes)))
+	if scopes == nil {
+		scopes = []string{}
+	}
+	for _, scope := range scopes {
+		if !permissions.Has(scope) {
+			return "", models.PersonalAccessToken{}, fmt.Errorf("%w: user has no permission %s", ErrInvalidPersonalTokenRequest, scope)
+		}
+	}
+
+	count, err := storage.CountActivePersonalTokens(ctx, userID)
+	if err != nil {
+		return "", models.PersonalAccessToken{}, err
+	}
+	if count >= maxPersonalTokens {
+		return "", models.PersonalAccessToken{}, fmt.Errorf("%w: at most %d active tokens are allowed", ErrTooManyPersonalTokens, maxPersonalTokens)
+	}
+
+	secret, err := randomToken()
+	if err != nil {
+		return "", models.PersonalAccessToken{}, fmt.Errorf("failed to generate personal access token: %w", err)
+	}
+	token := PersonalTokenPrefix + secret
+
+	now := time.Now()
+	record := models.PersonalAccessToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashToken(token),
+		Scopes:    scopes,
+		ExpiresAt: now.AddDate(0, 0, days),
+		CreatedAt: now,
+	}
+	if err := storage.SavePersonalToken(ctx, record); err != nil {
+		return "", models.PersonalAccessToken{}, err
+	}
+
+	log.Printf("Personal access token created (user_id=%s, token_id=%s, scopes=%v)", userID, record.ID, scopes)
+	return token, record, nil
+}
+
+// AuthenticatePersonalToken проверяет персональный токен и отмечает его использование
+func AuthenticatePersonalToken(ctx context.Context, storage *database.Storage, token, ip string) (models.PersonalAccessToken, models.User, error) {
+	record, err := storage.GetPersonalTokenByHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, database.ErrPersonalTokenNotFound) {
+			return models.PersonalAccessToken{}, models.User{}, ErrInvalidPersonalToken
+		}
+		return models.PersonalAccessToken{}, models.User{}, err
+	}
+	now := time.Now()
+	if record.RevokedAt != nil || now.After(record.ExpiresAt) {
+		return models.PersonalAccessToken{}, models.User{}, ErrInvalidPersonalToken
+	}
+
+	user, err := storage.GetUserByID(ctx, record.UserID)
+	if err != nil {
+		if errors.Is(err, database.ErrUserNotFound) {
+			return models.PersonalAccessToken{}, models.User{}, ErrInvalidPersonalToken
+		}
+		return models.PersonalAccessToken{}, models.User{}, err
+	}
+	user.PasswordHash = ""
+
+	// Отметка об использовании не должна мешать запросу
+	if err := storage.TouchPersonalToken(ctx, record.ID, now, ip); err != nil {
+		log.Printf("Failed to update personal access token usage (token_id=%s): %v", record.ID, err)
+	}
+	return record, user, nil
+}
+
+// PersonalTokenClaims возвращает claims запроса с персональным токеном: как у access-токена владельца,
+// но с typ personal, scope токена и jti - идентификатором токена
+func PersonalTokenClaims(token models.PersonalAccessToken, user models.User) *jwt.Claims {
+	claims := &jwt.Claims{
+		UID:       user.ID.String(),
+		Email:     user.Email,
+		Name:      user.Name,
+		Scope:     strings.Join(token.Scopes, " "),
+		TokenType: PersonalTokenType,
+	}
+	claims.ID = token.ID.String()
+	claims.Subject = user.ID.String()
+	return claims
+}
+
+// PersonalTokenPermissions возвращает права запроса с персональным токеном - пересечение scope токена
+// с текущими правами владельца
+func PersonalTokenPermissions(ctx context.Context, storage *database.Storage, token models.PersonalAccessToken) (permission.Set, error) {
+	permissions, err := permission.Resolve(ctx, storage, token.UserID)
+	if err != nil {
+		return permission.Set{}, err
+	}
+	return permissions.Restrict(token.Scopes), nil
+}
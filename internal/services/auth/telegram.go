@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"itam_auth/internal/database"
+	"itam_auth/internal/models"
+	"itam_auth/internal/services/jwt"
+	"itam_auth/internal/utils"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TelegramAuth - данные, которые виджет входа Telegram передает после авторизации пользователя
+type TelegramAuth struct {
+	ID        int64  `json:"id" binding:"required" example:"123456789"`
+	FirstName string `json:"first_name" example:"John"`
+	LastName  string `json:"last_name" example:"Doe"`
+	Username  string `json:"username" example:"johndoe"`
+	PhotoURL  string `json:"photo_url" example:"https://t.me/i/userpic/320/johndoe.jpg"`
+	AuthDate  int64  `json:"auth_date" binding:"required" example:"1700000000"`
+	Hash      string `json:"hash" binding:"required" example:"c9f3..."`
+}
+
+// values собирает поля в том виде, в каком их подписал Telegram: пустые поля в подпись не входят
+func (t TelegramAuth) values() url.Values {
+	data := url.Values{}
+	data.Set("id", strconv.FormatInt(t.ID, 10))
+	data.Set("auth_date", strconv.FormatInt(t.AuthDate, 10))
+	data.Set("hash", t.Hash)
+	optional := map[string]string{
+		"first_name": t.FirstName,
+		"last_name":  t.LastName,
+		"username":   t.Username,
+		"photo_url":  t.PhotoURL,
+	}
+	for key, value := range optional {
+		if value != "" {
+			data.Set(key, value)
+		}
+	}
+	return data
+}
+
+// verifyTelegramAuth проверяет подпись данных виджета токеном бота и то, что они не старше ttl
+func verifyTelegramAuth(data TelegramAuth, botToken string, ttl time.Duration) error {
+	if botToken == "" {
+		return fmt.Errorf("telegram login is not configured")
+	}
+	if !utils.ValidateTelegramAuth(data.values(), botToken) {
+		return fmt.Errorf("invalid telegram signature")
+	}
+
+	authDate := time.Unix(data.AuthDate, 0)
+	if time.Since(authDate) > ttl {
+		return fmt.Errorf("telegram auth data has expired")
+	}
+	if time.Until(authDate) > time.Minute {
+		return fmt.Errorf("telegram auth_date is in the future")
+	}
+	return nil
+}
+
+// AuthenticateTelegram впускает пользователя, привязавшего этот аккаунт Telegram. Если такого
+// пользователя нет, он создается по данным из Telegram
+func AuthenticateTelegram(ctx context.Context, storage *database.Storage, data TelegramAuth, botToken string, ttl time.Duration, policy LoginPolicy, keys *jwt.KeyRing) (TokenPair, error) {
+	if err := verifyTelegramAuth(data, botToken, ttl); err != nil {
+		log.Printf("Telegram auth verification failed (telegram_id=%d): %v", data.ID, err)
+		return TokenPair{}, err
+	}
+
+	user, err := storage.GetUserByTelegramID(ctx, data.ID)
+	if errors.Is(err, database.ErrUserNotFound) {
+		user, err = registerTelegramUser(ctx, storage, data)
+	}
+	if err != nil {
+		log.Printf("Failed to get user by telegram ID (telegram_id=%d): %v", data.ID, err)
+		return TokenPair{}, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	tokens, err := startSession(ctx, storage, user, policy, keys)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	log.Printf("User authenticated via telegram (telegram_id=%d, id=%s, mfa_required=%t)", data.ID, user.ID, tokens.MFAToken != "")
+	return tokens, nil
+}
+
+func registerTelegramUser(ctx context.Context, storage *database.Storage, data TelegramAuth) (models.User, error) {
+	name := strings.TrimSpace(data.FirstName + " " + data.LastName)
+	if name == "" {
+		name = data.Username
+	}
+	if name == "" {
+		name = strconv.FormatInt(data.ID, 10)
+	}
+
+	telegramID := data.ID
+	user := models.User{
+		ID:            uuid.New(),
+		Name:          name,
+		TelegramID:    &telegramID,
+		Specification: defaultUserSpecification,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	if data.Username != "" {
+		username := "@" + data.Username
+		user.Telegram = &username
+	}
+	if data.PhotoURL != "" {
+		user.PhotoURL = &data.PhotoURL
+	}
+
+	if _, err := storage.SaveUser(ctx, user); err != nil {
+		log.Printf("Failed to save telegram user (telegram_id=%d): %v", data.ID, err)
+		return models.User{}, fmt.Errorf("failed to save user: %w", err)
+	}
+
+	if err := assignDefaultRole(ctx, storage, user); err != nil {
+		return models.User{}, err
+	}
+
+	log.Printf("User registered via telegram (telegram_id=%d, id=%s)", data.ID, user.ID)
+	return user, nil
+}
+
+// LinkTelegram привязывает аккаунт Telegram к уже вошедшему пользователю
+func LinkTelegram(ctx context.Context, storage *database.Storage, userID uuid.UUID, data TelegramAuth, botToken string, ttl time.Duration) error {
+	if err := verifyTelegramAuth(data, botToken, ttl); err != nil {
+		log.Printf("Telegram auth verification failed (telegram_id=%d, id=%s): %v", data.ID, userID, err)
+		return err
+	}
+
+	var username *string
+	if data.Username != "" {
+		value := "@" + data.Username
+		username = &value
+	}
+
+	if err := storage.LinkTelegram(ctx, userID, data.ID, username); err != nil {
+		log.Printf("Failed to link telegram (telegram_id=%d, id=%s): %v", data.ID, userID, err)
+		return err
+	}
+
+	log.Printf("Telegram linked (telegram_id=%d, id=%s)", data.ID, userID)
+	return nil
+}
+
+// ErrLastSignInMethod - операция оставила бы пользователя без способа входа
+var ErrLastSignInMethod = errors.New("this is the only sign-in method of this user")
+
+// UnlinkTelegram отвязывает аккаунт Telegram. Пользователю, у которого нет пароля, отвязать его нельзя
+func UnlinkTelegram(ctx context.Context, storage *database.Storage, userID uuid.UUID) error {
+	user, err := storage.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.PasswordHash == "" || user.Email == "" {
+		passkeys, err := storage.CountWebAuthnCredentials(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if passkeys == 0 {
+			return ErrLastSignInMethod
+		}
+	}
+
+	if err := storage.UnlinkTelegram(ctx, userID); err != nil {
+		log.Printf("Failed to unlink telegram (id=%s): %v", userID, err)
+		return err
+	}
+
+	log.Printf("Telegram unlinked (id=%s)", userID)
+	return nil
+}
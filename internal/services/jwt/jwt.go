@@ -1,37 +1,110 @@
 package jwt
 
 import (
+	"context"
 	"fmt"
 	"itam_auth/internal/models"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+const (
+	accessTokenType  = "access"
+	refreshTokenType = "refresh"
+	mfaTokenType     = "mfa"
+	emailTokenType   = "email_verification"
+)
+
+func init() {
+	// Время выпуска (iat) пишется с точностью до микросекунды, как tokens_valid_after в Postgres. С точностью
+	// до секунды токен, выпущенный сразу после отзыва всех токенов пользователя, нельзя отличить от отозванного
+	jwt.TimePrecision = time.Microsecond
+}
+
+// Claims - содержимое access-токена. Токен пользователя несет uid, токен сервисного аккаунта
+// вместо него - cid и выданные scope. Токен, выданный пользователем приложению через OpenID Connect,
+// несет и uid, и cid (он же aud) с выданным приложению scope. Роли, права и профиль - снимок на момент
+// выпуска токена
 type Claims struct {
-	UID           string   `json:"uid"`
-	Email         string   `json:"email"`
-	AdminServices []string `json:"admin_services"`
+	UID           string   `json:"uid,omitempty"`
+	Email         string   `json:"email,omitempty"`
+	Name          string   `json:"name,omitempty"`
+	Specification string   `json:"specification,omitempty"`
+	Roles         []string `json:"roles,omitempty"`
+	Permissions   string   `json:"perms,omitempty"` // Права через пробел, как scope
+	AdminServices []string `json:"admin_services,omitempty"`
+	ClientID      string   `json:"cid,omitempty"`
+	Scope         string   `json:"scope,omitempty"`
+	TokenType     string   `json:"typ,omitempty"`
+	// AuthTime - момент входа пользователя, с которого началась сессия; не меняется при обновлении токенов
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// RevocationChecker проверяет, не был ли токен отозван после выпуска
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, tokenID string, userID uuid.UUID, issuedAt time.Time) (bool, error)
+}
+
+// RefreshClaims содержит данные refresh-токена. ID (jti) указывает на запись
+// в таблице refresh_tokens, FamilyID - на семейство токенов одного логина.
+type RefreshClaims struct {
+	UID       string `json:"uid"`
+	FamilyID  string `json:"fid"`
+	TokenType string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+// MFAClaims - содержимое токена MFA-челленджа: пароль уже проверен, но нужен второй фактор
+type MFAClaims struct {
+	UID       string `json:"uid"`
+	TokenType string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+// EmailVerificationClaims - содержимое токена из письма подтверждения email. ID (jti) указывает
+// на запись в таблице email_verification_tokens, по ней токен принимается один раз
+type EmailVerificationClaims struct {
+	UID       string `json:"uid"`
+	Email     string `json:"email"`
+	TokenType string `json:"typ"`
 	jwt.RegisteredClaims
 }
 
-func NewToken(user models.User, duration time.Duration, hmacSecret string, userRoles []models.UserRole,
-	roles []models.Role, rolePermissions []models.RolePermission, permissions []models.Permission) (string, error) {
+// Grants - роли, права и профиль пользователя, которые попадают в access-токен. Пустые поля
+// в токен не выпускаются; что заполнять, решает вызывающий (JWT_CLAIMS)
+type Grants struct {
+	Roles         []string
+	Permissions   []string
+	AdminServices []string // Сервисы, которые пользователь администрирует
+	Name          string
+	Specification string
+}
+
+// NewToken выпускает access-токен пользователя с uid, email и данными из grants
+func NewToken(user models.User, authTime time.Time, duration time.Duration, keys *KeyRing, grants Grants) (string, error) {
 	claims := Claims{
 		UID:           user.ID.String(),
 		Email:         user.Email,
-		AdminServices: user.GetAdminServices(userRoles, roles, rolePermissions, permissions),
+		Name:          grants.Name,
+		Specification: grants.Specification,
+		Roles:         grants.Roles,
+		Permissions:   strings.Join(grants.Permissions, " "),
+		AdminServices: grants.AdminServices,
+		TokenType:     accessTokenType,
+		AuthTime:      jwt.NewNumericDate(authTime),
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	tokenString, err := token.SignedString([]byte(hmacSecret))
+	tokenString, err := keys.sign(claims)
 	if err != nil {
 		return "", err
 	}
@@ -39,49 +112,335 @@ func NewToken(user models.User, duration time.Duration, hmacSecret string, userR
 	return tokenString, nil
 }
 
-func ValidateToken(tokenString string, hmacSecret string) (models.User, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
+// NewClientToken выпускает access-токен, который пользователь выдал приложению (OIDC-клиенту). В нем нет
+// ролей, прав и профиля: приложение действует от имени пользователя только в пределах scope, а aud и cid
+// не дают предъявить токен как токен самого сервиса
+func NewClientToken(user models.User, clientID, scope string, authTime time.Time, duration time.Duration, keys *KeyRing) (string, error) {
+	claims := Claims{
+		UID:       user.ID.String(),
+		ClientID:  clientID,
+		Scope:     scope,
+		TokenType: accessTokenType,
+		AuthTime:  jwt.NewNumericDate(authTime),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Subject:   user.ID.String(),
+			Audience:  jwt.ClaimStrings{clientID},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	tokenString, err := keys.sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign client token: %w", err)
+	}
+
+	return tokenString, nil
+}
+
+// NewServiceToken выпускает access-токен сервисного аккаунта с выданными ему scope
+func NewServiceToken(clientID string, scopes []string, duration time.Duration, keys *KeyRing) (string, error) {
+	claims := Claims{
+		ClientID:  clientID,
+		Scope:     strings.Join(scopes, " "),
+		TokenType: accessTokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Subject:   clientID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	tokenString, err := keys.sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign service token: %w", err)
+	}
+
+	return tokenString, nil
+}
 
-		return []byte(hmacSecret), nil
-	})
+// ParseToken проверяет подпись и срок действия access-токена, а если передан revocations - еще и то,
+// что токен не был отозван
+func ParseToken(ctx context.Context, tokenString string, keys *KeyRing, revocations RevocationChecker) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, keys.keyFunc)
 
 	if err != nil {
 		log.Printf("Error parsing token: %v", err)
-		return models.User{}, fmt.Errorf("failed to parse token: %w", err)
+		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
 
 	if !token.Valid {
-		return models.User{}, fmt.Errorf("invalid token")
+		return nil, fmt.Errorf("invalid token")
 	}
 
 	claims, ok := token.Claims.(*Claims)
 	if !ok {
-		return models.User{}, fmt.Errorf("invalid claims")
+		return nil, fmt.Errorf("invalid claims")
 	}
 
-	var authUser models.User
-	authUser.ID = uuid.MustParse(claims.UID)
-	authUser.Email = claims.Email
-	return authUser, nil
+	// Токены, выпущенные до появления typ, считаются access-токенами
+	if claims.TokenType != "" && claims.TokenType != accessTokenType {
+		return nil, fmt.Errorf("%s token cannot be used as access token", claims.TokenType)
+	}
+
+	// Токены сервисных аккаунтов короткоживущие и не отзываются: доступ прекращается удалением клиента
+	// и истечением уже выданных токенов
+	if claims.IsService() {
+		return claims, nil
+	}
+
+	userID, err := uuid.Parse(claims.UID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID in token: %w", err)
+	}
+
+	if revocations != nil {
+		var issuedAt time.Time
+		if claims.IssuedAt != nil {
+			issuedAt = claims.IssuedAt.Time
+		}
+		revoked, err := revocations.IsRevoked(ctx, claims.ID, userID, issuedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check token revocation: %w", err)
+		}
+		if revoked {
+			return nil, fmt.Errorf("token has been revoked")
+		}
+	}
+
+	return claims, nil
 }
 
-func NewRefreshToken(user models.User, hmacSecret string, userRoles []models.UserRole,
-	roles []models.Role, rolePermissions []models.RolePermission, permissions []models.Permission) (string, error) {
-	claims := jwt.MapClaims{
-		"uid":            user.ID.String(),
-		"admin_services": user.GetAdminServices(userRoles, roles, rolePermissions, permissions),
-		"exp":            time.Now().Add(time.Hour * 24 * 7).Unix(),
+func ValidateToken(ctx context.Context, tokenString string, keys *KeyRing, revocations RevocationChecker) (models.User, error) {
+	claims, err := ParseToken(ctx, tokenString, keys, revocations)
+	if err != nil {
+		return models.User{}, err
+	}
+	if claims.IsService() {
+		return models.User{}, fmt.Errorf("token belongs to a service account, not a user")
 	}
+	return claims.User(), nil
+}
+
+// IsService сообщает, что токен выдан сервисному аккаунту, а не пользователю
+func (c *Claims) IsService() bool {
+	return c.UID == "" && c.ClientID != ""
+}
+
+// IsClientToken сообщает, что пользователь выдал токен приложению через OpenID Connect
+func (c *Claims) IsClientToken() bool {
+	return c.UID != "" && c.ClientID != ""
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+// Scopes возвращает scope, выданные сервисному аккаунту или приложению
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// HasScope проверяет, что сервисному аккаунту или приложению выдан scope
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// User возвращает пользователя, которому выдан токен, с профилем, ролями и правами из токена (если
+// они в него выпущены). Для токена сервисного аккаунта не вызывается
+func (c *Claims) User() models.User {
+	var authUser models.User
+	authUser.ID = uuid.MustParse(c.UID)
+	authUser.Email = c.Email
+	authUser.Name = c.Name
+	authUser.Specification = models.Specification(c.Specification)
+	authUser.Roles = c.Roles
+	authUser.Permissions = strings.Fields(c.Permissions)
+	return authUser
+}
+
+func NewRefreshToken(user models.User, tokenID, familyID uuid.UUID, duration time.Duration, keys *KeyRing) (string, error) {
+	claims := RefreshClaims{
+		UID:       user.ID.String(),
+		FamilyID:  familyID.String(),
+		TokenType: refreshTokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID.String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
 
-	tokenString, err := token.SignedString([]byte(hmacSecret))
+	tokenString, err := keys.sign(claims)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign refresh token: %w", err)
 	}
 
 	return tokenString, nil
 }
+
+func ValidateRefreshToken(tokenString string, keys *KeyRing) (*RefreshClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &RefreshClaims{}, keys.keyFunc)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse refresh token: %w", err)
+	}
+
+	claims, ok := token.Claims.(*RefreshClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid refresh token")
+	}
+
+	if claims.TokenType != refreshTokenType {
+		return nil, fmt.Errorf("token is not a refresh token")
+	}
+
+	if _, err := uuid.Parse(claims.ID); err != nil {
+		return nil, fmt.Errorf("invalid refresh token ID: %w", err)
+	}
+	if _, err := uuid.Parse(claims.FamilyID); err != nil {
+		return nil, fmt.Errorf("invalid refresh token family: %w", err)
+	}
+
+	return claims, nil
+}
+
+func NewMFAToken(userID uuid.UUID, duration time.Duration, keys *KeyRing) (string, error) {
+	claims := MFAClaims{
+		UID:       userID.String(),
+		TokenType: mfaTokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	tokenString, err := keys.sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign mfa token: %w", err)
+	}
+
+	return tokenString, nil
+}
+
+// ValidateMFAToken проверяет токен MFA-челленджа и возвращает ID пользователя
+func ValidateMFAToken(tokenString string, keys *KeyRing) (uuid.UUID, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &MFAClaims{}, keys.keyFunc)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to parse mfa token: %w", err)
+	}
+
+	claims, ok := token.Claims.(*MFAClaims)
+	if !ok || !token.Valid {
+		return uuid.Nil, fmt.Errorf("invalid mfa token")
+	}
+
+	if claims.TokenType != mfaTokenType {
+		return uuid.Nil, fmt.Errorf("token is not an mfa token")
+	}
+
+	userID, err := uuid.Parse(claims.UID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid user ID in mfa token: %w", err)
+	}
+
+	return userID, nil
+}
+
+func NewEmailVerificationToken(userID uuid.UUID, email string, tokenID uuid.UUID, duration time.Duration, keys *KeyRing) (string, error) {
+	claims := EmailVerificationClaims{
+		UID:       userID.String(),
+		Email:     email,
+		TokenType: emailTokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID.String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	tokenString, err := keys.sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign email verification token: %w", err)
+	}
+
+	return tokenString, nil
+}
+
+// ValidateEmailVerificationToken проверяет подпись и срок токена подтверждения email.
+// Одноразовость проверяется по jti в базе
+func ValidateEmailVerificationToken(tokenString string, keys *KeyRing) (*EmailVerificationClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &EmailVerificationClaims{}, keys.keyFunc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse email verification token: %w", err)
+	}
+
+	claims, ok := token.Claims.(*EmailVerificationClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid email verification token")
+	}
+
+	if claims.TokenType != emailTokenType {
+		return nil, fmt.Errorf("token is not an email verification token")
+	}
+	if _, err := uuid.Parse(claims.UID); err != nil {
+		return nil, fmt.Errorf("invalid user ID in email verification token: %w", err)
+	}
+	if _, err := uuid.Parse(claims.ID); err != nil {
+		return nil, fmt.Errorf("invalid email verification token ID: %w", err)
+	}
+
+	return claims, nil
+}
+
+// IDToken описывает содержимое ID-токена OpenID Connect. Пустые поля профиля в токен не попадают
+type IDToken struct {
+	Issuer        string
+	Subject       string
+	Audience      string
+	Nonce         string
+	AuthTime      time.Time
+	Name          string
+	Email         string
+	Specification string
+	Picture       string
+}
+
+type idTokenClaims struct {
+	Name          string           `json:"name,omitempty"`
+	Email         string           `json:"email,omitempty"`
+	Specification string           `json:"specification,omitempty"`
+	Picture       string           `json:"picture,omitempty"`
+	Nonce         string           `json:"nonce,omitempty"`
+	AuthTime      *jwt.NumericDate `json:"auth_time,omitempty"`
+	jwt.RegisteredClaims
+}
+
+func NewIDToken(idToken IDToken, duration time.Duration, keys *KeyRing) (string, error) {
+	claims := idTokenClaims{
+		Name:          idToken.Name,
+		Email:         idToken.Email,
+		Specification: idToken.Specification,
+		Picture:       idToken.Picture,
+		Nonce:         idToken.Nonce,
+		AuthTime:      jwt.NewNumericDate(idToken.AuthTime),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    idToken.Issuer,
+			Subject:   idToken.Subject,
+			Audience:  jwt.ClaimStrings{idToken.Audience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	tokenString, err := keys.sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign ID token: %w", err)
+	}
+
+	return tokenString, nil
+}
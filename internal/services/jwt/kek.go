@@ -0,0 +1,91 @@
+package jwt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"itam_auth/internal/models"
+	"strings"
+)
+
+// encryptedKeyPrefix отмечает закрытые ключи в signing_keys, зашифрованные ключом шифрования (KEK)
+const encryptedKeyPrefix = "enc:v1:"
+
+// ParseKeyEncryptionKey разбирает JWT_KEY_ENCRYPTION_KEY: 32 байта в base64. Пустое значение - шифрование выключено
+func ParseKeyEncryptionKey(value string) ([]byte, error) {
+	if value == "" {
+		return nil, nil
+	}
+	kek, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode JWT_KEY_ENCRYPTION_KEY: %w", err)
+	}
+	if len(kek) != 32 {
+		return nil, fmt.Errorf("JWT_KEY_ENCRYPTION_KEY must be 32 bytes, got %d", len(kek))
+	}
+	return kek, nil
+}
+
+// IsEncryptedSigningKey сообщает, что закрытый ключ записи зашифрован
+func IsEncryptedSigningKey(record models.SigningKey) bool {
+	return strings.HasPrefix(record.PrivateKey, encryptedKeyPrefix)
+}
+
+// EncryptSigningKey шифрует закрытый ключ записи AES-256-GCM. kid входит в дополнительные данные,
+// поэтому зашифрованный ключ нельзя переставить в другую запись
+func EncryptSigningKey(record models.SigningKey, kek []byte) (models.SigningKey, error) {
+	if IsEncryptedSigningKey(record) {
+		return record, nil
+	}
+	aead, err := newKeyCipher(kek)
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return models.SigningKey{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(record.PrivateKey), []byte(record.ID))
+	record.PrivateKey = encryptedKeyPrefix + base64.StdEncoding.EncodeToString(sealed)
+	return record, nil
+}
+
+// DecryptSigningKey расшифровывает закрытый ключ записи. Незашифрованные записи возвращаются как есть
+func DecryptSigningKey(record models.SigningKey, kek []byte) (models.SigningKey, error) {
+	if !IsEncryptedSigningKey(record) {
+		return record, nil
+	}
+	if kek == nil {
+		return models.SigningKey{}, fmt.Errorf("key %s is encrypted, but JWT_KEY_ENCRYPTION_KEY is not set", record.ID)
+	}
+	aead, err := newKeyCipher(kek)
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(record.PrivateKey, encryptedKeyPrefix))
+	if err != nil {
+		return models.SigningKey{}, fmt.Errorf("failed to decode encrypted key %s: %w", record.ID, err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return models.SigningKey{}, fmt.Errorf("encrypted key %s is too short", record.ID)
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, []byte(record.ID))
+	if err != nil {
+		return models.SigningKey{}, fmt.Errorf("failed to decrypt key %s: wrong JWT_KEY_ENCRYPTION_KEY?", record.ID)
+	}
+	record.PrivateKey = string(plain)
+	return record, nil
+}
+
+func newKeyCipher(kek []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create key cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,266 @@
+package jwt
+
+import (
+	"context"
+	"fmt"
+	"itam_auth/internal/config"
+	"itam_auth/internal/models"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeyLoader загружает из хранилища все ключи, кроме выведенных из обращения
+type KeyLoader func(ctx context.Context) ([]models.SigningKey, error)
+
+// KeyRing - связка ключей подписи. Новые токены подписываются активным ключом, а проверяются
+// любым ключом, который еще не выведен из обращения, поэтому смена ключа не разлогинивает пользователей.
+//
+// Ключи из конфигурации (JWT_SECRET_KEY, JWT_PRIVATE_KEY_PATH) подписывают токены, только пока в таблице
+// signing_keys нет активного ключа, а после этого только проверяют их. JWT_CONFIG_KEY_STATE выводит их
+// из обращения так же, как ключи из таблицы: retiring - только проверка, retired - ключи не используются.
+type KeyRing struct {
+	mu      sync.RWMutex
+	signing *SigningKey
+	keys    map[string]*SigningKey
+
+	// Ключи из конфигурации
+	staticSigning *SigningKey
+	static        []*SigningKey
+	// legacy проверяет токены без kid, выпущенные до появления идентификаторов ключей, до legacyUntil
+	legacy      *SigningKey
+	legacyUntil time.Time
+	// kek расшифровывает закрытые ключи из хранилища (JWT_KEY_ENCRYPTION_KEY)
+	kek []byte
+}
+
+// NewKeyRing собирает связку из ключей конфигурации. signing может быть nil, если токены подписывает
+// только ключ из хранилища
+func NewKeyRing(signing *SigningKey, verifyOnly ...*SigningKey) *KeyRing {
+	static := verifyOnly
+	if signing != nil {
+		static = append([]*SigningKey{signing}, verifyOnly...)
+	}
+	r := &KeyRing{
+		staticSigning: signing,
+		static:        static,
+	}
+	r.rebuild(nil)
+	return r
+}
+
+// LoadKeyRing собирает связку ключей из конфигурации. При асимметричной подписи JWT_SECRET_KEY,
+// если он задан, остается ключом проверки, чтобы ранее выданные HS256-токены продолжали работать.
+// Ключи конфигурации необязательны, если токены подписывает активный ключ из signing_keys: без
+// подписывающего ключа Reload вернет ошибку.
+func LoadKeyRing(cfg *config.AppConfig) (*KeyRing, error) {
+	state := models.KeyState(cfg.JwtConfigKeyState)
+	switch state {
+	case "":
+		state = models.KeyStateActive
+	case models.KeyStateActive, models.KeyStateRetiring, models.KeyStateRetired:
+	default:
+		return nil, fmt.Errorf("unsupported JWT_CONFIG_KEY_STATE: %s (expected active, retiring or retired)", state)
+	}
+
+	var legacyUntil time.Time
+	if cfg.JwtLegacyTokensUntil != "" {
+		var err error
+		if legacyUntil, err = time.Parse(time.RFC3339, cfg.JwtLegacyTokensUntil); err != nil {
+			return nil, fmt.Errorf("invalid JWT_LEGACY_TOKENS_UNTIL (expected RFC 3339 time): %w", err)
+		}
+	}
+
+	var signing, hmacKey *SigningKey
+	var verifyOnly []*SigningKey
+	if state != models.KeyStateRetired {
+		if cfg.JwtSecretKey != "" {
+			hmacKey = NewHMACKey("", []byte(cfg.JwtSecretKey))
+		}
+
+		switch cfg.JwtSigningAlg {
+		case AlgHS256:
+			if hmacKey != nil && cfg.JwtKeyID != "" {
+				hmacKey.ID = cfg.JwtKeyID
+			}
+			signing = hmacKey
+		case AlgRS256, AlgEdDSA:
+			if cfg.JwtPrivateKeyPath != "" {
+				key, err := LoadPrivateKeyFile(cfg.JwtKeyID, cfg.JwtPrivateKeyPath)
+				if err != nil {
+					return nil, err
+				}
+				if key.Method.Alg() != cfg.JwtSigningAlg {
+					return nil, fmt.Errorf("private key is %s, but JWT_SIGNING_ALG is %s", key.Method.Alg(), cfg.JwtSigningAlg)
+				}
+				signing = key
+			}
+			if hmacKey != nil {
+				verifyOnly = append(verifyOnly, hmacKey)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported JWT_SIGNING_ALG: %s", cfg.JwtSigningAlg)
+		}
+
+		if state == models.KeyStateRetiring && signing != nil {
+			verifyOnly = append(verifyOnly, signing)
+			signing = nil
+		}
+	}
+
+	r := NewKeyRing(signing, verifyOnly...)
+	r.legacy = hmacKey
+	r.legacyUntil = legacyUntil
+
+	kek, err := ParseKeyEncryptionKey(cfg.JwtKeyEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	r.kek = kek
+	return r, nil
+}
+
+// Reload заменяет ключи из хранилища на актуальные. Ожидающие ключи только публикуются и проверяют токены
+func (r *KeyRing) Reload(ctx context.Context, load KeyLoader) error {
+	records, err := load(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load signing keys: %w", err)
+	}
+
+	var stored []*SigningKey
+	var active *SigningKey
+	for _, record := range records {
+		if record.State == models.KeyStateRetired {
+			continue
+		}
+		record, err := DecryptSigningKey(record, r.kek)
+		if err != nil {
+			return err
+		}
+		key, err := ParseSigningKeyRecord(record)
+		if err != nil {
+			return err
+		}
+		stored = append(stored, key)
+		if record.State == models.KeyStateActive {
+			active = key
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if active == nil && r.staticSigning == nil {
+		return fmt.Errorf("no signing key: set JWT_SECRET_KEY or JWT_PRIVATE_KEY_PATH, or promote a key with cmd/keys")
+	}
+	r.rebuild(stored)
+	if active != nil {
+		r.signing = active
+	}
+	return nil
+}
+
+// Watch периодически перечитывает ключи, чтобы ротация, сделанная через cmd/keys, дошла до всех экземпляров сервиса
+func (r *KeyRing) Watch(ctx context.Context, load KeyLoader, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Reload(ctx, load); err != nil {
+				log.Printf("Failed to reload signing keys: %v", err)
+			}
+		}
+	}
+}
+
+// rebuild собирает связку из ключей конфигурации и переданных ключей хранилища. Вызывается под r.mu
+func (r *KeyRing) rebuild(stored []*SigningKey) {
+	keys := make(map[string]*SigningKey, len(r.static)+len(stored))
+	for _, key := range r.static {
+		keys[key.ID] = key
+	}
+	for _, key := range stored {
+		keys[key.ID] = key
+	}
+	r.keys = keys
+	r.signing = r.staticSigning
+}
+
+// SigningKeyID возвращает идентификатор ключа, которым сейчас подписываются токены
+func (r *KeyRing) SigningKeyID() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.signing == nil {
+		return ""
+	}
+	return r.signing.ID
+}
+
+// SigningAlg возвращает алгоритм, которым сейчас подписываются токены
+func (r *KeyRing) SigningAlg() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.signing == nil {
+		return ""
+	}
+	return r.signing.Method.Alg()
+}
+
+// JWKS возвращает публичные ключи, которыми можно проверить выданные токены
+func (r *KeyRing) JWKS() JWKS {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range r.keys {
+		if jwk, ok := key.PublicJWK(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+func (r *KeyRing) sign(claims jwt.Claims) (string, error) {
+	r.mu.RLock()
+	signing := r.signing
+	r.mu.RUnlock()
+	if signing == nil {
+		return "", fmt.Errorf("no active signing key")
+	}
+
+	token := jwt.NewWithClaims(signing.Method, claims)
+	token.Header["kid"] = signing.ID
+	return token.SignedString(signing.signKey)
+}
+
+// keyFunc выбирает ключ проверки по kid и следит, чтобы алгоритм токена совпадал с алгоритмом ключа
+func (r *KeyRing) keyFunc(token *jwt.Token) (interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var key *SigningKey
+	if kid, ok := token.Header["kid"].(string); ok {
+		key = r.keys[kid]
+		if key == nil {
+			return nil, fmt.Errorf("unknown signing key: %s", kid)
+		}
+	} else {
+		key = r.legacy
+		if key == nil || (!r.legacyUntil.IsZero() && time.Now().After(r.legacyUntil)) {
+			return nil, fmt.Errorf("token has no key ID")
+		}
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.verifyKey, nil
+}
@@ -0,0 +1,247 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"itam_auth/internal/models"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+
+	defaultHMACKeyID = "hmac"
+	hmacSecretSize   = 32   // Длина генерируемого HMAC-секрета в байтах
+	rsaKeyBits       = 2048 // Длина генерируемого RSA-ключа
+)
+
+// SigningKey - ключ, которым подписываются и проверяются токены
+type SigningKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// JWK - публичный ключ в формате JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS - набор публичных ключей, публикуемый в /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func NewHMACKey(id string, secret []byte) *SigningKey {
+	if id == "" {
+		id = defaultHMACKeyID
+	}
+	return &SigningKey{
+		ID:        id,
+		Method:    jwt.SigningMethodHS256,
+		signKey:   secret,
+		verifyKey: secret,
+	}
+}
+
+// ParsePrivateKeyPEM разбирает закрытый ключ RSA (PKCS#1 или PKCS#8) или Ed25519 (PKCS#8).
+// Если id пустой, идентификатором ключа становится его отпечаток по RFC 7638.
+func ParsePrivateKeyPEM(id string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	var key *SigningKey
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("RSA key must be at least 2048 bits, got %d", k.N.BitLen())
+		}
+		key = &SigningKey{ID: id, Method: jwt.SigningMethodRS256, signKey: k, verifyKey: &k.PublicKey}
+	case ed25519.PrivateKey:
+		key = &SigningKey{ID: id, Method: jwt.SigningMethodEdDSA, signKey: k, verifyKey: k.Public()}
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", parsed)
+	}
+
+	if key.ID == "" {
+		key.ID, err = key.thumbprint()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+func LoadPrivateKeyFile(id, path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key file: %w", err)
+	}
+	return ParsePrivateKeyPEM(id, data)
+}
+
+// ParseSigningKeyRecord восстанавливает ключ из записи таблицы signing_keys
+func ParseSigningKeyRecord(record models.SigningKey) (*SigningKey, error) {
+	switch record.Algorithm {
+	case AlgHS256:
+		secret, err := base64.StdEncoding.DecodeString(record.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode HMAC secret of key %s: %w", record.ID, err)
+		}
+		return NewHMACKey(record.ID, secret), nil
+	case AlgRS256, AlgEdDSA:
+		key, err := ParsePrivateKeyPEM(record.ID, []byte(record.PrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key %s: %w", record.ID, err)
+		}
+		if key.Method.Alg() != record.Algorithm {
+			return nil, fmt.Errorf("key %s is %s, but stored as %s", record.ID, key.Method.Alg(), record.Algorithm)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %s of key %s", record.Algorithm, record.ID)
+	}
+}
+
+// GenerateSigningKey создает новый ключ и возвращает его в виде записи для таблицы signing_keys.
+// Состояние ключа выставляет хранилище при сохранении.
+func GenerateSigningKey(alg string) (models.SigningKey, error) {
+	record := models.SigningKey{Algorithm: alg, CreatedAt: time.Now()}
+
+	switch alg {
+	case AlgHS256:
+		secret := make([]byte, hmacSecretSize)
+		if _, err := rand.Read(secret); err != nil {
+			return models.SigningKey{}, fmt.Errorf("failed to generate HMAC secret: %w", err)
+		}
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return models.SigningKey{}, fmt.Errorf("failed to generate key ID: %w", err)
+		}
+		record.ID = base64.RawURLEncoding.EncodeToString(id)
+		record.PrivateKey = base64.StdEncoding.EncodeToString(secret)
+		return record, nil
+	case AlgRS256, AlgEdDSA:
+		var private interface{}
+		var err error
+		if alg == AlgRS256 {
+			private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		} else {
+			_, private, err = ed25519.GenerateKey(rand.Reader)
+		}
+		if err != nil {
+			return models.SigningKey{}, fmt.Errorf("failed to generate %s key: %w", alg, err)
+		}
+
+		der, err := x509.MarshalPKCS8PrivateKey(private)
+		if err != nil {
+			return models.SigningKey{}, fmt.Errorf("failed to marshal private key: %w", err)
+		}
+		pemData := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+		key, err := ParsePrivateKeyPEM("", pemData)
+		if err != nil {
+			return models.SigningKey{}, err
+		}
+		record.ID = key.ID
+		record.PrivateKey = string(pemData)
+		return record, nil
+	default:
+		return models.SigningKey{}, fmt.Errorf("unsupported algorithm: %s", alg)
+	}
+}
+
+// PublicJWK возвращает публичную часть ключа. Для HMAC-ключей публичной части нет
+func (k *SigningKey) PublicJWK() (JWK, bool) {
+	switch pub := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Kid: k.ID,
+			Use: "sig",
+			Alg: k.Method.Alg(),
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Kid: k.ID,
+			Use: "sig",
+			Alg: k.Method.Alg(),
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}, true
+	default:
+		return JWK{}, false
+	}
+}
+
+// thumbprint считает отпечаток публичного ключа по RFC 7638
+func (k *SigningKey) thumbprint() (string, error) {
+	jwk, ok := k.PublicJWK()
+	if !ok {
+		return "", fmt.Errorf("thumbprint is only defined for asymmetric keys")
+	}
+
+	// Поля должны идти в лексикографическом порядке
+	var members interface{}
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JWK: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
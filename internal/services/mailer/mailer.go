@@ -0,0 +1,36 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"itam_auth/internal/config"
+)
+
+const (
+	DriverSMTP = "smtp" // Отправка через SMTP-сервер
+	DriverLog  = "log"  // Письма пишутся в лог и, если задан каталог, в файлы .eml - для локальной разработки
+)
+
+// Message - письмо пользователю
+type Message struct {
+	To      string
+	Subject string
+	Body    string // Текст письма без разметки
+}
+
+// Mailer отправляет письма. Реализация выбирается настройкой MAIL_DRIVER
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New создает отправителя писем по настройкам
+func New(cfg *config.AppConfig) (Mailer, error) {
+	switch cfg.MailDriver {
+	case DriverSMTP:
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom), nil
+	case DriverLog:
+		return NewLogMailer(cfg.MailOutputDir, cfg.MailFrom), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q, expected %q or %q", cfg.MailDriver, DriverSMTP, DriverLog)
+	}
+}
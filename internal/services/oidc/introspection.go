@@ -0,0 +1,222 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"itam_auth/internal/database"
+	"itam_auth/internal/models"
+	"itam_auth/internal/services/auth"
+	"itam_auth/internal/services/jwt"
+	"itam_auth/internal/services/permission"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Подсказки token_type_hint (RFC 7662, раздел 2.1)
+const (
+	TokenTypeHintAccess  = "access_token"
+	TokenTypeHintRefresh = "refresh_token"
+)
+
+// TokenTypePersonal - token_type в ответе о персональном токене. Подсказкой он не нужен: персональный
+// токен узнается по префиксу
+const TokenTypePersonal = "personal_access_token"
+
+// IntrospectionRequest - параметры запроса на /introspect
+type IntrospectionRequest struct {
+	Token         string `form:"token" binding:"required"`
+	TokenTypeHint string `form:"token_type_hint"`
+	ClientID      string `form:"client_id"`
+	ClientSecret  string `form:"client_secret"`
+}
+
+// IntrospectionResponse - ответ /introspect (RFC 7662, раздел 2.2). Для недействительного токена
+// заполняется только Active
+type IntrospectionResponse struct {
+	Active        bool     `json:"active"`
+	TokenType     string   `json:"token_type,omitempty" example:"access_token"`
+	Subject       string   `json:"sub,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Username      string   `json:"username,omitempty" example:"john@example.com"`
+	ClientID      string   `json:"client_id,omitempty" example:"points-importer"`
+	Scope         string   `json:"scope,omitempty" example:"achievements"`
+	AdminServices []string `json:"admin_services,omitempty"`
+	ExpiresAt     int64    `json:"exp,omitempty" example:"1700000000"`
+	IssuedAt      int64    `json:"iat,omitempty" example:"1700000000"`
+	TokenID       string   `json:"jti,omitempty"`
+	Issuer        string   `json:"iss,omitempty" example:"http://localhost:8080"`
+}
+
+// AuthorizeIntrospection проверяет, что аутентифицированному клиенту разрешено проверять токены:
+// это конфиденциальный клиент со scope introspection
+func AuthorizeIntrospection(client models.OAuthClient) error {
+	if client.IsPublic() || !client.AllowsScope(models.ScopeIntrospection) {
+		return newError("unauthorized_client", "client is not allowed to introspect tokens")
+	}
+	return nil
+}
+
+// Introspect сообщает, действителен ли выпущенный сервисом access-, refresh- или персональный токен. Отозванные,
+// просроченные и чужие токены неактивны. admin_services берутся из текущих прав пользователя, а не из токена
+func (p *Provider) Introspect(ctx context.Context, req IntrospectionRequest, revocations jwt.RevocationChecker) (IntrospectionResponse, error) {
+	if auth.IsPersonalToken(req.Token) {
+		return p.introspectPersonalToken(ctx, req.Token)
+	}
+
+	// Подсказка только задает порядок проверки (RFC 7662, раздел 2.1)
+	if req.TokenTypeHint == TokenTypeHintRefresh {
+		if response, ok, err := p.introspectRefreshToken(ctx, req.Token); ok || err != nil {
+			return response, err
+		}
+		return p.introspectAccessToken(ctx, req.Token, revocations)
+	}
+
+	response, err := p.introspectAccessToken(ctx, req.Token, revocations)
+	if err != nil || response.Active {
+		return response, err
+	}
+	if response, ok, err := p.introspectRefreshToken(ctx, req.Token); ok || err != nil {
+		return response, err
+	}
+	return IntrospectionResponse{}, nil
+}
+
+func (p *Provider) introspectAccessToken(ctx context.Context, token string, revocations jwt.RevocationChecker) (IntrospectionResponse, error) {
+	claims, err := jwt.ParseToken(ctx, token, p.keys, revocations)
+	if err != nil {
+		return IntrospectionResponse{}, nil
+	}
+
+	response := IntrospectionResponse{
+		Active:    true,
+		TokenType: TokenTypeHintAccess,
+		TokenID:   claims.ID,
+		Issuer:    p.issuer,
+	}
+	if claims.ExpiresAt != nil {
+		response.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		response.IssuedAt = claims.IssuedAt.Unix()
+	}
+
+	if claims.IsService() {
+		// Удаленный клиент больше не может пользоваться уже выданными токенами
+		if _, err := p.storage.GetOAuthClient(ctx, claims.ClientID); err != nil {
+			return IntrospectionResponse{}, nil
+		}
+		response.Subject = claims.ClientID
+		response.ClientID = claims.ClientID
+		response.Scope = claims.Scope
+		return response, nil
+	}
+
+	if !claims.IsClientToken() {
+		return p.withUser(ctx, response, uuid.MustParse(claims.UID))
+	}
+	return p.withClient(ctx, response, claims.ClientID, claims.Scope, uuid.MustParse(claims.UID))
+}
+
+// introspectRefreshToken возвращает ok = false, если token - не refresh-токен этого сервиса
+func (p *Provider) introspectRefreshToken(ctx context.Context, token string) (IntrospectionResponse, bool, error) {
+	claims, err := jwt.ValidateRefreshToken(token, p.keys)
+	if err != nil {
+		return IntrospectionResponse{}, false, nil
+	}
+
+	stored, err := p.storage.GetRefreshToken(ctx, uuid.MustParse(claims.ID))
+	if err != nil {
+		log.Printf("Failed to get refresh token for introspection (id=%s): %v", claims.ID, err)
+		return IntrospectionResponse{}, true, nil
+	}
+	if stored.RevokedAt != nil || stored.RotatedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return IntrospectionResponse{}, true, nil
+	}
+
+	response := IntrospectionResponse{
+		Active:    true,
+		TokenType: TokenTypeHintRefresh,
+		TokenID:   claims.ID,
+		Issuer:    p.issuer,
+		ExpiresAt: stored.ExpiresAt.Unix(),
+		IssuedAt:  stored.CreatedAt.Unix(),
+	}
+	if stored.ClientID == nil {
+		response, err = p.withUser(ctx, response, stored.UserID)
+		return response, true, err
+	}
+	response, err = p.withClient(ctx, response, *stored.ClientID, stored.Scope, stored.UserID)
+	return response, true, err
+}
+
+// introspectPersonalToken сообщает о персональном токене: scope - его scope, admin_services - из текущих
+// прав владельца в пределах этих scope
+func (p *Provider) introspectPersonalToken(ctx context.Context, token string) (IntrospectionResponse, error) {
+	record, user, err := auth.AuthenticatePersonalToken(ctx, p.storage, token, "")
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidPersonalToken) {
+			return IntrospectionResponse{}, nil
+		}
+		log.Printf("Failed to get personal access token for introspection: %v", err)
+		return IntrospectionResponse{}, err
+	}
+
+	permissions, err := auth.PersonalTokenPermissions(ctx, p.storage, record)
+	if err != nil {
+		return IntrospectionResponse{}, err
+	}
+
+	return IntrospectionResponse{
+		Active:        true,
+		TokenType:     TokenTypePersonal,
+		Subject:       user.ID.String(),
+		Username:      user.Email,
+		Scope:         strings.Join(record.Scopes, " "),
+		AdminServices: permissions.AdminServices(),
+		ExpiresAt:     record.ExpiresAt.Unix(),
+		IssuedAt:      record.CreatedAt.Unix(),
+		TokenID:       record.ID.String(),
+		Issuer:        p.issuer,
+	}, nil
+}
+
+// withClient дополняет ответ о токене, выданном приложению, данными клиента и пользователя. Прав
+// пользователя у приложения нет, поэтому admin_services не возвращаются. Токен удаленного клиента неактивен
+func (p *Provider) withClient(ctx context.Context, response IntrospectionResponse, clientID, scope string, userID uuid.UUID) (IntrospectionResponse, error) {
+	if _, err := p.storage.GetOAuthClient(ctx, clientID); err != nil {
+		return IntrospectionResponse{}, nil
+	}
+
+	response, err := p.withUser(ctx, response, userID)
+	if err != nil || !response.Active {
+		return response, err
+	}
+	response.ClientID = clientID
+	response.Scope = scope
+	response.AdminServices = nil
+	return response, nil
+}
+
+// withUser дополняет ответ данными пользователя. Токен удаленного пользователя неактивен
+func (p *Provider) withUser(ctx context.Context, response IntrospectionResponse, userID uuid.UUID) (IntrospectionResponse, error) {
+	user, err := p.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrUserNotFound) {
+			return IntrospectionResponse{}, nil
+		}
+		log.Printf("Failed to get user for introspection (user_id=%s): %v", userID, err)
+		return IntrospectionResponse{}, err
+	}
+
+	permissions, err := permission.Resolve(ctx, p.storage, user.ID)
+	if err != nil {
+		return IntrospectionResponse{}, err
+	}
+
+	response.Subject = user.ID.String()
+	response.Username = user.Email
+	response.AdminServices = permissions.AdminServices()
+	return response, nil
+}
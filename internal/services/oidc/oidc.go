@@ -0,0 +1,492 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"itam_auth/internal/config"
+	"itam_auth/internal/database"
+	"itam_auth/internal/models"
+	"itam_auth/internal/services/auth"
+	"itam_auth/internal/services/jwt"
+	"log"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	authorizationCodeDuration = 5 * time.Minute  // Время жизни кода авторизации
+	idTokenDuration           = time.Hour        // Время жизни ID-токена
+	serviceTokenDuration      = 15 * time.Minute // Время жизни токена сервисного аккаунта
+	codeChallengeMethodS256   = "S256"           // Единственный поддерживаемый метод PKCE
+
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+
+	// AllUserInfoScopes - scope, которые /userinfo считает выданными токенам самого сервиса
+	AllUserInfoScopes = ScopeOpenID + " " + ScopeProfile + " " + ScopeEmail
+)
+
+// Error - ошибка в формате OAuth 2.0 (RFC 6749, раздел 5.2)
+type Error struct {
+	Code        string
+	Description string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Description)
+}
+
+func newError(code, format string, args ...any) *Error {
+	return &Error{Code: code, Description: fmt.Sprintf(format, args...)}
+}
+
+// AuthorizeRequest - параметры запроса на /authorize
+type AuthorizeRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type"`
+	ClientID            string `form:"client_id" json:"client_id"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	Nonce               string `form:"nonce" json:"nonce"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+}
+
+// TokenRequest - параметры запроса на /token
+type TokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	Scope        string `form:"scope"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+// TokenResponse - ответ /token
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// Provider реализует OpenID Connect: код авторизации с PKCE, выдачу ID-токенов и userinfo
+type Provider struct {
+	storage  *database.Storage
+	keys     *jwt.KeyRing
+	issuer   string
+	loginURL string
+}
+
+func NewProvider(storage *database.Storage, keys *jwt.KeyRing, cfg *config.AppConfig) *Provider {
+	return &Provider{
+		storage:  storage,
+		keys:     keys,
+		issuer:   strings.TrimSuffix(cfg.OIDCIssuer, "/"),
+		loginURL: cfg.OIDCLoginURL,
+	}
+}
+
+// LoginRedirect возвращает адрес страницы входа, которой передаются исходные параметры /authorize.
+// После входа страница отправляет их на POST /authorize вместе с access-токеном пользователя
+func (p *Provider) LoginRedirect(req AuthorizeRequest) string {
+	return redirectWithParams(p.loginURL, url.Values{
+		"response_type":         {req.ResponseType},
+		"client_id":             {req.ClientID},
+		"redirect_uri":          {req.RedirectURI},
+		"scope":                 {req.Scope},
+		"state":                 {req.State},
+		"nonce":                 {req.Nonce},
+		"code_challenge":        {req.CodeChallenge},
+		"code_challenge_method": {req.CodeChallengeMethod},
+	})
+}
+
+// SingleSignOnEnabled сообщает, может ли сервис выдавать ID-токены. Приложение проверяет ID-токен по JWKS,
+// а HS256-токен проверяется только общим секретом сервиса, поэтому вход через OpenID Connect работает,
+// только пока токены подписывает ключ RS256 или EdDSA. Сервисные аккаунты и /introspect от этого не зависят
+func (p *Provider) SingleSignOnEnabled() bool {
+	alg := p.keys.SigningAlg()
+	return alg == jwt.AlgRS256 || alg == jwt.AlgEdDSA
+}
+
+// Discovery возвращает документ /.well-known/openid-configuration. Пока вход через OpenID Connect
+// выключен (см. SingleSignOnEnabled), документ не публикуется
+func (p *Provider) Discovery() map[string]interface{} {
+	return map[string]interface{}{
+		"issuer":                                        p.issuer,
+		"authorization_endpoint":                        p.issuer + "/auth/oidc/authorize",
+		"token_endpoint":                                p.issuer + "/auth/oidc/token",
+		"userinfo_endpoint":                             p.issuer + "/auth/oidc/userinfo",
+		"introspection_endpoint":                        p.issuer + "/auth/oidc/introspect",
+		"jwks_uri":                                      p.issuer + "/.well-known/jwks.json",
+		"response_types_supported":                      []string{"code"},
+		"grant_types_supported":                         []string{"authorization_code", "refresh_token", "client_credentials"},
+		"subject_types_supported":                       []string{"public"},
+		"id_token_signing_alg_values_supported":         []string{p.keys.SigningAlg()},
+		"scopes_supported":                              []string{ScopeOpenID, ScopeProfile, ScopeEmail},
+		"token_endpoint_auth_methods_supported":         []string{"client_secret_basic", "client_secret_post", "none"},
+		"introspection_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post"},
+		"code_challenge_methods_supported":              []string{codeChallengeMethodS256},
+		"claims_supported":                              []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "name", "email", "specification", "picture"},
+	}
+}
+
+// ValidateClient проверяет client_id и redirect_uri. Пока они не проверены, перенаправлять
+// пользователя нельзя, поэтому об ошибке сообщается самому пользователю
+func (p *Provider) ValidateClient(ctx context.Context, req AuthorizeRequest) (models.OAuthClient, error) {
+	if req.ClientID == "" {
+		return models.OAuthClient{}, newError("invalid_request", "client_id is required")
+	}
+
+	client, err := p.storage.GetOAuthClient(ctx, req.ClientID)
+	if err != nil {
+		log.Printf("Failed to get OAuth client (client_id=%s): %v", req.ClientID, err)
+		return models.OAuthClient{}, newError("invalid_client", "unknown client")
+	}
+
+	if !client.AllowsRedirectURI(req.RedirectURI) {
+		return models.OAuthClient{}, newError("invalid_request", "redirect_uri is not registered for this client")
+	}
+
+	return client, nil
+}
+
+// ValidateAuthorizeRequest проверяет остальные параметры запроса. Об этих ошибках клиенту
+// сообщается через redirect_uri
+func (p *Provider) ValidateAuthorizeRequest(req AuthorizeRequest) error {
+	if !p.SingleSignOnEnabled() {
+		return newError("temporarily_unavailable", "OpenID Connect requires an RS256 or EdDSA signing key")
+	}
+	if req.ResponseType != "code" {
+		return newError("unsupported_response_type", "only response_type=code is supported")
+	}
+	if !hasScope(req.Scope, ScopeOpenID) {
+		return newError("invalid_scope", "scope must include openid")
+	}
+	if req.CodeChallenge == "" {
+		return newError("invalid_request", "code_challenge is required")
+	}
+	if req.CodeChallengeMethod != codeChallengeMethodS256 {
+		return newError("invalid_request", "code_challenge_method must be S256")
+	}
+	return nil
+}
+
+// Authorize выдает код авторизации для пользователя, который уже вошел в систему,
+// и возвращает адрес, на который нужно перенаправить браузер
+func (p *Provider) Authorize(ctx context.Context, req AuthorizeRequest, user models.User, authTime time.Time) (string, error) {
+	if _, err := p.ValidateClient(ctx, req); err != nil {
+		return "", err
+	}
+	if err := p.ValidateAuthorizeRequest(req); err != nil {
+		return ErrorRedirect(req, err), nil
+	}
+
+	code, err := randomToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate authorization code: %w", err)
+	}
+
+	now := time.Now()
+	err = p.storage.SaveAuthorizationCode(ctx, models.AuthorizationCode{
+		CodeHash:      hashCode(code),
+		ClientID:      req.ClientID,
+		UserID:        user.ID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         req.Scope,
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		AuthTime:      authTime,
+		ExpiresAt:     now.Add(authorizationCodeDuration),
+		CreatedAt:     now,
+	})
+	if err != nil {
+		log.Printf("Failed to save authorization code (client_id=%s, user_id=%s): %v", req.ClientID, user.ID, err)
+		return "", err
+	}
+
+	if err := p.storage.DeleteExpiredAuthorizationCodes(ctx); err != nil {
+		log.Printf("Failed to delete expired authorization codes: %v", err)
+	}
+
+	log.Printf("Authorization code issued (client_id=%s, user_id=%s)", req.ClientID, user.ID)
+	return redirectWithParams(req.RedirectURI, url.Values{"code": {code}, "state": {req.State}}), nil
+}
+
+// ErrorRedirect возвращает адрес redirect_uri с описанием ошибки
+func ErrorRedirect(req AuthorizeRequest, err error) string {
+	oauthErr, ok := err.(*Error)
+	if !ok {
+		oauthErr = newError("server_error", "internal error")
+	}
+	return redirectWithParams(req.RedirectURI, url.Values{
+		"error":             {oauthErr.Code},
+		"error_description": {oauthErr.Description},
+		"state":             {req.State},
+	})
+}
+
+// AuthenticateClient проверяет секрет конфиденциального клиента. Публичный клиент
+// аутентифицируется только по client_id и должен подтвердить код через PKCE
+func (p *Provider) AuthenticateClient(ctx context.Context, clientID, clientSecret string) (models.OAuthClient, error) {
+	if clientID == "" {
+		return models.OAuthClient{}, newError("invalid_client", "client authentication is required")
+	}
+
+	client, err := p.storage.GetOAuthClient(ctx, clientID)
+	if err != nil {
+		return models.OAuthClient{}, newError("invalid_client", "unknown client")
+	}
+
+	if client.IsPublic() {
+		if clientSecret != "" {
+			return models.OAuthClient{}, newError("invalid_client", "public client must not send a secret")
+		}
+		return client, nil
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(*client.SecretHash), []byte(clientSecret)) != nil {
+		log.Printf("Invalid client secret (client_id=%s)", clientID)
+		return models.OAuthClient{}, newError("invalid_client", "invalid client credentials")
+	}
+
+	return client, nil
+}
+
+// Exchange обрабатывает запрос к /token от уже аутентифицированного клиента
+func (p *Provider) Exchange(ctx context.Context, client models.OAuthClient, req TokenRequest) (TokenResponse, error) {
+	switch req.GrantType {
+	case "authorization_code":
+		return p.exchangeCode(ctx, client, req)
+	case "client_credentials":
+		return p.clientCredentials(client, req)
+	case "refresh_token":
+		tokens, err := auth.RefreshTokens(ctx, p.storage, req.RefreshToken, client.ID, p.keys)
+		if err != nil {
+			return TokenResponse{}, newError("invalid_grant", "%v", err)
+		}
+		return TokenResponse{
+			AccessToken:  tokens.AccessToken,
+			TokenType:    "Bearer",
+			ExpiresIn:    tokens.ExpiresIn,
+			RefreshToken: tokens.RefreshToken,
+		}, nil
+	default:
+		return TokenResponse{}, newError("unsupported_grant_type", "grant_type %q is not supported", req.GrantType)
+	}
+}
+
+func (p *Provider) exchangeCode(ctx context.Context, client models.OAuthClient, req TokenRequest) (TokenResponse, error) {
+	if !p.SingleSignOnEnabled() {
+		return TokenResponse{}, newError("unsupported_grant_type", "authorization_code requires an RS256 or EdDSA signing key")
+	}
+	if req.Code == "" {
+		return TokenResponse{}, newError("invalid_request", "code is required")
+	}
+
+	code, err := p.storage.ConsumeAuthorizationCode(ctx, hashCode(req.Code))
+	if err != nil {
+		return TokenResponse{}, newError("invalid_grant", "invalid authorization code")
+	}
+
+	if code.ClientID != client.ID {
+		return TokenResponse{}, newError("invalid_grant", "authorization code was issued to another client")
+	}
+	if time.Now().After(code.ExpiresAt) {
+		return TokenResponse{}, newError("invalid_grant", "authorization code has expired")
+	}
+	if code.RedirectURI != req.RedirectURI {
+		return TokenResponse{}, newError("invalid_grant", "redirect_uri does not match")
+	}
+	if !verifyCodeChallenge(req.CodeVerifier, code.CodeChallenge) {
+		return TokenResponse{}, newError("invalid_grant", "invalid code_verifier")
+	}
+
+	user, err := p.storage.GetUserByID(ctx, code.UserID)
+	if err != nil {
+		log.Printf("Failed to get user for authorization code (client_id=%s, user_id=%s): %v", client.ID, code.UserID, err)
+		return TokenResponse{}, newError("invalid_grant", "user not found")
+	}
+
+	// Приложение получает токены, привязанные к нему и к выданному scope, а не токены самого сервиса
+	scope := grantedScope(client, code.Scope)
+	tokens, err := auth.IssueClientTokens(ctx, p.storage, user, client.ID, scope, code.AuthTime, p.keys)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	idToken := jwt.IDToken{
+		Issuer:   p.issuer,
+		Subject:  user.ID.String(),
+		Audience: client.ID,
+		Nonce:    code.Nonce,
+		AuthTime: code.AuthTime,
+	}
+	if hasScope(code.Scope, ScopeProfile) {
+		idToken.Name = user.Name
+		idToken.Specification = string(user.Specification)
+		if user.PhotoURL != nil {
+			idToken.Picture = *user.PhotoURL
+		}
+	}
+	if hasScope(code.Scope, ScopeEmail) {
+		idToken.Email = user.Email
+	}
+
+	idTokenString, err := jwt.NewIDToken(idToken, idTokenDuration, p.keys)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	log.Printf("Authorization code exchanged (client_id=%s, user_id=%s)", client.ID, user.ID)
+	return TokenResponse{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    tokens.ExpiresIn,
+		RefreshToken: tokens.RefreshToken,
+		IDToken:      idTokenString,
+		Scope:        scope,
+	}, nil
+}
+
+// grantedScope оставляет из запрошенного scope стандартные scope OpenID Connect и scope API,
+// разрешенные клиенту. Остальные молча отбрасываются (RFC 6749, раздел 3.3)
+func grantedScope(client models.OAuthClient, requested string) string {
+	var granted []string
+	for _, scope := range strings.Fields(requested) {
+		switch {
+		case slices.Contains(granted, scope):
+		case scope == ScopeOpenID || scope == ScopeProfile || scope == ScopeEmail, client.AllowsScope(scope):
+			granted = append(granted, scope)
+		}
+	}
+	return strings.Join(granted, " ")
+}
+
+// clientCredentials выпускает токен самому клиенту как сервисному аккаунту. Если scope не указан,
+// выдаются все scope, разрешенные клиенту
+func (p *Provider) clientCredentials(client models.OAuthClient, req TokenRequest) (TokenResponse, error) {
+	if client.IsPublic() {
+		return TokenResponse{}, newError("unauthorized_client", "public clients cannot use client_credentials")
+	}
+	if len(client.Scopes) == 0 {
+		return TokenResponse{}, newError("unauthorized_client", "client is not allowed to use client_credentials")
+	}
+
+	scopes := strings.Fields(req.Scope)
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	for _, scope := range scopes {
+		if !client.AllowsScope(scope) {
+			return TokenResponse{}, newError("invalid_scope", "scope %q is not allowed for this client", scope)
+		}
+	}
+
+	accessToken, err := jwt.NewServiceToken(client.ID, scopes, serviceTokenDuration, p.keys)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	log.Printf("Service token issued (client_id=%s, scope=%s)", client.ID, strings.Join(scopes, " "))
+	return TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(serviceTokenDuration.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+// UserInfo возвращает стандартные claims пользователя для /userinfo: профиль при scope profile,
+// email при scope email
+func UserInfo(user models.User, scope string) map[string]interface{} {
+	info := map[string]interface{}{
+		"sub": user.ID.String(),
+	}
+	if hasScope(scope, ScopeProfile) {
+		info["name"] = user.Name
+		info["specification"] = user.Specification
+		info["updated_at"] = user.UpdatedAt.Unix()
+		if user.PhotoURL != nil && *user.PhotoURL != "" {
+			info["picture"] = *user.PhotoURL
+		}
+	}
+	if hasScope(scope, ScopeEmail) {
+		info["email"] = user.Email
+	}
+	return info
+}
+
+// GenerateClientSecret создает секрет конфиденциального клиента и его bcrypt-хеш для хранения
+func GenerateClientSecret() (secret string, hash string, err error) {
+	secret, err = randomToken()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate client secret: %w", err)
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to hash client secret: %w", err)
+	}
+	return secret, string(hashed), nil
+}
+
+func verifyCodeChallenge(verifier, challenge string) bool {
+	if verifier == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+func hasScope(scope, wanted string) bool {
+	for _, s := range strings.Fields(scope) {
+		if s == wanted {
+			return true
+		}
+	}
+	return false
+}
+
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func redirectWithParams(redirectURI string, params url.Values) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+	query := u.Query()
+	for key, values := range params {
+		if len(values) > 0 && values[0] != "" {
+			query.Set(key, values[0])
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
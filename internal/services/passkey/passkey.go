@@ -0,0 +1,358 @@
+package passkey
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"itam_auth/internal/config"
+	"itam_auth/internal/database"
+	"itam_auth/internal/models"
+	"itam_auth/internal/services/auth"
+	"itam_auth/internal/services/jwt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+)
+
+const (
+	RPDisplayName   = "ITaM"          // Название сервиса в диалоге браузера
+	sessionDuration = 5 * time.Minute // Сколько ждать ответа аутентификатора
+	maxNameLength   = 255
+)
+
+var (
+	// ErrInvalidCredential - ответ аутентификатора не прошел проверку
+	ErrInvalidCredential = errors.New("invalid passkey response")
+	// ErrClonedAuthenticator - счетчик подписей не вырос, ключ мог быть скопирован
+	ErrClonedAuthenticator = errors.New("passkey signature counter did not increase")
+)
+
+// Service проводит церемонии WebAuthn: регистрацию ключей доступа и вход по ним
+type Service struct {
+	storage  *database.Storage
+	keys     *jwt.KeyRing
+	webAuthn *webauthn.WebAuthn
+	policy   auth.LoginPolicy
+}
+
+func NewService(storage *database.Storage, keys *jwt.KeyRing, cfg *config.AppConfig) (*Service, error) {
+	webAuthn, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.WebAuthnRPID,
+		RPDisplayName: RPDisplayName,
+		RPOrigins:     cfg.WebAuthnRPOrigins,
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        webauthn.TimeoutConfig{Enforce: true, Timeout: sessionDuration, TimeoutUVD: sessionDuration},
+			Registration: webauthn.TimeoutConfig{Enforce: true, Timeout: sessionDuration, TimeoutUVD: sessionDuration},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure webauthn: %w", err)
+	}
+
+	// Ключ доступа сам по себе второй фактор, поэтому из политики входа действует только подтверждение email
+	policy := auth.LoginPolicy{RequireVerifiedEmail: cfg.RequireVerifiedEmail}
+	return &Service{storage: storage, keys: keys, webAuthn: webAuthn, policy: policy}, nil
+}
+
+// passkeyUser связывает пользователя с его ключами для библиотеки WebAuthn.
+// Идентификатор пользователя (user handle) - байты UUID
+type passkeyUser struct {
+	user        models.User
+	credentials []webauthn.Credential
+}
+
+func (u *passkeyUser) WebAuthnID() []byte {
+	return u.user.ID[:]
+}
+
+func (u *passkeyUser) WebAuthnName() string {
+	if u.user.Email != "" {
+		return u.user.Email
+	}
+	return u.user.Name
+}
+
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	return u.user.Name
+}
+
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+// BeginRegistration начинает регистрацию ключа доступа. Уже зарегистрированные ключи пользователя
+// передаются в excludeCredentials, чтобы аутентификатор не создал второй ключ там же
+func (s *Service) BeginRegistration(ctx context.Context, userID uuid.UUID) (uuid.UUID, *protocol.CredentialCreation, error) {
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	creation, session, err := s.webAuthn.BeginRegistration(user,
+		webauthn.WithExclusions(webauthn.Credentials(user.credentials).CredentialDescriptors()),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+	)
+	if err != nil {
+		return uuid.Nil, nil, fmt.Errorf("failed to begin webauthn registration: %w", err)
+	}
+
+	sessionID, err := s.saveSession(ctx, &userID, models.WebAuthnPurposeRegistration, session)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	log.Printf("Passkey registration started (id=%s)", userID)
+	return sessionID, creation, nil
+}
+
+// FinishRegistration проверяет ответ navigator.credentials.create() и сохраняет новый ключ
+func (s *Service) FinishRegistration(ctx context.Context, userID, sessionID uuid.UUID, name string, response []byte) (models.WebAuthnCredential, error) {
+	stored, err := s.storage.ConsumeWebAuthnSession(ctx, sessionID, models.WebAuthnPurposeRegistration)
+	if err != nil {
+		return models.WebAuthnCredential{}, err
+	}
+	if stored.UserID == nil || *stored.UserID != userID {
+		return models.WebAuthnCredential{}, database.ErrWebAuthnSessionNotFound
+	}
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal(stored.Data, &session); err != nil {
+		return models.WebAuthnCredential{}, fmt.Errorf("failed to decode webauthn session: %w", err)
+	}
+
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return models.WebAuthnCredential{}, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		return models.WebAuthnCredential{}, fmt.Errorf("%w: %s", ErrInvalidCredential, describe(err))
+	}
+
+	credential, err := s.webAuthn.CreateCredential(user, session, parsed)
+	if err != nil {
+		log.Printf("Passkey registration rejected (id=%s): %s", userID, describe(err))
+		return models.WebAuthnCredential{}, fmt.Errorf("%w: %s", ErrInvalidCredential, describe(err))
+	}
+
+	data, err := json.Marshal(credential)
+	if err != nil {
+		return models.WebAuthnCredential{}, fmt.Errorf("failed to encode webauthn credential: %w", err)
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Passkey"
+	}
+	if len(name) > maxNameLength {
+		name = name[:maxNameLength]
+	}
+
+	record := models.WebAuthnCredential{
+		ID:         credential.ID,
+		UserID:     userID,
+		Name:       name,
+		Credential: data,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.storage.SaveWebAuthnCredential(ctx, record); err != nil {
+		log.Printf("Failed to save passkey (id=%s): %v", userID, err)
+		return models.WebAuthnCredential{}, err
+	}
+
+	log.Printf("Passkey registered (id=%s)", userID)
+	return record, nil
+}
+
+// BeginLogin начинает вход без указания пользователя: браузер сам предложит сохраненные ключи.
+// Проверка пользователя на аутентификаторе (PIN, биометрия) обязательна, поэтому ключ доступа
+// заменяет и пароль, и второй фактор
+func (s *Service) BeginLogin(ctx context.Context) (uuid.UUID, *protocol.CredentialAssertion, error) {
+	assertion, session, err := s.webAuthn.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+	if err != nil {
+		return uuid.Nil, nil, fmt.Errorf("failed to begin webauthn login: %w", err)
+	}
+
+	sessionID, err := s.saveSession(ctx, nil, models.WebAuthnPurposeLogin, session)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	return sessionID, assertion, nil
+}
+
+// FinishLogin проверяет ответ navigator.credentials.get() и выдает такие же токены, как вход по паролю
+func (s *Service) FinishLogin(ctx context.Context, sessionID uuid.UUID, response []byte) (auth.TokenPair, error) {
+	stored, err := s.storage.ConsumeWebAuthnSession(ctx, sessionID, models.WebAuthnPurposeLogin)
+	if err != nil {
+		return auth.TokenPair{}, err
+	}
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal(stored.Data, &session); err != nil {
+		return auth.TokenPair{}, fmt.Errorf("failed to decode webauthn session: %w", err)
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return auth.TokenPair{}, fmt.Errorf("%w: %s", ErrInvalidCredential, describe(err))
+	}
+
+	found, credential, err := s.webAuthn.ValidatePasskeyLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user handle: %w", err)
+		}
+		return s.loadUser(ctx, userID)
+	}, session, parsed)
+	if err != nil {
+		log.Printf("Passkey login rejected: %s", describe(err))
+		return auth.TokenPair{}, fmt.Errorf("%w: %s", ErrInvalidCredential, describe(err))
+	}
+
+	user := found.(*passkeyUser).user
+	if credential.Authenticator.CloneWarning {
+		log.Printf("Passkey signature counter did not increase, login rejected (id=%s)", user.ID)
+		return auth.TokenPair{}, ErrClonedAuthenticator
+	}
+
+	data, err := json.Marshal(credential)
+	if err != nil {
+		return auth.TokenPair{}, fmt.Errorf("failed to encode webauthn credential: %w", err)
+	}
+	if err := s.storage.UpdateWebAuthnCredential(ctx, credential.ID, data, time.Now()); err != nil {
+		log.Printf("Failed to update passkey (id=%s): %v", user.ID, err)
+		return auth.TokenPair{}, err
+	}
+
+	if err := s.policy.CheckEmail(user); err != nil {
+		log.Printf("Passkey login rejected, email is not verified (email=%s, id=%s)", user.Email, user.ID)
+		return auth.TokenPair{}, err
+	}
+
+	tokens, err := auth.IssueTokens(ctx, s.storage, user, s.keys)
+	if err != nil {
+		return auth.TokenPair{}, err
+	}
+
+	log.Printf("User logged in with passkey (email=%s, id=%s)", user.Email, user.ID)
+	return tokens, nil
+}
+
+// List возвращает ключи доступа пользователя
+func (s *Service) List(ctx context.Context, userID uuid.UUID) ([]models.PasskeyResponse, error) {
+	records, err := s.storage.GetWebAuthnCredentialsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	passkeys := make([]models.PasskeyResponse, len(records))
+	for i, record := range records {
+		passkeys[i] = Response(record)
+	}
+	return passkeys, nil
+}
+
+// Delete удаляет ключ доступа. Последний ключ удалить нельзя, если других способов входа нет
+func (s *Service) Delete(ctx context.Context, userID uuid.UUID, encodedID string) error {
+	id, err := base64.RawURLEncoding.DecodeString(encodedID)
+	if err != nil {
+		return database.ErrWebAuthnCredentialNotFound
+	}
+
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if (user.PasswordHash == "" || user.Email == "") && user.TelegramID == nil {
+		count, err := s.storage.CountWebAuthnCredentials(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if count <= 1 {
+			return auth.ErrLastSignInMethod
+		}
+	}
+
+	if err := s.storage.DeleteWebAuthnCredential(ctx, userID, id); err != nil {
+		return err
+	}
+
+	log.Printf("Passkey deleted (id=%s)", userID)
+	return nil
+}
+
+// Response приводит ключ к виду для API. Идентификатор кодируется в base64url, как в WebAuthn
+func Response(record models.WebAuthnCredential) models.PasskeyResponse {
+	return models.PasskeyResponse{
+		ID:         base64.RawURLEncoding.EncodeToString(record.ID),
+		Name:       record.Name,
+		LastUsedAt: record.LastUsedAt,
+		CreatedAt:  record.CreatedAt,
+	}
+}
+
+func (s *Service) loadUser(ctx context.Context, userID uuid.UUID) (*passkeyUser, error) {
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	records, err := s.storage.GetWebAuthnCredentialsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := make([]webauthn.Credential, 0, len(records))
+	for _, record := range records {
+		var credential webauthn.Credential
+		if err := json.Unmarshal(record.Credential, &credential); err != nil {
+			return nil, fmt.Errorf("failed to decode webauthn credential: %w", err)
+		}
+		credentials = append(credentials, credential)
+	}
+
+	return &passkeyUser{user: user, credentials: credentials}, nil
+}
+
+func (s *Service) saveSession(ctx context.Context, userID *uuid.UUID, purpose string, session *webauthn.SessionData) (uuid.UUID, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to encode webauthn session: %w", err)
+	}
+
+	now := time.Now()
+	record := models.WebAuthnSession{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Purpose:   purpose,
+		Data:      data,
+		ExpiresAt: now.Add(sessionDuration),
+		CreatedAt: now,
+	}
+	if err := s.storage.SaveWebAuthnSession(ctx, record); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := s.storage.DeleteExpiredWebAuthnSessions(ctx); err != nil {
+		log.Printf("Failed to delete expired webauthn sessions: %v", err)
+	}
+	return record.ID, nil
+}
+
+// describe достает подробности из ошибок библиотеки, в которых основное сообщение слишком общее
+func describe(err error) string {
+	var protocolErr *protocol.Error
+	if errors.As(err, &protocolErr) && protocolErr.Details != "" {
+		return protocolErr.Details
+	}
+	return err.Error()
+}
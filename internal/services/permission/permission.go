@@ -0,0 +1,107 @@
+package permission
+
+import (
+	"context"
+	"fmt"
+	"itam_auth/internal/database"
+	"itam_auth/internal/models"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const adminPrefix = "admin_" // Права admin_<service> делают пользователя администратором сервиса
+
+// Set - права пользователя, собранные по всем его ролям
+type Set struct {
+	permissions []models.Permission
+}
+
+// Resolve собирает права пользователя по всем его ролям и их предкам. Одинаковые права разных ролей
+// учитываются один раз; у пользователя без ролей набор пустой
+func Resolve(ctx context.Context, storage *database.Storage, userID uuid.UUID) (Set, error) {
+	permissions, err := storage.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return Set{}, fmt.Errorf("failed to resolve permissions: %w", err)
+	}
+
+	unique := make([]models.Permission, 0, len(permissions))
+	for _, permission := range permissions {
+		if !slices.ContainsFunc(unique, func(p models.Permission) bool { return p.Name == permission.Name }) {
+			unique = append(unique, permission)
+		}
+	}
+	slices.SortFunc(unique, func(a, b models.Permission) int { return strings.Compare(a.Name, b.Name) })
+
+	return Set{permissions: unique}, nil
+}
+
+// FromNames собирает набор по названиям прав, например из claim perms access-токена. Идентификаторов
+// прав в таком наборе нет
+func FromNames(names []string) Set {
+	permissions := make([]models.Permission, 0, len(names))
+	for _, name := range names {
+		if !slices.ContainsFunc(permissions, func(p models.Permission) bool { return p.Name == name }) {
+			permissions = append(permissions, models.Permission{Name: name})
+		}
+	}
+	slices.SortFunc(permissions, func(a, b models.Permission) int { return strings.Compare(a.Name, b.Name) })
+
+	return Set{permissions: permissions}
+}
+
+// Permissions возвращает права в порядке названий
+func (s Set) Permissions() []models.Permission {
+	return slices.Clone(s.permissions)
+}
+
+// Names возвращает названия прав в алфавитном порядке
+func (s Set) Names() []string {
+	names := make([]string, len(s.permissions))
+	for i, permission := range s.permissions {
+		names[i] = permission.Name
+	}
+	return names
+}
+
+// Has сообщает, есть ли хотя бы одно из прав
+func (s Set) Has(names ...string) bool {
+	for _, permission := range s.permissions {
+		if slices.Contains(names, permission.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Restrict оставляет только права из names, например scope персонального токена
+func (s Set) Restrict(names []string) Set {
+	permissions := make([]models.Permission, 0, len(names))
+	for _, permission := range s.permissions {
+		if slices.Contains(names, permission.Name) {
+			permissions = append(permissions, permission)
+		}
+	}
+	return Set{permissions: permissions}
+}
+
+// AdminServices возвращает сервисы, которые пользователь администрирует (по правам admin_<service>)
+func (s Set) AdminServices() []string {
+	services := []string{}
+	for _, permission := range s.permissions {
+		if service, ok := strings.CutPrefix(permission.Name, adminPrefix); ok && service != "" {
+			services = append(services, service)
+		}
+	}
+	return services
+}
+
+// Explain возвращает права пользователя вместе с ролями, от которых они получены по иерархии
+func Explain(ctx context.Context, storage *database.Storage, userID uuid.UUID) ([]models.EffectivePermission, error) {
+	permissions, err := storage.GetEffectivePermissions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain permissions: %w", err)
+	}
+	return permissions, nil
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"itam_auth/internal/models"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute // Как часто удаляем наполнившиеся bucket
+
+type memoryBucket struct {
+	models.TokenBucket
+	fullAt time.Time
+}
+
+// MemoryStore хранит bucket в памяти процесса. У каждого экземпляра сервиса свои лимиты
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:   make(map[string]*memoryBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{TokenBucket: models.NewTokenBucket(policy.Burst, now)}
+		s.buckets[key] = bucket
+	}
+	allowed := bucket.Take(now, policy.Rate(), policy.Burst)
+	bucket.fullAt = now.Add(bucket.Until(float64(policy.Burst), policy.Rate()))
+
+	return result(bucket.Tokens, allowed, policy), nil
+}
+
+// sweep удаляет наполнившиеся bucket: они ничем не отличаются от отсутствующих. Вызывается под s.mu
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	for key, bucket := range s.buckets {
+		if now.After(bucket.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"context"
+	"itam_auth/internal/database"
+	"log"
+	"sync"
+	"time"
+)
+
+// PostgresStore хранит bucket в Postgres, поэтому лимиты общие для всех экземпляров сервиса
+type PostgresStore struct {
+	storage *database.Storage
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewPostgresStore(storage *database.Storage) *PostgresStore {
+	return &PostgresStore{storage: storage, lastSweep: time.Now()}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	s.sweep(ctx)
+
+	bucket, allowed, err := s.storage.TakeRateLimitToken(ctx, key, policy.Rate(), policy.Burst)
+	if err != nil {
+		return Result{}, err
+	}
+	return result(bucket.Tokens, allowed, policy), nil
+}
+
+// sweep не чаще раза в sweepInterval удаляет наполнившиеся bucket
+func (s *PostgresStore) sweep(ctx context.Context) {
+	s.mu.Lock()
+	if time.Since(s.lastSweep) < sweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = time.Now()
+	s.mu.Unlock()
+
+	if _, err := s.storage.DeleteFullRateLimitBuckets(ctx); err != nil {
+		log.Printf("Failed to delete full rate limit buckets: %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"itam_auth/internal/config"
+	"itam_auth/internal/database"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	StoreMemory   = "memory"   // Ограничители в памяти процесса - для одного экземпляра сервиса
+	StorePostgres = "postgres" // Ограничители в Postgres, общие для всех экземпляров
+)
+
+// Policy - ограничение частоты: Limit запросов за Period с запасом Burst запросов подряд
+type Policy struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+// Rate возвращает скорость пополнения bucket в запросах в секунду
+func (p Policy) Rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// Result - итог проверки запроса
+type Result struct {
+	Allowed    bool
+	Remaining  int           // Сколько запросов подряд можно сделать сейчас
+	RetryAfter time.Duration // Через сколько появится следующий жетон, если запрос отклонен
+	Reset      time.Duration // Через сколько bucket наполнится полностью
+}
+
+// Store хранит bucket ограничителей
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// Limiter проверяет запросы по именованным политикам из RATE_LIMIT_POLICIES
+type Limiter struct {
+	store    Store
+	policies map[string]Policy
+}
+
+// New создает ограничитель по настройкам. Если ограничение выключено, возвращает nil:
+// ограничитель nil пропускает все запросы
+func New(storage *database.Storage, cfg *config.AppConfig) (*Limiter, error) {
+	if !cfg.RateLimitEnabled {
+		return nil, nil
+	}
+
+	policies, err := ParsePolicies(cfg.RateLimitPolicies)
+	if err != nil {
+		return nil, err
+	}
+
+	var store Store
+	switch cfg.RateLimitStore {
+	case StoreMemory:
+		store = NewMemoryStore()
+	case StorePostgres:
+		store = NewPostgresStore(storage)
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q, expected %q or %q", cfg.RateLimitStore, StoreMemory, StorePostgres)
+	}
+
+	return &Limiter{store: store, policies: policies}, nil
+}
+
+// Policy возвращает политику по имени. Для ненастроенной политики ограничения нет
+func (l *Limiter) Policy(name string) (Policy, bool) {
+	if l == nil {
+		return Policy{}, false
+	}
+	policy, ok := l.policies[name]
+	return policy, ok
+}
+
+// Take учитывает запрос субъекта (ip:<адрес>, user:<id>, client:<client_id>) по политике
+func (l *Limiter) Take(ctx context.Context, policy Policy, subject string) (Result, error) {
+	return l.store.Take(ctx, policy.Name+":"+subject, policy)
+}
+
+// ParsePolicies разбирает политики вида name=limit/period[:burst], например login=10/m или upload=30/h:10.
+// Период - s, m, h или длительность Go (15m). Burst по умолчанию равен limit
+func ParsePolicies(specs []string) (map[string]Policy, error) {
+	policies := make(map[string]Policy, len(specs))
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		policy, err := parsePolicy(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit policy %q: %w", spec, err)
+		}
+		policies[policy.Name] = policy
+	}
+	return policies, nil
+}
+
+func parsePolicy(spec string) (Policy, error) {
+	name, rule, ok := strings.Cut(spec, "=")
+	if !ok || name == "" {
+		return Policy{}, fmt.Errorf("expected name=limit/period[:burst]")
+	}
+	rule, burstValue, hasBurst := strings.Cut(rule, ":")
+	limitValue, periodValue, ok := strings.Cut(rule, "/")
+	if !ok {
+		return Policy{}, fmt.Errorf("expected limit/period")
+	}
+
+	limit, err := strconv.Atoi(limitValue)
+	if err != nil || limit <= 0 {
+		return Policy{}, fmt.Errorf("limit must be a positive integer")
+	}
+
+	// "m" означает "1m"
+	if periodValue != "" && (periodValue[0] < '0' || periodValue[0] > '9') {
+		periodValue = "1" + periodValue
+	}
+	period, err := time.ParseDuration(periodValue)
+	if err != nil || period <= 0 {
+		return Policy{}, fmt.Errorf("period must be a positive duration")
+	}
+
+	burst := limit
+	if hasBurst {
+		burst, err = strconv.Atoi(burstValue)
+		if err != nil || burst <= 0 {
+			return Policy{}, fmt.Errorf("burst must be a positive integer")
+		}
+	}
+
+	return Policy{Name: name, Limit: limit, Period: period, Burst: burst}, nil
+}
+
+func result(tokens float64, allowed bool, policy Policy) Result {
+	rate := policy.Rate()
+	res := Result{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(policy.Burst) - tokens) / rate * float64(time.Second)),
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return res
+}
@@ -0,0 +1,167 @@
+package revocation
+
+import (
+	"context"
+	"fmt"
+	"itam_auth/internal/database"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	cacheTTL      = 30 * time.Second // Сколько доверяем закешированному результату проверки
+	sweepInterval = 5 * time.Minute  // Как часто чистим кеш от устаревших записей
+)
+
+type tokenEntry struct {
+	revoked   bool
+	expiresAt time.Time // Для отозванных токенов - срок действия токена, иначе - срок жизни записи в кеше
+}
+
+type userEntry struct {
+	validAfter time.Time
+	expiresAt  time.Time
+}
+
+// Store хранит отозванные токены в Postgres и кеширует результаты проверок в памяти процесса.
+// Отзывы, сделанные этим экземпляром сервиса, видны сразу; сделанные другими - не позже чем через cacheTTL.
+type Store struct {
+	storage *database.Storage
+
+	mu        sync.Mutex
+	tokens    map[uuid.UUID]tokenEntry
+	users     map[uuid.UUID]userEntry
+	lastSweep time.Time
+}
+
+func NewStore(storage *database.Storage) *Store {
+	return &Store{
+		storage:   storage,
+		tokens:    make(map[uuid.UUID]tokenEntry),
+		users:     make(map[uuid.UUID]userEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+// RevokeToken отзывает один токен до истечения его срока действия
+func (s *Store) RevokeToken(ctx context.Context, tokenID, userID uuid.UUID, expiresAt time.Time) error {
+	if err := s.storage.SaveRevokedToken(ctx, tokenID, userID, expiresAt); err != nil {
+		return err
+	}
+	if _, err := s.storage.DeleteExpiredRevokedTokens(ctx); err != nil {
+		log.Printf("Failed to delete expired revoked tokens: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[tokenID] = tokenEntry{revoked: true, expiresAt: expiresAt}
+	return nil
+}
+
+// RevokeAllUserTokens делает недействительными все токены пользователя, выпущенные до текущего момента,
+// и отзывает его персональные токены: их мог выпустить тот, кто завладел сессией.
+// Граница хранится с точностью до микросекунды, как время выпуска в токене, поэтому токены, выпущенные
+// сразу после отзыва (новая сессия после смены пароля или повторный вход), остаются действительными
+func (s *Store) RevokeAllUserTokens(ctx context.Context, userID uuid.UUID) error {
+	validAfter := time.Now().Truncate(time.Microsecond)
+	if err := s.storage.SetTokensValidAfter(ctx, userID, validAfter); err != nil {
+		return err
+	}
+	if err := s.storage.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.storage.RevokeUserPersonalTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[userID] = userEntry{validAfter: validAfter, expiresAt: time.Now().Add(cacheTTL)}
+	return nil
+}
+
+// IsRevoked сообщает, был ли токен отозван явно или выпущен до момента отзыва всех токенов пользователя
+func (s *Store) IsRevoked(ctx context.Context, tokenID string, userID uuid.UUID, issuedAt time.Time) (bool, error) {
+	validAfter, err := s.tokensValidAfter(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if !validAfter.IsZero() && issuedAt.Before(validAfter) {
+		return true, nil
+	}
+
+	// Токены без jti выпущены до появления отзыва и могут быть отозваны только целиком
+	if tokenID == "" {
+		return false, nil
+	}
+	id, err := uuid.Parse(tokenID)
+	if err != nil {
+		return false, fmt.Errorf("invalid token ID: %w", err)
+	}
+	return s.isTokenRevoked(ctx, id)
+}
+
+func (s *Store) isTokenRevoked(ctx context.Context, tokenID uuid.UUID) (bool, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	entry, ok := s.tokens[tokenID]
+	s.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := s.storage.IsTokenRevoked(ctx, tokenID)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[tokenID] = tokenEntry{revoked: revoked, expiresAt: now.Add(cacheTTL)}
+	s.sweepLocked(now)
+	return revoked, nil
+}
+
+func (s *Store) tokensValidAfter(ctx context.Context, userID uuid.UUID) (time.Time, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	entry, ok := s.users[userID]
+	s.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.validAfter, nil
+	}
+
+	validAfter, err := s.storage.GetTokensValidAfter(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[userID] = userEntry{validAfter: validAfter, expiresAt: now.Add(cacheTTL)}
+	s.sweepLocked(now)
+	return validAfter, nil
+}
+
+// sweepLocked удаляет устаревшие записи кеша. Вызывается под s.mu
+func (s *Store) sweepLocked(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	for id, entry := range s.tokens {
+		if now.After(entry.expiresAt) {
+			delete(s.tokens, id)
+		}
+	}
+	for id, entry := range s.users {
+		if now.After(entry.expiresAt) {
+			delete(s.users, id)
+		}
+	}
+	s.lastSweep = now
+}
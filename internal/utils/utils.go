@@ -13,6 +13,9 @@ func ValidateTelegramAuth(data url.Values, botToken string) bool {
 	// Извлекаем hash из данных
 	receivedHash := data.Get("hash")
 	data.Del("hash") // Убираем hash из проверяемых данных
+	if receivedHash == "" || len(data) == 0 {
+		return false
+	}
 
 	// Формируем строку из оставшихся параметров
 	var dataCheckString string
@@ -33,5 +36,5 @@ func ValidateTelegramAuth(data url.Values, botToken string) bool {
 	calculatedHash := hex.EncodeToString(h.Sum(nil))
 
 	// Сравниваем рассчитанный hash с переданным
-	return calculatedHash == receivedHash
+	return hmac.Equal([]byte(calculatedHash), []byte(receivedHash))
 }
@@ -0,0 +1,3 @@
+-- Удаляем ключи доступа
+DROP TABLE IF EXISTS webauthn_sessions;
+DROP TABLE IF EXISTS webauthn_credentials;
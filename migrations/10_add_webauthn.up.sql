@@ -0,0 +1,22 @@
+-- Ключи доступа (passkeys). Запись WebAuthn хранится целиком в JSON: открытый ключ, счетчик подписей, флаги
+CREATE TABLE webauthn_credentials (
+    id BYTEA PRIMARY KEY, -- идентификатор, который выдал аутентификатор
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    credential JSONB NOT NULL,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Индекс для поиска ключей пользователя
+CREATE INDEX idx_webauthn_credentials_user_id ON webauthn_credentials(user_id);
+
+-- Незавершенные церемонии регистрации и входа. Запись удаляется при завершении
+CREATE TABLE webauthn_sessions (
+    id UUID PRIMARY KEY,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE, -- пусто для входа, пользователь станет известен из ответа аутентификатора
+    purpose VARCHAR(16) NOT NULL, -- registration или login
+    data JSONB NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
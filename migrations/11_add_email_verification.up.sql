@@ -0,0 +1,18 @@
+-- Подтверждение email. Пока email_verified_at пуст, адрес не подтвержден
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+
+-- Пользователи, зарегистрированные до появления подтверждения, считаются подтвердившими адрес
+UPDATE users SET email_verified_at = created_at WHERE email IS NOT NULL;
+
+-- Выданные токены подтверждения. Сам токен подписан, здесь по его jti отмечается использование
+CREATE TABLE email_verification_tokens (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL, -- адрес, на который ушло письмо
+    used_at TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Индекс для поиска токенов пользователя
+CREATE INDEX idx_email_verification_tokens_user_id ON email_verification_tokens(user_id);
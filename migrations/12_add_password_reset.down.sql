@@ -0,0 +1,2 @@
+-- Удаляем токены сброса пароля
+DROP TABLE IF EXISTS password_reset_tokens;
@@ -0,0 +1,11 @@
+-- Токены сброса пароля. Хранится только SHA-256 токена, сам токен есть лишь в письме
+CREATE TABLE password_reset_tokens (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    used_at TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Индекс для поиска токенов пользователя
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens(user_id);
@@ -0,0 +1,2 @@
+-- Удаляем счетчики неудачных попыток входа
+DROP TABLE IF EXISTS login_throttles;
@@ -0,0 +1,9 @@
+-- Неудачные попытки входа по паролю. Счетчики ведутся отдельно для email (account:<email>)
+-- и для IP-адреса (ip:<адрес>), в том числе для email, которых нет в базе
+CREATE TABLE login_throttles (
+    key VARCHAR(320) PRIMARY KEY,
+    kind VARCHAR(16) NOT NULL, -- account или ip
+    failed_attempts INT NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP -- до этого момента попытки входа отклоняются без проверки пароля
+);
@@ -0,0 +1,2 @@
+-- Удаляем состояние ограничителей частоты запросов
+DROP TABLE IF EXISTS rate_limit_buckets;
@@ -0,0 +1,9 @@
+-- Состояние ограничителей частоты запросов для RATE_LIMIT_STORE=postgres (общее для всех экземпляров сервиса)
+CREATE TABLE rate_limit_buckets (
+    key VARCHAR(320) PRIMARY KEY, -- <политика>:<ip|user|client>:<идентификатор>
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    full_at TIMESTAMP NOT NULL -- когда bucket снова наполнится; после этого запись можно удалить
+);
+
+CREATE INDEX idx_rate_limit_buckets_full_at ON rate_limit_buckets(full_at);
@@ -0,0 +1,3 @@
+-- Удаляем права, добавленные миграцией (связи с ролями удалятся каскадно)
+DELETE FROM permissions
+WHERE id IN (SELECT md5(name)::uuid FROM (VALUES ('admin_auth'), ('admin_achievements'), ('admin_notifications'), ('admin_requests'), ('admin_files')) AS p(name));
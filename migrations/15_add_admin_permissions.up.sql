@@ -0,0 +1,6 @@
+-- Права администраторов, которые проверяют защищенные маршруты. Идентификаторы детерминированные,
+-- чтобы миграцию можно было откатить, не задев права, созданные вручную
+INSERT INTO permissions (id, name)
+SELECT md5(name)::uuid, name
+FROM (VALUES ('admin_auth'), ('admin_achievements'), ('admin_notifications'), ('admin_requests'), ('admin_files')) AS p(name)
+WHERE NOT EXISTS (SELECT 1 FROM permissions WHERE permissions.name = p.name);
@@ -0,0 +1,4 @@
+-- Убираем наследование ролей
+DROP INDEX IF EXISTS idx_roles_parent_id;
+ALTER TABLE roles DROP CONSTRAINT IF EXISTS roles_parent_not_self;
+ALTER TABLE roles DROP COLUMN IF EXISTS parent_id;
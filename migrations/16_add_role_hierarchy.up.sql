@@ -0,0 +1,5 @@
+-- Родительская роль: роль наследует все права родителя (и его предков). Циклы запрещает приложение
+ALTER TABLE roles ADD COLUMN parent_id UUID REFERENCES roles(id) ON DELETE SET NULL;
+ALTER TABLE roles ADD CONSTRAINT roles_parent_not_self CHECK (parent_id <> id);
+
+CREATE INDEX idx_roles_parent_id ON roles(parent_id);
@@ -0,0 +1,6 @@
+-- Убираем журнал аудита и ограничения уникальности ролей и прав
+DROP INDEX IF EXISTS idx_user_roles_user_role;
+DROP INDEX IF EXISTS idx_role_permissions_role_permission;
+DROP INDEX IF EXISTS idx_permissions_name;
+DROP INDEX IF EXISTS idx_roles_name;
+DROP TABLE IF EXISTS admin_audit_log;
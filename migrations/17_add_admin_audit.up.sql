@@ -0,0 +1,50 @@
+-- Журнал изменений ролей, прав и их назначений через админское API
+CREATE TABLE admin_audit_log (
+    id UUID PRIMARY KEY,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL, -- NULL, если изменение сделано из CLI
+    action VARCHAR(64) NOT NULL,
+    target_type VARCHAR(32) NOT NULL, -- role, permission или user
+    target_id UUID NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    ip VARCHAR(64),
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_admin_audit_log_created_at ON admin_audit_log(created_at);
+CREATE INDEX idx_admin_audit_log_target ON admin_audit_log(target_type, target_id);
+
+-- По названиям роли и права находятся приложением, поэтому они должны быть уникальными.
+-- Одноименные роли и права сливаются в ту, у которой меньший id: привязки и назначения переносятся на нее,
+-- а повторы, которые при этом появятся, убирает очистка ниже
+CREATE TEMP TABLE role_merges AS
+SELECT r.id AS old_id, keep.id AS new_id
+FROM roles r
+JOIN (SELECT DISTINCT ON (name) id, name FROM roles ORDER BY name, id) keep ON keep.name = r.name AND keep.id <> r.id;
+
+UPDATE role_permissions rp SET role_id = m.new_id FROM role_merges m WHERE rp.role_id = m.old_id;
+UPDATE user_roles ur SET role_id = m.new_id FROM role_merges m WHERE ur.role_id = m.old_id;
+-- Роль, наследовавшая от своего дубликата, остается без родителя: наследовать от себя нельзя
+UPDATE roles r SET parent_id = NULLIF(m.new_id, r.id) FROM role_merges m WHERE r.parent_id = m.old_id;
+DELETE FROM roles WHERE id IN (SELECT old_id FROM role_merges);
+DROP TABLE role_merges;
+
+CREATE TEMP TABLE permission_merges AS
+SELECT p.id AS old_id, keep.id AS new_id
+FROM permissions p
+JOIN (SELECT DISTINCT ON (name) id, name FROM permissions ORDER BY name, id) keep ON keep.name = p.name AND keep.id <> p.id;
+
+UPDATE role_permissions rp SET permission_id = m.new_id FROM permission_merges m WHERE rp.permission_id = m.old_id;
+DELETE FROM permissions WHERE id IN (SELECT old_id FROM permission_merges);
+DROP TABLE permission_merges;
+
+CREATE UNIQUE INDEX idx_roles_name ON roles(name);
+CREATE UNIQUE INDEX idx_permissions_name ON permissions(name);
+
+-- Повторная привязка ничего не дает, а мешает отвязке: убираем дубликаты и запрещаем новые
+DELETE FROM role_permissions a USING role_permissions b
+WHERE a.role_id = b.role_id AND a.permission_id = b.permission_id AND a.id > b.id;
+CREATE UNIQUE INDEX idx_role_permissions_role_permission ON role_permissions(role_id, permission_id);
+
+DELETE FROM user_roles a USING user_roles b
+WHERE a.user_id = b.user_id AND a.role_id = b.role_id AND a.id > b.id;
+CREATE UNIQUE INDEX idx_user_roles_user_role ON user_roles(user_id, role_id);
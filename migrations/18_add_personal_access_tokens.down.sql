@@ -0,0 +1,2 @@
+-- Удаляем персональные токены доступа
+DROP TABLE IF EXISTS personal_access_tokens;
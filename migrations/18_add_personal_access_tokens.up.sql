@@ -0,0 +1,16 @@
+-- Персональные токены доступа для скриптов и CI. Хранится только SHA-256 токена, сам токен показывается один раз
+CREATE TABLE personal_access_tokens (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL DEFAULT '{}', -- Права пользователя, доступные по токену
+    expires_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP,
+    last_used_ip VARCHAR(64),
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- Индекс для списка токенов пользователя
+CREATE INDEX idx_personal_access_tokens_user_id ON personal_access_tokens(user_id);
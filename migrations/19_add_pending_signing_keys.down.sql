@@ -0,0 +1,5 @@
+-- Ожидающие ключи ни разу не подписывали токены, их можно просто вывести из обращения
+UPDATE signing_keys SET state = 'retired', retired_at = NOW() WHERE state = 'pending';
+ALTER TABLE signing_keys DROP CONSTRAINT IF EXISTS signing_keys_state_check;
+ALTER TABLE signing_keys ADD CONSTRAINT signing_keys_state_check
+    CHECK (state IN ('active', 'retiring', 'retired'));
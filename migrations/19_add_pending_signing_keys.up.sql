@@ -0,0 +1,5 @@
+-- Ожидающий (pending) ключ уже опубликован в JWKS, но еще не подписывает токены. Его делают активным
+-- отдельной командой, когда кэши JWKS у проверяющих сервисов успели обновиться
+ALTER TABLE signing_keys DROP CONSTRAINT IF EXISTS signing_keys_state_check;
+ALTER TABLE signing_keys ADD CONSTRAINT signing_keys_state_check
+    CHECK (state IN ('pending', 'active', 'retiring', 'retired'));
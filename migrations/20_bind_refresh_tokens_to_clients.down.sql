@@ -0,0 +1,5 @@
+-- Токены приложений без привязки к клиенту стали бы токенами первой стороны, поэтому удаляем их
+DELETE FROM refresh_tokens WHERE client_id IS NOT NULL;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS auth_time;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS scope;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS client_id;
@@ -0,0 +1,13 @@
+-- Семейство refresh-токенов, выданное приложению через OpenID Connect, привязано к клиенту и выданному scope.
+-- auth_time - момент входа пользователя, с которого началось семейство; он сохраняется при каждой ротации
+ALTER TABLE refresh_tokens ADD COLUMN client_id VARCHAR(255) REFERENCES oauth_clients(id) ON DELETE CASCADE;
+ALTER TABLE refresh_tokens ADD COLUMN scope TEXT;
+ALTER TABLE refresh_tokens ADD COLUMN auth_time TIMESTAMP;
+
+-- Для уже выданных семейств моментом входа считаем выпуск их первого токена
+UPDATE refresh_tokens AS t SET auth_time = f.first_created_at
+FROM (SELECT family_id, MIN(created_at) AS first_created_at FROM refresh_tokens GROUP BY family_id) AS f
+WHERE t.family_id = f.family_id;
+
+ALTER TABLE refresh_tokens ALTER COLUMN auth_time SET DEFAULT NOW();
+ALTER TABLE refresh_tokens ALTER COLUMN auth_time SET NOT NULL;
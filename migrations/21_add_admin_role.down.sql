@@ -0,0 +1,7 @@
+-- Отвязываем права, привязанные миграцией, и удаляем роль Admin, если ее создала миграция
+DELETE FROM role_permissions
+WHERE id IN (
+    SELECT md5('role_permission:Admin:' || name)::uuid
+    FROM (VALUES ('admin_auth'), ('admin_achievements'), ('admin_notifications'), ('admin_requests'), ('admin_files')) AS p(name)
+);
+DELETE FROM roles WHERE id = md5('role:Admin')::uuid;
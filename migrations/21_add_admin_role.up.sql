@@ -0,0 +1,13 @@
+-- Роль Admin со всеми правами admin_*, чтобы первому администратору было что выдать (cmd/roles -action=grant-role).
+-- Если роль Admin уже есть, права добавляются к ней.
+-- Идентификаторы детерминированные, чтобы миграцию можно было откатить, не задев данные, созданные вручную
+INSERT INTO roles (id, name)
+SELECT md5('role:Admin')::uuid, 'Admin'
+WHERE NOT EXISTS (SELECT 1 FROM roles WHERE name = 'Admin');
+
+INSERT INTO role_permissions (id, role_id, permission_id)
+SELECT md5('role_permission:Admin:' || p.name)::uuid, r.id, p.id
+FROM roles r
+JOIN permissions p ON p.name IN ('admin_auth', 'admin_achievements', 'admin_notifications', 'admin_requests', 'admin_files')
+WHERE r.name = 'Admin'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
@@ -0,0 +1,2 @@
+-- Удаляем таблицу refresh-токенов
+DROP TABLE IF EXISTS refresh_tokens;
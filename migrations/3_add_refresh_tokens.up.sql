@@ -0,0 +1,17 @@
+-- Таблица выданных refresh-токенов. Каждый токен принадлежит семейству (family_id),
+-- которое создается при логине и сохраняется при каждой ротации.
+CREATE TABLE refresh_tokens (
+    id UUID PRIMARY KEY, -- jti refresh-токена
+    family_id UUID NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    rotated_at TIMESTAMP, -- момент, когда токен был обменян на новый
+    revoked_at TIMESTAMP, -- момент отзыва всего семейства
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Индекс для отзыва всего семейства токенов
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+
+-- Индекс для поиска токенов пользователя
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens(user_id);
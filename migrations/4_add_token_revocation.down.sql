@@ -0,0 +1,5 @@
+-- Удаляем таблицу отозванных токенов
+DROP TABLE IF EXISTS revoked_tokens;
+
+-- Удаляем отметку времени отзыва токенов пользователя
+ALTER TABLE users DROP COLUMN IF EXISTS tokens_valid_after;
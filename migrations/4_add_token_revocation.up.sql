@@ -0,0 +1,13 @@
+-- Токены, выпущенные раньше этого момента, считаются отозванными (выход со всех устройств)
+ALTER TABLE users ADD COLUMN tokens_valid_after TIMESTAMP;
+
+-- Отозванные access-токены. Записи нужны только до истечения срока действия токена
+CREATE TABLE revoked_tokens (
+    jti UUID PRIMARY KEY,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Индекс для очистки устаревших записей
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
@@ -0,0 +1,2 @@
+-- Удаляем связку ключей подписи
+DROP TABLE IF EXISTS signing_keys;
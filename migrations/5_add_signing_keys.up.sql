@@ -0,0 +1,14 @@
+-- Связка ключей подписи JWT. Активный ключ подписывает новые токены, уходящие (retiring)
+-- только проверяют ранее выданные, выведенные (retired) больше не принимаются
+CREATE TABLE signing_keys (
+    id VARCHAR(255) PRIMARY KEY, -- kid
+    algorithm VARCHAR(16) NOT NULL CHECK (algorithm IN ('HS256', 'RS256', 'EdDSA')),
+    private_key TEXT NOT NULL, -- PEM для RS256/EdDSA, base64 секрета для HS256
+    state VARCHAR(16) NOT NULL CHECK (state IN ('active', 'retiring', 'retired')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    activated_at TIMESTAMP,
+    retired_at TIMESTAMP
+);
+
+-- Активным может быть только один ключ
+CREATE UNIQUE INDEX idx_signing_keys_single_active ON signing_keys(state) WHERE state = 'active';
@@ -0,0 +1,3 @@
+-- Удаляем коды авторизации и клиентов OpenID Connect
+DROP TABLE IF EXISTS oauth_authorization_codes;
+DROP TABLE IF EXISTS oauth_clients;
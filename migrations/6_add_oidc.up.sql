@@ -0,0 +1,25 @@
+-- Зарегистрированные клиенты (приложения ITaM), которые входят через OpenID Connect
+CREATE TABLE oauth_clients (
+    id VARCHAR(255) PRIMARY KEY, -- client_id
+    name VARCHAR(255) NOT NULL,
+    secret_hash VARCHAR(255), -- bcrypt-хеш секрета, NULL для публичных клиентов (SPA, мобильные приложения)
+    redirect_uris TEXT[] NOT NULL DEFAULT '{}', -- разрешенные redirect_uri, сравниваются посимвольно
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Одноразовые коды авторизации. Хранится только SHA-256 кода
+CREATE TABLE oauth_authorization_codes (
+    code_hash VARCHAR(64) PRIMARY KEY,
+    client_id VARCHAR(255) REFERENCES oauth_clients(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    redirect_uri TEXT NOT NULL,
+    scope TEXT NOT NULL,
+    nonce TEXT,
+    code_challenge VARCHAR(128) NOT NULL,
+    auth_time TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Индекс для очистки просроченных кодов
+CREATE INDEX idx_oauth_authorization_codes_expires_at ON oauth_authorization_codes(expires_at);
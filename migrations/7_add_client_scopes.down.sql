@@ -0,0 +1,2 @@
+-- Удаляем scope сервисных аккаунтов
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS scopes;
@@ -0,0 +1,2 @@
+-- Scope, которые конфиденциальный клиент (сервисный аккаунт) может получить через client_credentials
+ALTER TABLE oauth_clients ADD COLUMN scopes TEXT[] NOT NULL DEFAULT '{}';
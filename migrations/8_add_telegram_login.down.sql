@@ -0,0 +1,6 @@
+-- Пользователям, вошедшим через Telegram, проставляем заглушки, чтобы вернуть NOT NULL без удаления данных
+UPDATE users SET email = 'telegram_' || COALESCE(telegram_id::TEXT, id::TEXT) || '@telegram.invalid' WHERE email IS NULL;
+UPDATE users SET password_hash = '' WHERE password_hash IS NULL;
+ALTER TABLE users ALTER COLUMN password_hash SET NOT NULL;
+ALTER TABLE users ALTER COLUMN email SET NOT NULL;
+ALTER TABLE users DROP COLUMN IF EXISTS telegram_id;
@@ -0,0 +1,5 @@
+-- Вход через Telegram: аккаунт привязывается по Telegram ID. У пользователей,
+-- зарегистрированных через Telegram, нет ни email, ни пароля
+ALTER TABLE users ADD COLUMN telegram_id BIGINT UNIQUE;
+ALTER TABLE users ALTER COLUMN email DROP NOT NULL;
+ALTER TABLE users ALTER COLUMN password_hash DROP NOT NULL;
@@ -0,0 +1,22 @@
+-- TOTP второго фактора. Пока confirmed_at пуст, подключение не завершено и при входе не требуется
+CREATE TABLE user_mfa (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    totp_secret VARCHAR(64) NOT NULL, -- секрет в base32
+    last_used_step BIGINT NOT NULL DEFAULT 0, -- номер последнего принятого 30-секундного интервала, защищает от повторного ввода кода
+    failed_attempts INT NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP, -- после серии неверных кодов ввод блокируется до этого момента
+    confirmed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Одноразовые коды восстановления на случай потери устройства. Хранится только SHA-256 кода
+CREATE TABLE mfa_recovery_codes (
+    id UUID PRIMARY KEY,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Индекс для поиска кодов пользователя
+CREATE INDEX idx_mfa_recovery_codes_user_id ON mfa_recovery_codes(user_id);
@@ -0,0 +1,73 @@
+package authclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+)
+
+// GetAchievements возвращает все достижения
+func (c *Client) GetAchievements(ctx context.Context, page Page) ([]Achievement, error) {
+	var achievements []Achievement
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/get_all_achievements", query: pageQuery(page)}, &achievements); err != nil {
+		return nil, err
+	}
+	return achievements, nil
+}
+
+// GetUserAchievements возвращает достижения пользователя
+func (c *Client) GetUserAchievements(ctx context.Context, userID uuid.UUID, page Page) ([]Achievement, error) {
+	query := pageQuery(page)
+	query.Set("user_id", userID.String())
+
+	var achievements []Achievement
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/get_user_achievements", query: query}, &achievements); err != nil {
+		return nil, err
+	}
+	return achievements, nil
+}
+
+// GetAchievement возвращает достижение по ID
+func (c *Client) GetAchievement(ctx context.Context, achievementID uuid.UUID) (*Achievement, error) {
+	query := url.Values{"achievement_id": {achievementID.String()}}
+
+	var achievement Achievement
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/get_achievement", query: query}, &achievement); err != nil {
+		return nil, err
+	}
+	return &achievement, nil
+}
+
+// CreateAchievement выдает достижение пользователю achievement.UserID и возвращает его ID.
+// Нужно право admin_achievements
+func (c *Client) CreateAchievement(ctx context.Context, achievement Achievement) (uuid.UUID, error) {
+	req, err := jsonRequest(http.MethodPost, "/create_achievement", achievement)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	var resp struct {
+		ID uuid.UUID `json:"id"`
+	}
+	if err := c.do(ctx, req, &resp); err != nil {
+		return uuid.Nil, err
+	}
+	return resp.ID, nil
+}
+
+// UpdateAchievement обновляет достижение с achievement.ID. Нужно право admin_achievements
+func (c *Client) UpdateAchievement(ctx context.Context, achievement Achievement) error {
+	req, err := jsonRequest(http.MethodPatch, "/update_achievement", achievement)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, req, nil)
+}
+
+// DeleteAchievement удаляет достижение. Нужно право admin_achievements
+func (c *Client) DeleteAchievement(ctx context.Context, achievementID uuid.UUID) error {
+	query := url.Values{"achievement_id": {achievementID.String()}}
+	return c.do(ctx, request{method: http.MethodDelete, path: "/delete_achievement", query: query}, nil)
+}
@@ -0,0 +1,248 @@
+// Package authclient - клиент API сервиса авторизации ITaM (/auth/api/*) для ботов и сервисов.
+//
+// Клиент хранит пару токенов, сам обновляет access-токен незадолго до истечения и при ответе 401,
+// а OnTokens позволяет сохранить выданные токены. Ошибки API возвращаются как *APIError и
+// сопоставляются с ErrUnauthorized, ErrNotFound и другими через errors.Is.
+package authclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	apiPrefix      = "/auth/api"
+	defaultTimeout = 30 * time.Second
+	// Access-токен обновляется заранее, чтобы он не истек по дороге к сервису
+	refreshLeeway = 30 * time.Second
+)
+
+// Options - настройки клиента. Нулевые значения заменяются значениями по умолчанию
+type Options struct {
+	HTTPClient *http.Client
+	// OnTokens вызывается после входа и каждого обновления токенов, например чтобы сохранить их между запусками.
+	// Refresh-токен одноразовый: старый после обновления недействителен
+	OnTokens func(Tokens)
+	// OnRefreshError вызывается, если токены не удалось обновить. Если сервис отклонил refresh-токен,
+	// клиент забывает токены и нужен повторный вход
+	OnRefreshError func(error)
+}
+
+// Client - клиент API. Безопасен для использования из нескольких горутин
+type Client struct {
+	baseURL string
+	options Options
+
+	mu     sync.Mutex
+	tokens Tokens
+	// refreshMu не дает обновлять токены параллельно: повторное предъявление refresh-токена отзывает сессию
+	refreshMu sync.Mutex
+}
+
+// New создает клиент для сервиса по адресу baseURL, например https://auth.itam.com
+func New(baseURL string, options Options) *Client {
+	if options.HTTPClient == nil {
+		options.HTTPClient = &http.Client{Timeout: defaultTimeout}
+	}
+	return &Client{baseURL: strings.TrimRight(baseURL, "/"), options: options}
+}
+
+// Tokens возвращает текущую пару токенов
+func (c *Client) Tokens() Tokens {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tokens
+}
+
+// SetTokens задает токены, например сохраненные ранее или полученные сервисным аккаунтом через
+// /auth/oidc/token. Если Expiry не задан, он вычисляется по ExpiresIn
+func (c *Client) SetTokens(tokens Tokens) {
+	if tokens.Expiry.IsZero() && tokens.ExpiresIn > 0 {
+		tokens.Expiry = time.Now().Add(time.Duration(tokens.ExpiresIn) * time.Second)
+	}
+	c.mu.Lock()
+	c.tokens = tokens
+	c.mu.Unlock()
+}
+
+// storeTokens сохраняет выданные сервисом токены и сообщает о них через OnTokens
+func (c *Client) storeTokens(tokens Tokens) Tokens {
+	c.SetTokens(tokens)
+	tokens = c.Tokens()
+	if c.options.OnTokens != nil {
+		c.options.OnTokens(tokens)
+	}
+	return tokens
+}
+
+// request - запрос к API. Тело хранится целиком, чтобы повторить запрос после обновления токенов
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        []byte
+	contentType string
+	// public - запрос без access-токена
+	public bool
+}
+
+func jsonRequest(method, path string, payload any) (request, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return request{}, fmt.Errorf("failed to encode request: %w", err)
+	}
+	return request{method: method, path: path, body: body, contentType: "application/json"}, nil
+}
+
+// do выполняет запрос и декодирует ответ 2xx в out (если out не nil). Запрос с токеном повторяется один раз,
+// если сервис ответил 401, а токены удалось обновить
+func (c *Client) do(ctx context.Context, req request, out any) error {
+	if req.public {
+		return c.send(ctx, req, "", out)
+	}
+
+	tokens, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	err = c.send(ctx, req, tokens.AccessToken, out)
+	if !errors.Is(err, ErrUnauthorized) || tokens.RefreshToken == "" {
+		return err
+	}
+
+	// Токен мог быть отозван или подписан выведенным ключом: пробуем получить новый
+	if _, refreshErr := c.refresh(ctx, tokens.AccessToken); refreshErr != nil {
+		return err
+	}
+	tokens = c.Tokens()
+	return c.send(ctx, req, tokens.AccessToken, out)
+}
+
+// accessToken возвращает действующий access-токен, при необходимости обновив его заранее
+func (c *Client) accessToken(ctx context.Context) (Tokens, error) {
+	tokens := c.Tokens()
+	if tokens.AccessToken == "" {
+		return Tokens{}, ErrNotAuthenticated
+	}
+	expiring := !tokens.Expiry.IsZero() && time.Until(tokens.Expiry) < refreshLeeway
+	if !expiring || tokens.RefreshToken == "" {
+		return tokens, nil
+	}
+	return c.refresh(ctx, tokens.AccessToken)
+}
+
+// refresh обменивает refresh-токен на новую пару. stale - access-токен, который нужно заменить:
+// если другая горутина уже обновила токены, повторно refresh-токен не предъявляется
+func (c *Client) refresh(ctx context.Context, stale string) (Tokens, error) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	current := c.Tokens()
+	if current.AccessToken != stale {
+		return current, nil
+	}
+	if current.RefreshToken == "" {
+		return Tokens{}, ErrNotAuthenticated
+	}
+
+	req, err := jsonRequest(http.MethodPost, "/refresh", map[string]string{"refresh_token": current.RefreshToken})
+	if err != nil {
+		return Tokens{}, err
+	}
+	req.public = true
+
+	var tokens Tokens
+	if err := c.send(ctx, req, "", &tokens); err != nil {
+		// Отклоненный refresh-токен больше не пригодится; сетевую ошибку или сбой сервиса можно переждать
+		if errors.Is(err, ErrUnauthorized) {
+			c.SetTokens(Tokens{})
+		}
+		if c.options.OnRefreshError != nil {
+			c.options.OnRefreshError(err)
+		}
+		return Tokens{}, err
+	}
+	return c.storeTokens(tokens), nil
+}
+
+// send отправляет запрос один раз
+func (c *Client) send(ctx context.Context, req request, accessToken string, out any) error {
+	target := c.baseURL + apiPrefix + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+	if accessToken != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	resp, err := c.options.HTTPClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", req.path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", req.path, err)
+	}
+	return nil
+}
+
+func decodeError(resp *http.Response) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	var payload struct {
+		Error   string `json:"error"`
+		Details string `json:"details"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err == nil {
+		apiErr.Message = payload.Error
+		apiErr.Details = payload.Details
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return apiErr
+}
+
+func pageQuery(page Page) url.Values {
+	query := url.Values{}
+	if page.Limit > 0 {
+		query.Set("limit", strconv.Itoa(page.Limit))
+	}
+	if page.Offset > 0 {
+		query.Set("offset", strconv.Itoa(page.Offset))
+	}
+	return query
+}
@@ -0,0 +1,70 @@
+package authclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Ошибки по статусу ответа. Проверяются через errors.Is, подробности - через errors.As с *APIError
+var (
+	ErrBadRequest   = errors.New("authclient: bad request")
+	ErrUnauthorized = errors.New("authclient: unauthorized")
+	ErrForbidden    = errors.New("authclient: forbidden")
+	ErrNotFound     = errors.New("authclient: not found")
+	ErrConflict     = errors.New("authclient: conflict")
+	ErrRateLimited  = errors.New("authclient: rate limited")
+	ErrServer       = errors.New("authclient: server error")
+)
+
+// ErrNotAuthenticated возвращается методам, которым нужен токен, если клиент еще не вошел
+var ErrNotAuthenticated = errors.New("authclient: not authenticated")
+
+// APIError - ответ сервиса авторизации с ошибкой ({"error": ..., "details": ...})
+type APIError struct {
+	StatusCode int
+	Message    string
+	Details    string
+	// RetryAfter - из заголовка Retry-After при 429
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if e.Details != "" {
+		return fmt.Sprintf("authclient: %d %s: %s", e.StatusCode, e.Message, e.Details)
+	}
+	return fmt.Sprintf("authclient: %d %s", e.StatusCode, e.Message)
+}
+
+// Is сопоставляет ошибку с ErrUnauthorized, ErrNotFound и т.д. по статусу ответа
+func (e *APIError) Is(target error) bool {
+	switch e.StatusCode {
+	case http.StatusBadRequest:
+		return target == ErrBadRequest
+	case http.StatusUnauthorized:
+		return target == ErrUnauthorized
+	case http.StatusForbidden:
+		return target == ErrForbidden
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	case http.StatusConflict:
+		return target == ErrConflict
+	case http.StatusTooManyRequests:
+		return target == ErrRateLimited
+	}
+	return e.StatusCode >= http.StatusInternalServerError && target == ErrServer
+}
+
+// MFARequiredError возвращает Login, если у пользователя включен второй фактор.
+// Вход завершается через LoginMFA с MFAToken
+type MFARequiredError struct {
+	MFAToken string
+	// EnrollmentRequired - второй фактор обязателен, но еще не подключен
+	EnrollmentRequired bool
+	ExpiresIn          int
+}
+
+func (e *MFARequiredError) Error() string {
+	return "authclient: second factor required"
+}
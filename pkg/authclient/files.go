@@ -0,0 +1,92 @@
+package authclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+// UploadProfileImage загружает фото профиля (JPEG, PNG, GIF, WebP, до 10 МБ)
+func (c *Client) UploadProfileImage(ctx context.Context, filename string, content io.Reader) (*UploadedFile, error) {
+	return c.upload(ctx, "/upload_profile_image", "image", filename, content, nil)
+}
+
+// UploadAchievementImage загружает изображение достижения. Нужно право admin_achievements
+func (c *Client) UploadAchievementImage(ctx context.Context, achievementID uuid.UUID, filename string, content io.Reader) (*UploadedFile, error) {
+	fields := map[string]string{"achievement_id": achievementID.String()}
+	return c.upload(ctx, "/upload_achievement_image", "image", filename, content, fields)
+}
+
+// UploadResume загружает резюме (PDF, DOC, DOCX, до 10 МБ)
+func (c *Client) UploadResume(ctx context.Context, filename string, content io.Reader) (*UploadedFile, error) {
+	return c.upload(ctx, "/upload_resume", "resume", filename, content, nil)
+}
+
+// GetUserFiles возвращает файлы, загруженные текущим пользователем
+func (c *Client) GetUserFiles(ctx context.Context) ([]FileInfo, error) {
+	var files []FileInfo
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/get_user_files"}, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// DeleteFile удаляет загруженный файл
+func (c *Client) DeleteFile(ctx context.Context, fileID uuid.UUID) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: "/delete_file/" + url.PathEscape(fileID.String())}, nil)
+}
+
+// upload отправляет файл формой multipart/form-data. Файл читается в память целиком (он не больше 10 МБ),
+// чтобы запрос можно было повторить после обновления токенов
+func (c *Client) upload(ctx context.Context, path, field, filename string, content io.Reader, fields map[string]string) (*UploadedFile, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	// Сервис проверяет тип файла по Content-Type части формы
+	contentType := mime.TypeByExtension(filepath.Ext(filename))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			return nil, fmt.Errorf("failed to build form: %w", err)
+		}
+	}
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": field, "filename": filepath.Base(filename)}))
+	header.Set("Content-Type", contentType)
+	part, err := form.CreatePart(header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build form: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to build form: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build form: %w", err)
+	}
+
+	req := request{method: http.MethodPost, path: path, body: body.Bytes(), contentType: form.FormDataContentType()}
+
+	var resp struct {
+		File UploadedFile `json:"file"`
+	}
+	if err := c.do(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.File, nil
+}
@@ -0,0 +1,59 @@
+package authclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+)
+
+// GetNotifications возвращает уведомления пользователя. uuid.Nil - уведомления текущего пользователя,
+// а для администратора (admin_notifications) и сервисного аккаунта - все
+func (c *Client) GetNotifications(ctx context.Context, userID uuid.UUID, page Page) ([]Notification, error) {
+	query := pageQuery(page)
+	if userID != uuid.Nil {
+		query.Set("user_id", userID.String())
+	}
+
+	var notifications []Notification
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/get_all_notifications", query: query}, &notifications); err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// GetNotification возвращает уведомление по ID
+func (c *Client) GetNotification(ctx context.Context, notificationID uuid.UUID) (*Notification, error) {
+	req := request{method: http.MethodGet, path: "/get_notification/" + url.PathEscape(notificationID.String())}
+
+	var notification Notification
+	if err := c.do(ctx, req, &notification); err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// CreateNotification отправляет уведомление пользователю. Нужно право admin_notifications
+func (c *Client) CreateNotification(ctx context.Context, userID uuid.UUID, content string) error {
+	req, err := jsonRequest(http.MethodPost, "/create_notification", Notification{UserID: userID, Content: content})
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, req, nil)
+}
+
+// UpdateNotification обновляет уведомление с notification.ID. Нужно право admin_notifications
+func (c *Client) UpdateNotification(ctx context.Context, notification Notification) error {
+	req, err := jsonRequest(http.MethodPatch, "/update_notification", notification)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, req, nil)
+}
+
+// DeleteNotification удаляет уведомление
+func (c *Client) DeleteNotification(ctx context.Context, notificationID uuid.UUID) error {
+	query := url.Values{"notification_id": {notificationID.String()}}
+	return c.do(ctx, request{method: http.MethodDelete, path: "/delete_notification", query: query}, nil)
+}
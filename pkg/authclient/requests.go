@@ -0,0 +1,57 @@
+package authclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+)
+
+// CreateRequest создает заявку от имени текущего пользователя и возвращает ее ID
+func (c *Client) CreateRequest(ctx context.Context, input NewRequest) (uuid.UUID, error) {
+	req, err := jsonRequest(http.MethodPost, "/create_user_request", input)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	var resp struct {
+		RequestID uuid.UUID `json:"request_id"`
+	}
+	if err := c.do(ctx, req, &resp); err != nil {
+		return uuid.Nil, err
+	}
+	return resp.RequestID, nil
+}
+
+// GetRequests возвращает заявки пользователя. uuid.Nil - заявки текущего пользователя;
+// чужие доступны с правом admin_requests
+func (c *Client) GetRequests(ctx context.Context, userID uuid.UUID, page Page) ([]Request, error) {
+	query := pageQuery(page)
+	if userID != uuid.Nil {
+		query.Set("user_id", userID.String())
+	}
+
+	var resp struct {
+		Data []Request `json:"data"`
+	}
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/get_all_requests", query: query}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// UpdateRequestStatus меняет статус заявки. Нужно право admin_requests
+func (c *Client) UpdateRequestStatus(ctx context.Context, requestID uuid.UUID, status string) error {
+	req, err := jsonRequest(http.MethodPatch, "/update_request_status", map[string]any{"request_id": requestID, "status": status})
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, req, nil)
+}
+
+// DeleteRequest удаляет заявку
+func (c *Client) DeleteRequest(ctx context.Context, requestID uuid.UUID) error {
+	query := url.Values{"request_id": {requestID.String()}}
+	return c.do(ctx, request{method: http.MethodDelete, path: "/delete_request", query: query}, nil)
+}
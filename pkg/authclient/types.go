@@ -0,0 +1,112 @@
+package authclient
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tokens - пара токенов, выданная при входе или обновлении
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	// Expiry - когда истечет access-токен. Заполняется клиентом; нулевое значение - срок неизвестен
+	Expiry time.Time `json:"-"`
+}
+
+// User - профиль пользователя
+type User struct {
+	ID            uuid.UUID `json:"id"`
+	Name          string    `json:"name"`
+	Email         string    `json:"email"`
+	Telegram      *string   `json:"telegram,omitempty"`
+	TelegramID    *int64    `json:"telegram_id,omitempty"`
+	EmailVerified bool      `json:"email_verified"`
+	PhotoURL      *string   `json:"photo_url,omitempty"`
+	About         *string   `json:"about,omitempty"`
+	ResumeURL     *string   `json:"resume_url,omitempty"`
+	Specification string    `json:"specification"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ProfileUpdate - изменяемые поля профиля. Поля, которые не переданы, очищаются, как и в API
+type ProfileUpdate struct {
+	Name          string  `json:"name"`
+	Specification string  `json:"specification"`
+	About         *string `json:"about,omitempty"`
+	PhotoURL      *string `json:"photo_url,omitempty"`
+	Telegram      *string `json:"telegram,omitempty"`
+	ResumeURL     *string `json:"resume_url,omitempty"`
+}
+
+// Request - заявка пользователя
+type Request struct {
+	ID          uuid.UUID `json:"ID"`
+	UserID      uuid.UUID `json:"UserID"`
+	Description string    `json:"Description"`
+	Certificate string    `json:"Certificate"`
+	Status      string    `json:"Status"`
+	Type        string    `json:"Type"`
+	CreatedAt   time.Time `json:"CreatedAt"`
+}
+
+// NewRequest - данные новой заявки. Без Status заявка создается в статусе pending
+type NewRequest struct {
+	Description string `json:"description"`
+	Certificate string `json:"certificate,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Type        string `json:"type"`
+}
+
+// Achievement - достижение пользователя
+type Achievement struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Title       string    `json:"title"`
+	Description *string   `json:"description,omitempty"`
+	Points      float64   `json:"points"`
+	Approved    bool      `json:"approved"`
+	ImageURL    *string   `json:"image_url,omitempty"`
+	CreatedBy   int64     `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Notification - уведомление пользователя
+type Notification struct {
+	ID        uuid.UUID `json:"ID"`
+	UserID    uuid.UUID `json:"UserID"`
+	Content   string    `json:"Content"`
+	IsRead    bool      `json:"IsRead"`
+	CreatedAt time.Time `json:"CreatedAt"`
+}
+
+// UploadedFile - файл, загруженный через upload_*
+type UploadedFile struct {
+	ID           uuid.UUID `json:"id"`
+	FileName     string    `json:"fileName"`
+	OriginalName string    `json:"originalName"`
+	FileSize     int64     `json:"fileSize"`
+	MimeType     string    `json:"mimeType"`
+	URL          string    `json:"url"`
+}
+
+// FileInfo - файл из списка файлов пользователя
+type FileInfo struct {
+	ID           uuid.UUID  `json:"id"`
+	FileName     string     `json:"fileName"`
+	OriginalName string     `json:"originalName"`
+	FileSize     int64      `json:"fileSize"`
+	MimeType     string     `json:"mimeType"`
+	UploadType   string     `json:"uploadType"`
+	EntityID     *uuid.UUID `json:"entityID,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// Page - пагинация списков. Нулевой Limit - значение по умолчанию сервиса (10)
+type Page struct {
+	Limit  int
+	Offset int
+}
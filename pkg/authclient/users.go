@@ -0,0 +1,95 @@
+package authclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+)
+
+// loginResponse - ответ входа: пара токенов (200) или MFA-челлендж (202)
+type loginResponse struct {
+	Tokens
+	MFARequired           bool   `json:"mfa_required"`
+	MFAToken              string `json:"mfa_token"`
+	MFAEnrollmentRequired bool   `json:"mfa_enrollment_required"`
+}
+
+// Login входит по email и паролю. Если нужен второй фактор, возвращает *MFARequiredError
+func (c *Client) Login(ctx context.Context, email, password string) (Tokens, error) {
+	return c.login(ctx, "/login", map[string]string{"email": email, "password": password})
+}
+
+// LoginMFA завершает вход кодом TOTP или кодом восстановления
+func (c *Client) LoginMFA(ctx context.Context, mfaToken, code string) (Tokens, error) {
+	return c.login(ctx, "/login/mfa", map[string]string{"mfa_token": mfaToken, "code": code})
+}
+
+func (c *Client) login(ctx context.Context, path string, payload any) (Tokens, error) {
+	req, err := jsonRequest(http.MethodPost, path, payload)
+	if err != nil {
+		return Tokens{}, err
+	}
+	req.public = true
+
+	var resp loginResponse
+	if err := c.do(ctx, req, &resp); err != nil {
+		return Tokens{}, err
+	}
+	if resp.MFARequired {
+		return Tokens{}, &MFARequiredError{
+			MFAToken:           resp.MFAToken,
+			EnrollmentRequired: resp.MFAEnrollmentRequired,
+			ExpiresIn:          resp.ExpiresIn,
+		}
+	}
+	return c.storeTokens(resp.Tokens), nil
+}
+
+// Refresh обновляет токены вне очереди. Обычно это не нужно: клиент обновляет их сам
+func (c *Client) Refresh(ctx context.Context) (Tokens, error) {
+	return c.refresh(ctx, c.Tokens().AccessToken)
+}
+
+// Logout отзывает токены текущей сессии и забывает их
+func (c *Client) Logout(ctx context.Context) error {
+	req, err := jsonRequest(http.MethodPost, "/logout", map[string]string{"refresh_token": c.Tokens().RefreshToken})
+	if err != nil {
+		return err
+	}
+	if err := c.do(ctx, req, nil); err != nil {
+		return err
+	}
+	c.SetTokens(Tokens{})
+	return nil
+}
+
+// Me возвращает профиль текущего пользователя
+func (c *Client) Me(ctx context.Context) (*User, error) {
+	var user User
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/me"}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUser возвращает профиль пользователя по ID. Токен не нужен
+func (c *Client) GetUser(ctx context.Context, userID uuid.UUID) (*User, error) {
+	req := request{method: http.MethodGet, path: "/get_user/" + url.PathEscape(userID.String()), public: true}
+
+	var user User
+	if err := c.do(ctx, req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateProfile обновляет профиль текущего пользователя
+func (c *Client) UpdateProfile(ctx context.Context, update ProfileUpdate) error {
+	req, err := jsonRequest(http.MethodPatch, "/update_user_info", update)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, req, nil)
+}
@@ -0,0 +1,80 @@
+package tokenauth
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Claims - содержимое access-токена сервиса авторизации ITaM. Токен пользователя несет uid, токен
+// сервисного аккаунта вместо него - cid и выданные scope. Роли, права, имя и специализация есть в токене,
+// только если включены в JWT_CLAIMS сервиса авторизации
+type Claims struct {
+	UID           string   `json:"uid,omitempty"`
+	Email         string   `json:"email,omitempty"`
+	Name          string   `json:"name,omitempty"`
+	Specification string   `json:"specification,omitempty"`
+	Roles         []string `json:"roles,omitempty"`
+	Perms         string   `json:"perms,omitempty"` // Права через пробел; список возвращает Permissions
+	AdminServices []string `json:"admin_services,omitempty"`
+	ClientID      string   `json:"cid,omitempty"`
+	Scope         string   `json:"scope,omitempty"`
+	TokenType     string   `json:"typ,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// IsService сообщает, что токен выдан сервисному аккаунту, а не пользователю
+func (c *Claims) IsService() bool {
+	return c.UID == "" && c.ClientID != ""
+}
+
+// UserID возвращает ID пользователя. Для токена сервисного аккаунта - uuid.Nil
+func (c *Claims) UserID() uuid.UUID {
+	id, err := uuid.Parse(c.UID)
+	if err != nil {
+		return uuid.Nil
+	}
+	return id
+}
+
+// Scopes возвращает scope, выданные сервисному аккаунту
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// HasScope проверяет, что сервисному аккаунту выдан scope
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes(), scope)
+}
+
+// Permissions возвращает права пользователя на момент выпуска токена
+func (c *Claims) Permissions() []string {
+	return strings.Fields(c.Perms)
+}
+
+// HasPermission сообщает, есть ли у пользователя хотя бы одно из прав. Права admin_<service> проверяются
+// и по admin_services, поэтому работают и без права permissions в JWT_CLAIMS
+func (c *Claims) HasPermission(names ...string) bool {
+	permissions := c.Permissions()
+	for _, name := range names {
+		if slices.Contains(permissions, name) {
+			return true
+		}
+		if service, ok := strings.CutPrefix(name, adminPrefix); ok && c.IsAdmin(service) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdmin сообщает, что пользователь администрирует сервис (у него есть право admin_<service>)
+func (c *Claims) IsAdmin(service string) bool {
+	return slices.Contains(c.AdminServices, service)
+}
+
+// HasRole сообщает, что пользователю напрямую назначена роль
+func (c *Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
@@ -0,0 +1,71 @@
+package tokenauth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Ключ gin.Context, под которым GinMiddleware хранит claims
+const GinClaimsKey = "claims"
+
+// GinMiddleware - Middleware для gin. Claims доступны через GinClaims и через контекст запроса
+func GinMiddleware(v *Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := v.Verify(c.Request.Context(), TokenFromRequest(c.Request))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": unauthorizedMessage(err)})
+			return
+		}
+
+		c.Set(GinClaimsKey, claims)
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), claims))
+		c.Next()
+	}
+}
+
+// GinClaims возвращает claims, которые положил GinMiddleware
+func GinClaims(c *gin.Context) (*Claims, bool) {
+	value, exists := c.Get(GinClaimsKey)
+	if !exists {
+		return nil, false
+	}
+	claims, ok := value.(*Claims)
+	return claims, ok
+}
+
+// GinRequirePermission - RequirePermission для gin
+func GinRequirePermission(permissions ...string) gin.HandlerFunc {
+	return ginRequire(func(claims *Claims) bool {
+		return !claims.IsService() && claims.HasPermission(permissions...)
+	})
+}
+
+// GinRequireAdmin - RequireAdmin для gin
+func GinRequireAdmin(service string) gin.HandlerFunc {
+	return ginRequire(func(claims *Claims) bool {
+		return !claims.IsService() && claims.IsAdmin(service)
+	})
+}
+
+// GinRequireScope - RequireScope для gin
+func GinRequireScope(scope string) gin.HandlerFunc {
+	return ginRequire(func(claims *Claims) bool {
+		return !claims.IsService() || claims.HasScope(scope)
+	})
+}
+
+func ginRequire(allowed func(*Claims) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := GinClaims(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+			return
+		}
+		if !allowed(claims) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			return
+		}
+		c.Next()
+	}
+}
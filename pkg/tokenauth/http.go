@@ -0,0 +1,98 @@
+package tokenauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+type contextKey struct{}
+
+// NewContext возвращает контекст с claims проверенного токена
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, contextKey{}, claims)
+}
+
+// FromContext возвращает claims, которые положил Middleware или GinMiddleware
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*Claims)
+	return claims, ok
+}
+
+// TokenFromRequest берет токен из заголовка Authorization. Префикс Bearer необязателен,
+// как и в сервисе авторизации
+func TokenFromRequest(r *http.Request) string {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return strings.TrimSpace(token)
+}
+
+// Middleware пропускает запрос с действительным токеном и кладет его claims в контекст запроса.
+// Иначе отвечает 401
+func Middleware(v *Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, err := v.Verify(r.Context(), TokenFromRequest(r))
+			if err != nil {
+				writeError(w, http.StatusUnauthorized, unauthorizedMessage(err))
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), claims)))
+		})
+	}
+}
+
+// RequirePermission пропускает пользователя, у которого есть хотя бы одно из прав. Сервисные аккаунты
+// не пропускает: у них нет прав, только scope. Ставится после Middleware
+func RequirePermission(permissions ...string) func(http.Handler) http.Handler {
+	return require(func(claims *Claims) bool {
+		return !claims.IsService() && claims.HasPermission(permissions...)
+	})
+}
+
+// RequireAdmin пропускает администратора сервиса (право admin_<service>). Ставится после Middleware
+func RequireAdmin(service string) func(http.Handler) http.Handler {
+	return require(func(claims *Claims) bool {
+		return !claims.IsService() && claims.IsAdmin(service)
+	})
+}
+
+// RequireScope пропускает сервисный аккаунт, только если ему выдан scope. Пользователей не ограничивает.
+// Ставится после Middleware
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return require(func(claims *Claims) bool {
+		return !claims.IsService() || claims.HasScope(scope)
+	})
+}
+
+func require(allowed func(*Claims) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := FromContext(r.Context())
+			if !ok {
+				writeError(w, http.StatusUnauthorized, "Not authenticated")
+				return
+			}
+			if !allowed(claims) {
+				writeError(w, http.StatusForbidden, "Insufficient permissions")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func unauthorizedMessage(err error) string {
+	if errors.Is(err, ErrMissingToken) {
+		return "Authorization header is required"
+	}
+	return "Invalid or expired token"
+}
+
+// writeError отвечает в том же формате, что и сервис авторизации
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
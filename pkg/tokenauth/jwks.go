@@ -0,0 +1,221 @@
+package tokenauth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultJWKSRefreshInterval = time.Hour        // Как часто перечитывать набор ключей
+	defaultJWKSMinRefresh      = 30 * time.Second // Не чаще этого при токенах с незнакомым kid
+	defaultJWKSTimeout         = 10 * time.Second
+)
+
+// JWKSOptions - настройки загрузки публичных ключей. Нулевые значения заменяются значениями по умолчанию
+type JWKSOptions struct {
+	HTTPClient *http.Client
+	// RefreshInterval - как часто перечитывать ключи, чтобы заметить выведенные из обращения
+	RefreshInterval time.Duration
+	// MinRefreshInterval - не чаще этого ключи перечитываются, если пришел токен с незнакомым kid
+	// (после ротации ключа). Защищает сервис авторизации от лишних запросов с поддельными токенами
+	MinRefreshInterval time.Duration
+}
+
+// JWKS - кэш публичных ключей из /.well-known/jwks.json сервиса авторизации
+type JWKS struct {
+	url     string
+	options JWKSOptions
+
+	mu        sync.RWMutex
+	keys      map[string]publicKey
+	fetchedAt time.Time
+	// fetching - загрузка, которая идет сейчас; остальные проверки ждут ее, а не обращаются к сервису сами
+	fetching *jwksFetch
+}
+
+type jwksFetch struct {
+	done chan struct{}
+	err  error
+}
+
+type publicKey struct {
+	alg string
+	key any
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+}
+
+// NewJWKS создает кэш ключей. Ключи загружаются при первой проверке токена
+func NewJWKS(url string, options JWKSOptions) *JWKS {
+	if options.HTTPClient == nil {
+		options.HTTPClient = &http.Client{Timeout: defaultJWKSTimeout}
+	}
+	if options.RefreshInterval <= 0 {
+		options.RefreshInterval = defaultJWKSRefreshInterval
+	}
+	if options.MinRefreshInterval <= 0 {
+		options.MinRefreshInterval = defaultJWKSMinRefresh
+	}
+	return &JWKS{url: url, options: options}
+}
+
+// NewJWKSVerifier проверяет токены, подписанные RS256 или EdDSA, ключами из jwksURL, например
+// https://auth.itam.com/.well-known/jwks.json
+func NewJWKSVerifier(jwksURL string, options JWKSOptions) *Verifier {
+	return NewVerifier(NewJWKS(jwksURL, options))
+}
+
+// VerificationKey возвращает ключ по kid. Устаревший набор перечитывается; незнакомый kid тоже
+// приводит к повторной загрузке, но не чаще MinRefreshInterval. Загрузка идет без блокировки кэша:
+// проверки токенов с известными ключами ее не ждут
+func (j *JWKS) VerificationKey(ctx context.Context, kid, alg string) (any, error) {
+	if kid == "" {
+		return nil, fmt.Errorf("token has no key ID")
+	}
+
+	key, known, stale := j.lookup(kid)
+	if stale {
+		if err := j.refresh(ctx); err != nil {
+			// Пока сервис авторизации недоступен, проверяем уже загруженными ключами
+			if !j.loaded() {
+				return nil, err
+			}
+			log.Printf("Failed to refresh JWKS (url=%s): %v", j.url, err)
+		}
+		key, known, _ = j.lookup(kid)
+	}
+
+	if !known {
+		return nil, fmt.Errorf("unknown signing key: %s", kid)
+	}
+	if key.alg != alg {
+		return nil, fmt.Errorf("unexpected signing method: %s", alg)
+	}
+	return key.key, nil
+}
+
+// lookup возвращает ключ по kid и сообщает, пора ли перечитать набор
+func (j *JWKS) lookup(kid string) (publicKey, bool, bool) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	key, known := j.keys[kid]
+	since := time.Since(j.fetchedAt)
+	return key, known, since > j.options.RefreshInterval || (!known && since > j.options.MinRefreshInterval)
+}
+
+func (j *JWKS) loaded() bool {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.keys != nil
+}
+
+// refresh перечитывает набор ключей. Одновременные вызовы ждут одну загрузку, а сама загрузка идет без j.mu
+func (j *JWKS) refresh(ctx context.Context) error {
+	j.mu.Lock()
+	if call := j.fetching; call != nil {
+		j.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	call := &jwksFetch{done: make(chan struct{})}
+	j.fetching = call
+	j.mu.Unlock()
+
+	// Результат нужен всем ожидающим, поэтому отмена запроса, который начал загрузку, ее не прерывает
+	keys, err := j.fetch(context.WithoutCancel(ctx))
+
+	j.mu.Lock()
+	// Неудачная попытка тоже считается загрузкой, чтобы не обращаться к сервису на каждый запрос
+	j.fetchedAt = time.Now()
+	if err == nil {
+		j.keys = keys
+	}
+	call.err = err
+	j.fetching = nil
+	j.mu.Unlock()
+	close(call.done)
+	return err
+}
+
+// fetch загружает набор ключей
+func (j *JWKS) fetch(ctx context.Context) (map[string]publicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+	resp, err := j.options.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]publicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			log.Printf("Skipping JWKS key (kid=%s): %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (publicKey, error) {
+	switch {
+	case k.Kty == "RSA" && k.Alg == "RS256":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return publicKey{}, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return publicKey{}, fmt.Errorf("invalid exponent: %w", err)
+		}
+		return publicKey{alg: k.Alg, key: &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}}, nil
+	case k.Kty == "OKP" && k.Crv == "Ed25519" && k.Alg == "EdDSA":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return publicKey{}, fmt.Errorf("invalid Ed25519 key")
+		}
+		return publicKey{alg: k.Alg, key: ed25519.PublicKey(x)}, nil
+	default:
+		return publicKey{}, fmt.Errorf("unsupported key type %s/%s", k.Kty, k.Alg)
+	}
+}
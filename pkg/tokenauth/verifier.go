@@ -0,0 +1,91 @@
+// Package tokenauth проверяет access-токены сервиса авторизации ITaM в других сервисах: по общему
+// HMAC-секрету или по публичным ключам из /.well-known/jwks.json. Есть middleware для net/http и gin.
+//
+// Проверка локальная и не видит отзыва токенов (logout, смена пароля): токены живут 15 минут. Там, где
+// это важно, токен проверяется через /auth/oidc/introspect
+package tokenauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	accessTokenType = "access"
+	adminPrefix     = "admin_"
+)
+
+var (
+	// ErrMissingToken - в запросе нет токена
+	ErrMissingToken = errors.New("token is missing")
+	// ErrInvalidToken - подпись, срок действия или тип токена не подходят
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// KeySource возвращает ключ проверки подписи по kid и алгоритму из заголовка токена
+type KeySource interface {
+	VerificationKey(ctx context.Context, kid, alg string) (any, error)
+}
+
+// Verifier проверяет access-токены
+type Verifier struct {
+	keys KeySource
+}
+
+// NewVerifier создает проверку токенов с произвольным источником ключей
+func NewVerifier(keys KeySource) *Verifier {
+	return &Verifier{keys: keys}
+}
+
+// NewHMACVerifier проверяет токены, подписанные HS256 общим секретом (JWT_SECRET_KEY сервиса авторизации)
+func NewHMACVerifier(secret []byte) *Verifier {
+	return NewVerifier(hmacKey(secret))
+}
+
+// Verify проверяет подпись, срок действия и тип токена и возвращает его claims. Ошибки
+// оборачивают ErrInvalidToken
+func (v *Verifier) Verify(ctx context.Context, tokenString string) (*Claims, error) {
+	if tokenString == "" {
+		return nil, ErrMissingToken
+	}
+
+	keyFunc := func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.keys.VerificationKey(ctx, kid, token.Method.Alg())
+	}
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, keyFunc,
+		jwt.WithValidMethods([]string{"HS256", "RS256", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	// Токены, выпущенные до появления typ, считаются access-токенами
+	if claims.TokenType != "" && claims.TokenType != accessTokenType {
+		return nil, fmt.Errorf("%w: %s token cannot be used as access token", ErrInvalidToken, claims.TokenType)
+	}
+	if !claims.IsService() && claims.UserID().String() != claims.UID {
+		return nil, fmt.Errorf("%w: invalid user ID", ErrInvalidToken)
+	}
+	// Токен, выданный пользователем стороннему приложению через OpenID Connect, годится только для userinfo
+	if !claims.IsService() && claims.ClientID != "" {
+		return nil, fmt.Errorf("%w: token was issued to application %s", ErrInvalidToken, claims.ClientID)
+	}
+
+	return claims, nil
+}
+
+type hmacKey []byte
+
+func (k hmacKey) VerificationKey(_ context.Context, _, alg string) (any, error) {
+	if alg != jwt.SigningMethodHS256.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %s", alg)
+	}
+	return []byte(k), nil
+}